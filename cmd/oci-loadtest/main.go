@@ -0,0 +1,117 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command oci-loadtest repeatedly drives the Attach->MountDevice->
+// UnmountDevice->Detach critical path against the oci-null loopback driver
+// and reports latency statistics, so that regressions in this path's wait
+// loops can be tracked over releases without requiring real OCI credentials.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/nulldriver"
+)
+
+func main() {
+	iterations := flag.Int("iterations", 20, "number of Attach->MountDevice->UnmountDevice->Detach cycles to run")
+	flag.Parse()
+
+	log.SetOutput(os.Stderr)
+
+	d := nulldriver.New()
+	if res := d.Init(); res.Status != flexvolume.StatusSuccess {
+		log.Fatalf("Init() failed: %+v", res)
+	}
+
+	durations := make([]time.Duration, 0, *iterations)
+	for i := 0; i < *iterations; i++ {
+		duration, err := runCycle(d, fmt.Sprintf("loadtest-volume-%d", i))
+		if err != nil {
+			log.Fatalf("cycle %d failed: %v", i, err)
+		}
+		durations = append(durations, duration)
+	}
+
+	report(durations)
+}
+
+func runCycle(d *nulldriver.NullFlexvolumeDriver, volumeName string) (time.Duration, error) {
+	opts := flexvolume.Options{
+		"kubernetes.io/fsType":         "ext4",
+		"kubernetes.io/pvOrVolumeName": volumeName,
+		"kubernetes.io/readwrite":      "rw",
+	}
+
+	start := time.Now()
+
+	res := d.Attach(opts, "loadtest-node")
+	if res.Status != flexvolume.StatusSuccess {
+		return 0, fmt.Errorf("Attach(): %+v", res)
+	}
+
+	res = d.WaitForAttach(res.Device, opts)
+	if res.Status != flexvolume.StatusSuccess {
+		return 0, fmt.Errorf("WaitForAttach(): %+v", res)
+	}
+	device := res.Device
+
+	mountDir, err := ioutil.TempDir("", "oci-loadtest")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(mountDir)
+
+	res = d.MountDevice(mountDir, device, opts)
+	if res.Status != flexvolume.StatusSuccess {
+		return 0, fmt.Errorf("MountDevice(): %+v", res)
+	}
+
+	elapsed := time.Since(start)
+
+	if res := d.UnmountDevice(mountDir); res.Status != flexvolume.StatusSuccess {
+		return 0, fmt.Errorf("UnmountDevice(): %+v", res)
+	}
+	if res := d.Detach(volumeName, "loadtest-node"); res.Status != flexvolume.StatusSuccess {
+		return 0, fmt.Errorf("Detach(): %+v", res)
+	}
+	os.Remove(filepath.Join(nulldriver.GetStateDirectory(), volumeName+".img"))
+
+	return elapsed, nil
+}
+
+func report(durations []time.Duration) {
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	fmt.Printf("n=%d min=%s p50=%s max=%s mean=%s\n",
+		len(sorted),
+		sorted[0],
+		sorted[len(sorted)/2],
+		sorted[len(sorted)-1],
+		total/time.Duration(len(sorted)))
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command oci-null is a Flexvolume driver that simulates attachment with a
+// loop device instead of talking to the real OCI API and iSCSI, so that
+// kubelet<->driver plumbing can be exercised without OCI credentials.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/nulldriver"
+)
+
+// GetLogPath returns the default path to the driver log file.
+func GetLogPath() string {
+	path := os.Getenv("OCI_NULLD_LOG_DIR")
+	if path == "" {
+		path = nulldriver.GetStateDirectory()
+	}
+	return path + "/oci_null_flexvolume_driver.log"
+}
+
+func main() {
+	if err := os.MkdirAll(nulldriver.GetStateDirectory(), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "error creating state directory: %v", err)
+		os.Exit(1)
+	}
+
+	f, err := os.OpenFile(GetLogPath(), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening log file: %v", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	log.SetPrefix(fmt.Sprintf("%d ", os.Getpid()))
+	log.SetOutput(f)
+
+	flexvolume.ExecDriver(nulldriver.New(), os.Args)
+}
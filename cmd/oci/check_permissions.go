@@ -0,0 +1,67 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["check-permissions"] = checkPermissions
+}
+
+// checkPermissions exercises exactly the OCI API operations the driver
+// needs against a synthetic resource ID, and reports which of them the
+// configured identity is missing IAM policy for - so an install-time
+// policy gap shows up as a named missing permission instead of a confusing
+// Attach/Detach failure the first time a pod is scheduled. It exits 1 if
+// any permission is missing.
+func checkPermissions(args []string) int {
+	fs := flag.NewFlagSet("check-permissions", flag.ContinueOnError)
+	configPath := fs.String("config", driver.GetConfigPath(), "path to the driver's config.yaml")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	c, err := client.New(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "check-permissions: %v\n", err)
+		return 1
+	}
+
+	missing := false
+	for _, check := range c.CheckPermissions() {
+		status := "ok"
+		if !check.OK {
+			status = "MISSING"
+			missing = true
+		}
+		if check.Message != "" {
+			fmt.Printf("%-24s %s (%s)\n", check.Operation, status, check.Message)
+		} else {
+			fmt.Printf("%-24s %s\n", check.Operation, status)
+		}
+	}
+
+	if missing {
+		return 1
+	}
+	return 0
+}
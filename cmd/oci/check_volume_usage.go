@@ -0,0 +1,70 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["check-volume-usage"] = checkVolumeUsage
+}
+
+// checkVolumeUsage statfs's this node's driver-managed mounts (see
+// driver.CheckVolumeUsage) and, for any at or past the usage threshold,
+// creates a Kubernetes Event against the pod it's mounted for (see
+// driver.EmitVolumeUsageEvents). flexvolume has no CSI-style
+// NodeGetVolumeStats call the kubelet polls for this on our behalf, so
+// raising the alert is this command's job rather than the kubelet's.
+//
+// Like "janitor" and "drain-detach-queue", it's meant to be run
+// periodically by a systemd timer on worker nodes rather than left running
+// as a daemon.
+func checkVolumeUsage(args []string) int {
+	fs := flag.NewFlagSet("check-volume-usage", flag.ContinueOnError)
+	kubeconfig := fs.String("kubeconfig", driver.GetKubeconfigPath(), "path to the kubeconfig used to create usage alert events")
+	threshold := fs.Int("threshold-percent", driver.UsageAlertThresholdPercent(), "filesystem utilization percentage at or past which a volume is considered high-water")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	usages := driver.CheckVolumeUsage(*threshold)
+	if len(usages) == 0 {
+		return 0
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "check-volume-usage: %v\n", err)
+		return 1
+	}
+	k, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "check-volume-usage: %v\n", err)
+		return 1
+	}
+
+	emitted := driver.EmitVolumeUsageEvents(k, usages, time.Now())
+	fmt.Printf("check-volume-usage: %d volume(s) at or past %d%%, %d event(s) emitted\n", len(usages), *threshold, emitted)
+	return 0
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func init() {
+	adminCommands["csi"] = csi
+}
+
+// csi is meant to run a CSI Identity/Controller/Node gRPC server built on
+// pkg/oci/client, pkg/iscsi and pkg/mount - the same building blocks
+// OCIFlexvolumeDriver's Attach/Detach/MountDevice/Unmount already use - so
+// migrating off flexvolume wouldn't mean switching codebases.
+//
+// This build can't do that yet: neither a gRPC library nor the CSI spec's
+// generated types (google.golang.org/grpc,
+// github.com/container-storage-interface/spec) are vendored, and adding
+// them isn't possible from here (see Gopkg.toml/Gopkg.lock and vendor/ -
+// there's no network access to run `dep ensure` with a new constraint).
+// Rather than silently skip a "csi" command, this is wired up to fail
+// fast and explain why, the same way pkg/secret.VaultProvider does for the
+// OCI Vault secrets client it's missing.
+func csi(args []string) int {
+	fs := flag.NewFlagSet("csi", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	fmt.Fprintln(os.Stderr, "csi: not supported by this build; google.golang.org/grpc and the "+
+		"container-storage-interface/spec types are not vendored. pkg/oci/client, pkg/iscsi, and "+
+		"pkg/mount are already structured so that a future CSI server can be built directly on top "+
+		"of them once those dependencies are added.")
+	return 1
+}
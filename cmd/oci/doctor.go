@@ -0,0 +1,76 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["doctor"] = doctorCommand
+}
+
+// doctorCommand runs every standalone diagnostic this driver has - the
+// same checks as "status" plus "check-permissions" - as the one command
+// an operator reaches for first when something's wrong, instead of having
+// to already know to run both separately. Like "status", it defaults to
+// a table and supports --output=json.
+func doctorCommand(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configPath := fs.String("config", driver.GetConfigPath(), "path to the driver's config.yaml")
+	output := addOutputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "doctor: %v\n", err)
+		return 2
+	}
+
+	report := healthCheckReport{OK: true}
+	add := func(result healthCheckResult) {
+		report.Checks = append(report.Checks, result)
+		if !result.OK {
+			report.OK = false
+		}
+	}
+	add(checkConfig(*configPath))
+	add(checkAPIReachable(*configPath))
+	add(checkInstanceMetadata())
+	add(checkISCSID())
+
+	if c, err := client.New(*configPath); err != nil {
+		add(healthCheckResult{Name: "permissions", OK: false, Message: err.Error()})
+	} else {
+		for _, check := range c.CheckPermissions() {
+			add(healthCheckResult{Name: "permission:" + check.Operation, OK: check.OK, Message: check.Message})
+		}
+	}
+
+	if err := printHealthCheckReport(report, format); err != nil {
+		fmt.Fprintf(os.Stderr, "doctor: %v\n", err)
+		return 1
+	}
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
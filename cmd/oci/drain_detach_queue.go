@@ -0,0 +1,62 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/detachqueue"
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["drain-detach-queue"] = drainDetachQueue
+}
+
+// drainDetachQueue processes whatever detach requests are currently queued
+// (see driver.AsyncDetachEnabled) with bounded concurrency and rate
+// limiting, and is meant to be run periodically by a systemd timer or node
+// shutdown hook rather than left running as a daemon.
+func drainDetachQueue(args []string) int {
+	fs := flag.NewFlagSet("drain-detach-queue", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	d, err := driver.NewOCIFlexvolumeDriver()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "drain-detach-queue: %v\n", err)
+		return 1
+	}
+
+	interval := time.Duration(driver.AsyncDetachIntervalSeconds()) * time.Second
+	err = detachqueue.Drain(driver.AsyncDetachDir(), driver.AsyncDetachConcurrency(), interval, func(req detachqueue.Request) error {
+		status := d.DetachNow(req.PVOrVolumeName, req.NodeName)
+		if status.Status != flexvolume.StatusSuccess {
+			return fmt.Errorf(status.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "drain-detach-queue: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
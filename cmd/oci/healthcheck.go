@@ -0,0 +1,137 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/iscsi"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/instancemeta"
+)
+
+func init() {
+	adminCommands["healthcheck"] = healthcheck
+}
+
+// healthCheckResult is one named check's outcome, as reported by the
+// "healthcheck" admin command.
+type healthCheckResult struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// healthCheckReport is printed to stdout as JSON by "healthcheck", for a
+// DaemonSet liveness/readiness probe to parse (or simply to key off the
+// process's exit code, which is 0 iff every check's OK is true).
+type healthCheckReport struct {
+	OK     bool                `json:"ok"`
+	Checks []healthCheckResult `json:"checks"`
+}
+
+// healthcheck verifies config parseability, OCI API reachability, instance
+// metadata access, and iscsid availability, printing a JSON report to
+// stdout and exiting non-zero if any check failed. It's meant to be wired
+// up as a DaemonSet liveness/readiness probe (`oci healthcheck`) rather
+// than run from a timer like "janitor" or "check-volume-usage" - a probe
+// needs a single fast command that fails loudly, not a log line.
+func healthcheck(args []string) int {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	configPath := fs.String("config", driver.GetConfigPath(), "path to the driver's config.yaml")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	report := healthCheckReport{OK: true}
+	add := func(result healthCheckResult) {
+		report.Checks = append(report.Checks, result)
+		if !result.OK {
+			report.OK = false
+		}
+	}
+
+	add(checkConfig(*configPath))
+	add(checkAPIReachable(*configPath))
+	add(checkInstanceMetadata())
+	add(checkISCSID())
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthcheck: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(b))
+
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// checkConfig verifies configPath parses, if present at all. A worker node
+// has no config.yaml by design (see OCIFlexvolumeDriver.master), so a
+// missing file is reported as OK rather than a failure.
+func checkConfig(configPath string) healthCheckResult {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return healthCheckResult{Name: "config", OK: true, Message: "no config present; assuming a worker node"}
+	}
+
+	if _, err := client.ConfigFromFile(configPath); err != nil {
+		return healthCheckResult{Name: "config", OK: false, Message: err.Error()}
+	}
+	return healthCheckResult{Name: "config", OK: true}
+}
+
+// checkAPIReachable verifies the OCI Compute API is reachable, skipping
+// the check entirely (reported as OK) on a worker node with no config to
+// build a client from.
+func checkAPIReachable(configPath string) healthCheckResult {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return healthCheckResult{Name: "oci-api", OK: true, Message: "no config present; assuming a worker node"}
+	}
+
+	c, err := client.New(configPath)
+	if err != nil {
+		return healthCheckResult{Name: "oci-api", OK: false, Message: err.Error()}
+	}
+	if err := c.CheckAPIReachable(); err != nil {
+		return healthCheckResult{Name: "oci-api", OK: false, Message: err.Error()}
+	}
+	return healthCheckResult{Name: "oci-api", OK: true}
+}
+
+// checkInstanceMetadata verifies the node-local OCI instance metadata
+// endpoint is reachable, the same dependency Init() already fails fast on
+// for a worker node (see newInstanceMetadata in pkg/oci/driver).
+func checkInstanceMetadata() healthCheckResult {
+	if _, err := instancemeta.New().Get(); err != nil {
+		return healthCheckResult{Name: "instance-metadata", OK: false, Message: err.Error()}
+	}
+	return healthCheckResult{Name: "instance-metadata", OK: true}
+}
+
+// checkISCSID verifies iscsid is installed and reachable, without which
+// every Attach/MountDevice on this node would fail.
+func checkISCSID() healthCheckResult {
+	if err := iscsi.CheckDaemon(); err != nil {
+		return healthCheckResult{Name: "iscsid", OK: false, Message: err.Error()}
+	}
+	return healthCheckResult{Name: "iscsid", OK: true}
+}
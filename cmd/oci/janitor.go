@@ -0,0 +1,46 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["janitor"] = janitorCommand
+}
+
+// janitorCommand removes abandoned mount tracking, IsAttached cache, detach
+// queue, and udev symlink entries, preventing this driver's node-local state
+// from accumulating cruft over a node's lifetime. Like "drain-detach-queue",
+// it's meant to be run periodically by a systemd timer rather than left
+// running as a daemon.
+func janitorCommand(args []string) int {
+	fs := flag.NewFlagSet("janitor", flag.ContinueOnError)
+	maxAge := fs.Duration("max-age", driver.JanitorMaxAge(), "age past which a state record with no corresponding attachment is considered abandoned")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	report := driver.RunJanitor(time.Now, *maxAge)
+	fmt.Printf("janitor: removed %d expired isattached cache entries, %d stale mount tracking entries, %d stale detach queue entries, %d stale udev symlinks\n",
+		report.ExpiredIsAttachedEntries, report.StaleMountTrackingEntries, report.StaleDetachQueueEntries, report.StaleUdevSymlinks)
+
+	return 0
+}
@@ -15,17 +15,31 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/oracle/oci-flexvolume-driver/pkg/buflog"
 	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/logrotate"
 	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+	"github.com/oracle/oci-flexvolume-driver/pkg/version"
 )
 
-// version/build is set at build time to the version of the driver being built.
-var version string
-var build string
+// adminCommand is an operator-facing subcommand, as opposed to a Flexvolume
+// call-out invoked by the kubelet.
+type adminCommand func(args []string) int
+
+// adminCommands holds every operator command (e.g. "status"), each
+// registered from its own file's init(), so adding one never needs
+// another round of flag-parsing plumbing here.
+var adminCommands = map[string]adminCommand{}
 
 // GetLogPath returns the default path to the driver log file.
 func GetLogPath() string {
@@ -36,25 +50,175 @@ func GetLogPath() string {
 	return path + "/oci_flexvolume_driver.log"
 }
 
+// GetLogAsync reports whether log writes should be handed off to a
+// background goroutine instead of happening on the callout's own goroutine,
+// overridable via OCI_FLEXD_LOG_ASYNC. There's no long-running driver
+// daemon to delegate to in this architecture (each callout is its own
+// short-lived process, see AsyncDetachEnabled for where the same
+// constraint comes up for Detach); "asynchronous" here means moved off the
+// callout's critical path within that process, not to a separate one.
+func GetLogAsync() bool {
+	return os.Getenv("OCI_FLEXD_LOG_ASYNC") != ""
+}
+
+// GetLogLevel returns the default logging verbosity, overridable via
+// OCI_FLEXD_LOG_LEVEL and, in turn, by the -log-level flag. It defaults to
+// "debug" so a driver that's never had its level configured behaves the
+// way this driver always has: everything reaches the log file.
+func GetLogLevel() string {
+	if l := os.Getenv("OCI_FLEXD_LOG_LEVEL"); l != "" {
+		return l
+	}
+	return "debug"
+}
+
+// defaultLogMaxSizeMB and defaultLogMaxBackups bound the log file this
+// driver appends to forever otherwise, as a short-lived callout process has
+// no long-running daemon to send it a SIGHUP to reopen its log file, the
+// way logrotate normally expects to manage a log.
+const (
+	defaultLogMaxSizeMB  = 10
+	defaultLogMaxBackups = 5
+)
+
+// GetLogMaxSizeMB returns the log file size, in megabytes, at or past which
+// it's rotated, overridable via OCI_FLEXD_LOG_MAX_SIZE_MB.
+func GetLogMaxSizeMB() int {
+	return intFromEnv("OCI_FLEXD_LOG_MAX_SIZE_MB", defaultLogMaxSizeMB)
+}
+
+// GetLogMaxBackups returns the number of rotated log files kept alongside
+// the active one, overridable via OCI_FLEXD_LOG_MAX_BACKUPS. 0 means the
+// log file is truncated rather than rotated when it hits GetLogMaxSizeMB.
+func GetLogMaxBackups() int {
+	return intFromEnv("OCI_FLEXD_LOG_MAX_BACKUPS", defaultLogMaxBackups)
+}
+
+func intFromEnv(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
 func main() {
-	// TODO: Maybe use sirupsen/logrus?
-	f, err := os.OpenFile(GetLogPath(), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	os.Exit(run(os.Args))
+}
+
+// run parses flags and dispatches to either an admin command or the
+// Flexvolume driver itself. The kubelet always execs this binary as
+// "<binary> <call-out> <args...>" with no leading flags, and flag.Parse
+// stops at the first non-flag argument, so a call-out invocation passes
+// through fs.Args() untouched and is handled exactly as before.
+func run(args []string) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	configPath := fs.String("config", driver.GetConfigPath(), "path to the driver's config.yaml")
+	logLevel := fs.String("log-level", GetLogLevel(), `log verbosity for this invocation: "debug", "info", "warn", "error", or "quiet"`)
+	driverName := fs.String("driver", "oci", fmt.Sprintf("driver to operate on: %s", strings.Join(drivers.Names(), ", ")))
+	dryRun := fs.Bool("dry-run", driver.DryRunEnabled(), "resolve the instance and volume and log what Attach/Detach would do, without performing it")
+	help := fs.Bool("help", false, "show this help text and exit")
+	fs.Usage = func() { printUsage(fs) }
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if *help {
+		printUsage(fs)
+		return 0
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		printUsage(fs)
+		return 0
+	}
+
+	if cmd, ok := adminCommands[rest[0]]; ok {
+		return cmd(rest[1:])
+	}
+
+	return runFlexvolumeDriver(*configPath, *logLevel, *driverName, *dryRun, append([]string{args[0]}, rest...))
+}
+
+func printUsage(fs *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	fmt.Fprintf(os.Stderr, "  %s <call-out> <args...>   invoked by the kubelet as a Flexvolume driver\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s [flags] <command>      invoked directly for operator commands\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	fs.PrintDefaults()
+	if len(adminCommands) > 0 {
+		fmt.Fprintf(os.Stderr, "\nCommands:\n")
+		for name := range adminCommands {
+			fmt.Fprintf(os.Stderr, "  %s\n", name)
+		}
+	}
+}
+
+// runFlexvolumeDriver opens the log file, builds the requested driver and
+// hands off to flexvolume.ExecDriver, which always exits the process itself.
+func runFlexvolumeDriver(configPath, logLevel, driverName string, dryRun bool, args []string) int {
+	if configPath != driver.GetConfigPath() {
+		os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", filepath.Dir(configPath))
+	}
+	if dryRun {
+		os.Setenv("OCI_FLEXD_DRY_RUN", "1")
+	} else {
+		os.Unsetenv("OCI_FLEXD_DRY_RUN")
+	}
+
+	logPath := GetLogPath()
+	if err := logrotate.RotateIfNeeded(logPath, int64(GetLogMaxSizeMB())*1024*1024, GetLogMaxBackups()); err != nil {
+		// A failed rotation still leaves the log file writable; don't fail
+		// the callout over it, just let the operator know why it's growing.
+		fmt.Fprintf(os.Stderr, "error rotating log file: %v", err)
+	}
+
+	f, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error opening log file: %v", err)
-		os.Exit(1)
+		return 1
 	}
 	defer f.Close()
 
 	log.SetPrefix(fmt.Sprintf("%d ", os.Getpid()))
+	if logLevel == "quiet" {
+		log.SetOutput(ioutil.Discard)
+	} else {
+		level, err := loglevel.ParseLevel(logLevel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v", err)
+			return 2
+		}
+		loglevel.SetLevel(level)
 
-	log.SetOutput(f)
+		w := buflog.New(f, GetLogAsync())
+		log.SetOutput(w)
+
+		// ExitWithResult exits via os.Exit, which skips deferred calls, so
+		// the buffered writer must be flushed from this hook instead of a
+		// defer. The defer below still covers every other return path out
+		// of this function (e.g. drivers.New failing).
+		flexvolume.SetBeforeExit(func() { w.Flush() })
+		defer w.Flush()
+	}
 
-	log.Printf("OCI FlexVolume Driver version: %s (%s)", version, build)
-	d, err := driver.NewOCIFlexvolumeDriver()
+	loglevel.Infof("OCI FlexVolume Driver version: %s (%s)", version.Version, version.Build)
+	d, err := drivers.New(driverName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error creating new driver: %v", err)
-		log.Printf("error creating new driver: %v", err)
-		os.Exit(1)
+		loglevel.Errorf("error creating new driver: %v", err)
+		return 1
 	}
-	flexvolume.ExecDriver(d, os.Args)
+	d = newTimeoutDriver(d, GetCalloutTimeout())
+	d = newInstrumentedDriver(d, GetMetricsDir())
+	flexvolume.ExecDriver(d, args)
+	return 0
 }
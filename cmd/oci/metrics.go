@@ -0,0 +1,120 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/metrics"
+)
+
+// GetMetricsDir returns the node_exporter textfile collector directory this
+// driver should write oci_flexvolume_driver.prom (metrics.TextfileName) into, overridable
+// via OCI_FLEXD_METRICS_DIR. It defaults to "", meaning metrics recording is
+// disabled - most callers don't run node_exporter's textfile collector at
+// all, and recording on every callout is wasted work (and a wasted failure
+// mode) for them.
+func GetMetricsDir() string {
+	return os.Getenv("OCI_FLEXD_METRICS_DIR")
+}
+
+// instrumentedDriver wraps a Driver, recording each call-out's outcome and
+// duration to dir via metrics.RecordCallout before returning the
+// unmodified result. It exists here, rather than in pkg/flexvolume or
+// pkg/oci/driver, because which directory (if any) to record into is
+// wiring the binary's composition root already owns (see GetMetricsDir).
+type instrumentedDriver struct {
+	next flexvolume.Driver
+	dir  string
+}
+
+// newInstrumentedDriver wraps next so that every call-out it serves is
+// recorded under dir, or returns next unchanged if dir is empty.
+func newInstrumentedDriver(next flexvolume.Driver, dir string) flexvolume.Driver {
+	if dir == "" {
+		return next
+	}
+	return &instrumentedDriver{next: next, dir: dir}
+}
+
+func (d *instrumentedDriver) record(callout string, start time.Time, status flexvolume.DriverStatus) flexvolume.DriverStatus {
+	if err := metrics.RecordCallout(d.dir, callout, string(status.Status), time.Since(start)); err != nil {
+		loglevel.Warnf("failed to record %s metrics: %v", callout, err)
+	}
+	return status
+}
+
+func (d *instrumentedDriver) Init() flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("init", start, d.next.Init())
+}
+
+func (d *instrumentedDriver) Attach(opts flexvolume.Options, nodeName string) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("attach", start, d.next.Attach(opts, nodeName))
+}
+
+func (d *instrumentedDriver) Detach(mountDevice, nodeName string) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("detach", start, d.next.Detach(mountDevice, nodeName))
+}
+
+func (d *instrumentedDriver) WaitForAttach(mountDevice string, opts flexvolume.Options) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("waitforattach", start, d.next.WaitForAttach(mountDevice, opts))
+}
+
+func (d *instrumentedDriver) IsAttached(opts flexvolume.Options, nodeName string) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("isattached", start, d.next.IsAttached(opts, nodeName))
+}
+
+func (d *instrumentedDriver) GetVolumeName(opts flexvolume.Options) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("getvolumename", start, d.next.GetVolumeName(opts))
+}
+
+func (d *instrumentedDriver) ExpandVolume(devicePath string, opts flexvolume.Options, newSize, oldSize string) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("expandvolume", start, d.next.ExpandVolume(devicePath, opts, newSize, oldSize))
+}
+
+func (d *instrumentedDriver) ExpandFS(devicePath, deviceMountPath string, opts flexvolume.Options, newSize, oldSize string) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("expandfs", start, d.next.ExpandFS(devicePath, deviceMountPath, opts, newSize, oldSize))
+}
+
+func (d *instrumentedDriver) MountDevice(mountDir, mountDevice string, opts flexvolume.Options) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("mountdevice", start, d.next.MountDevice(mountDir, mountDevice, opts))
+}
+
+func (d *instrumentedDriver) UnmountDevice(mountDevice string) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("unmountdevice", start, d.next.UnmountDevice(mountDevice))
+}
+
+func (d *instrumentedDriver) Mount(mountDir string, opts flexvolume.Options) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("mount", start, d.next.Mount(mountDir, opts))
+}
+
+func (d *instrumentedDriver) Unmount(mountDir string) flexvolume.DriverStatus {
+	start := time.Now()
+	return d.record("unmount", start, d.next.Unmount(mountDir))
+}
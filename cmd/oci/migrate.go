@@ -0,0 +1,131 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+// flexvolumeDriverName is the value this driver registers under in a PV's
+// spec.flexVolume.driver field. See example/nginx.yaml.
+const flexvolumeDriverName = "oracle/oci"
+
+// pvOrVolumeNameOptionKey is the flexvolume option the kubelet injects with
+// the PV's (or inline volume's) name. Mirrors the literal used throughout
+// pkg/oci/driver/driver.go.
+const pvOrVolumeNameOptionKey = "kubernetes.io/pvOrVolumeName"
+
+func init() {
+	adminCommands["migrate-to-csi"] = migrateToCSI
+}
+
+// migrateToCSI rewrites PersistentVolumes provisioned by this flexvolume
+// driver into equivalent CSI PersistentVolumes, preserving the volume OCID
+// (as VolumeHandle), FSType and mount options. It's intentionally narrow: it
+// only touches the PV objects in the API server, leaving attach/detach,
+// StorageClass migration and any running pods to the cluster operator and
+// the CSI driver this is migrating to.
+func migrateToCSI(args []string) int {
+	fs := flag.NewFlagSet("migrate-to-csi", flag.ContinueOnError)
+	kubeconfig := fs.String("kubeconfig", driver.GetKubeconfigPath(), "path to the kubeconfig used to list and update PersistentVolumes")
+	csiDriverName := fs.String("csi-driver-name", "blockvolume.csi.oraclecloud.com", "CSI driver name to write into the migrated PersistentVolumeSource")
+	dryRun := fs.Bool("dry-run", true, "print the PVs that would be migrated instead of updating them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-to-csi: %v\n", err)
+		return 1
+	}
+	k, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-to-csi: %v\n", err)
+		return 1
+	}
+
+	c, err := client.New(driver.GetConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-to-csi: loading driver config: %v\n", err)
+		return 1
+	}
+	regionKey := c.GetConfig().Auth.RegionKey
+
+	pvs, err := k.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-to-csi: %v\n", err)
+		return 1
+	}
+
+	migrated, skipped := 0, 0
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		if pv.Spec.FlexVolume == nil || pv.Spec.FlexVolume.Driver != flexvolumeDriverName {
+			continue
+		}
+
+		volumeName := pv.Spec.FlexVolume.Options[pvOrVolumeNameOptionKey]
+		if volumeName == "" {
+			volumeName = pv.Name
+		}
+		volumeOCID := driver.DeriveVolumeOCID(regionKey, volumeName)
+
+		if _, err := c.FindVolumeAttachment(volumeOCID, ""); err != nil && err != client.ErrVolumeAttachmentNotFound {
+			fmt.Fprintf(os.Stderr, "migrate-to-csi: skipping %s: checking attachability of %s: %v\n", pv.Name, volumeOCID, err)
+			skipped++
+			continue
+		}
+
+		flex := pv.Spec.FlexVolume
+		pv.Spec.CSI = &corev1.CSIPersistentVolumeSource{
+			Driver:       *csiDriverName,
+			VolumeHandle: volumeOCID,
+			ReadOnly:     flex.ReadOnly,
+			FSType:       flex.FSType,
+		}
+		pv.Spec.FlexVolume = nil
+
+		if *dryRun {
+			fmt.Printf("would migrate %s: flexVolume -> csi (driver=%s, volumeHandle=%s)\n", pv.Name, *csiDriverName, volumeOCID)
+			migrated++
+			continue
+		}
+
+		if _, err := k.CoreV1().PersistentVolumes().Update(pv); err != nil {
+			fmt.Fprintf(os.Stderr, "migrate-to-csi: updating %s: %v\n", pv.Name, err)
+			skipped++
+			continue
+		}
+		fmt.Printf("migrated %s: flexVolume -> csi (driver=%s, volumeHandle=%s)\n", pv.Name, *csiDriverName, volumeOCID)
+		migrated++
+	}
+
+	fmt.Printf("migrate-to-csi: %d migrated, %d skipped\n", migrated, skipped)
+	if *dryRun {
+		fmt.Println("migrate-to-csi: dry run - no PVs were updated. Pass -dry-run=false to apply.")
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// outputFormat selects how an interactive admin command renders its
+// result: a table for a human reading a terminal, or JSON for a script.
+// "status", "doctor" and "cleanup" share this rather than each growing
+// their own --output flag and rendering, so they stay visually
+// consistent with each other. It has no bearing on the strict JSON
+// flexvolume.DriverStatus protocol kubelet callouts use - that's written
+// by flexvolume.ExecDriver, not through here.
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+)
+
+// addOutputFlag registers the shared --output flag on fs, defaulting to
+// table. Call parseOutputFormat on the result after fs.Parse.
+func addOutputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", string(outputTable), `result format: "table" or "json"`)
+}
+
+func parseOutputFormat(s string) (outputFormat, error) {
+	switch outputFormat(s) {
+	case outputTable, outputJSON:
+		return outputFormat(s), nil
+	default:
+		return "", fmt.Errorf("unknown --output %q: want %q or %q", s, outputTable, outputJSON)
+	}
+}
+
+// printTable writes rows as a left-aligned, space-padded table with
+// headers to stdout.
+func printTable(headers []string, rows [][]string) {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = len(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	printRow := func(row []string) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		fmt.Println(strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+
+	printRow(headers)
+	for _, row := range rows {
+		printRow(row)
+	}
+}
+
+// printJSON marshals v to stdout, matching the wire format "healthcheck"
+// already printed before status/doctor/cleanup needed to share it.
+func printJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["reconcile"] = reconcileCommand
+}
+
+// reconcileCommand lists every cluster node's OCI volume attachments,
+// cross-checks them against that node's reported status.volumesAttached,
+// and detaches any left orphaned by a crashed node or a Detach call that
+// updated OCI but never made it back to the apiserver. Unlike
+// "drain-detach-queue" and "janitor", which tidy up this node's own
+// on-disk state, reconcile needs a cluster-wide view, so it's meant to be
+// run as a CronJob rather than a per-node systemd timer.
+func reconcileCommand(args []string) int {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report orphaned attachments without detaching them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	d, err := driver.NewOCIFlexvolumeDriver()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reconcile: %v\n", err)
+		return 1
+	}
+
+	report, err := driver.Reconcile(d, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reconcile: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("reconcile: checked %d attachments, found %d orphaned, detached %d, %d errors\n",
+		report.Checked, report.Orphaned, report.Detached, len(report.Errors))
+	for _, err := range report.Errors {
+		fmt.Fprintf(os.Stderr, "reconcile: %v\n", err)
+	}
+
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
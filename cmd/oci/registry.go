@@ -0,0 +1,101 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/nulldriver"
+)
+
+// driverFactory lazily constructs a flexvolume.Driver for a single --driver
+// name. It's called fresh for every invocation rather than once at
+// registration time, so a driver with expensive or failure-prone
+// construction (e.g. OCI's, which reads config.yaml and builds a Kube
+// client) is only ever constructed for the --driver actually requested.
+// Each driver threads its own config through its own package (OCI's via
+// OCI_FLEXD_CONFIG_DIRECTORY/GetConfigPath, as runFlexvolumeDriver already
+// sets up) rather than a factory parameter, so factories here take none.
+type driverFactory func() (flexvolume.Driver, error)
+
+// driverRegistry maps a --driver name to the factory that constructs it.
+// The zero value is not usable; use newDriverRegistry. Safe for concurrent
+// Register and New calls, though in practice every Register happens from
+// an init() before main() runs and every New happens once per process.
+type driverRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]driverFactory
+}
+
+func newDriverRegistry() *driverRegistry {
+	return &driverRegistry{factories: map[string]driverFactory{}}
+}
+
+// drivers is the registry every --driver name is resolved against.
+var drivers = newDriverRegistry()
+
+func init() {
+	drivers.Register("oci", func() (flexvolume.Driver, error) { return driver.NewOCIFlexvolumeDriver() })
+	drivers.Register("null", func() (flexvolume.Driver, error) { return nulldriver.New(), nil })
+}
+
+// Register adds name to the registry. A second Register under the same
+// name replaces the first factory outright, rather than the previous
+// behavior of calling through to whichever factory was looked up, which
+// made Register's replacement silently unobservable.
+func (r *driverRegistry) Register(name string, factory driverFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[name] = factory
+}
+
+// UnknownDriverError is returned by driverRegistry.New for a --driver name
+// with no registered factory.
+type UnknownDriverError struct {
+	Name string
+}
+
+func (e *UnknownDriverError) Error() string {
+	return fmt.Sprintf("unknown driver %q", e.Name)
+}
+
+// New looks up name's factory and invokes it, constructing a fresh driver
+// instance on every call.
+func (r *driverRegistry) New(name string) (flexvolume.Driver, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, &UnknownDriverError{Name: name}
+	}
+	return factory()
+}
+
+// Names returns the registered driver names in alphabetical order, for
+// usage text and "version -json"'s supportedDrivers list.
+func (r *driverRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
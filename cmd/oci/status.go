@@ -0,0 +1,86 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["status"] = statusCommand
+}
+
+// statusCommand runs the same checks as "healthcheck" - config, OCI API
+// reachability, instance metadata, iscsid - but for a human running it at
+// a terminal rather than a DaemonSet probe: a table by default instead of
+// the JSON healthcheck always prints, with --output=json still available
+// for a script that wants the same checks without parsing table output.
+func statusCommand(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	configPath := fs.String("config", driver.GetConfigPath(), "path to the driver's config.yaml")
+	output := addOutputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "status: %v\n", err)
+		return 2
+	}
+
+	report := healthCheckReport{OK: true}
+	add := func(result healthCheckResult) {
+		report.Checks = append(report.Checks, result)
+		if !result.OK {
+			report.OK = false
+		}
+	}
+	add(checkConfig(*configPath))
+	add(checkAPIReachable(*configPath))
+	add(checkInstanceMetadata())
+	add(checkISCSID())
+
+	if err := printHealthCheckReport(report, format); err != nil {
+		fmt.Fprintf(os.Stderr, "status: %v\n", err)
+		return 1
+	}
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// printHealthCheckReport renders report per format, shared by "status"
+// and "doctor" so the two stay visually consistent.
+func printHealthCheckReport(report healthCheckReport, format outputFormat) error {
+	if format == outputJSON {
+		return printJSON(report)
+	}
+
+	rows := make([][]string, 0, len(report.Checks))
+	for _, c := range report.Checks {
+		status := "ok"
+		if !c.OK {
+			status = "FAIL"
+		}
+		rows = append(rows, []string{c.Name, status, c.Message})
+	}
+	printTable([]string{"CHECK", "STATUS", "MESSAGE"}, rows)
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+)
+
+// defaultCalloutTimeoutSeconds is comfortably shorter than the kubelet's own
+// flexvolume exec timeout (2 minutes as of this writing), so a hung call-out
+// produces this package's structured timeout failure instead of a kubelet
+// SIGKILL with nothing but an empty log to diagnose it from.
+const defaultCalloutTimeoutSeconds = 90
+
+// GetCalloutTimeout returns the wall-clock limit a single call-out gets
+// before timeoutDriver fails it rather than let it run, overridable via
+// OCI_FLEXD_CALLOUT_TIMEOUT_SECONDS. 0 disables the watchdog entirely.
+func GetCalloutTimeout() time.Duration {
+	return time.Duration(intFromEnv("OCI_FLEXD_CALLOUT_TIMEOUT_SECONDS", defaultCalloutTimeoutSeconds)) * time.Second
+}
+
+// timeoutDriver wraps a flexvolume.Driver so that every call is run on its
+// own goroutine and raced against timeout, converting an overrun into a
+// DriverStatus carrying the phase (the call-out name) that was still
+// executing rather than leaving the kubelet to SIGKILL this process with no
+// diagnosable output at all. A timed-out call's goroutine is abandoned,
+// which is safe here: ExitWithResult always exits the process immediately
+// after, so there's no daemon lifetime for the leaked goroutine to outlast.
+type timeoutDriver struct {
+	next    flexvolume.Driver
+	timeout time.Duration
+}
+
+func newTimeoutDriver(next flexvolume.Driver, timeout time.Duration) flexvolume.Driver {
+	if timeout <= 0 {
+		return next
+	}
+	return timeoutDriver{next: next, timeout: timeout}
+}
+
+func (d timeoutDriver) run(phase string, fn func() flexvolume.DriverStatus) flexvolume.DriverStatus {
+	result := make(chan flexvolume.DriverStatus, 1)
+	go func() { result <- fn() }()
+
+	select {
+	case r := <-result:
+		return r
+	case <-time.After(d.timeout):
+		fmt.Fprintf(os.Stderr, "%s: timed out after %s\n", phase, d.timeout)
+		return flexvolume.Fail(fmt.Sprintf("%s: timed out after %s", phase, d.timeout))
+	}
+}
+
+func (d timeoutDriver) Init() flexvolume.DriverStatus {
+	return d.run("init", d.next.Init)
+}
+
+func (d timeoutDriver) Attach(opts flexvolume.Options, nodeName string) flexvolume.DriverStatus {
+	return d.run("attach", func() flexvolume.DriverStatus { return d.next.Attach(opts, nodeName) })
+}
+
+func (d timeoutDriver) Detach(mountDevice, nodeName string) flexvolume.DriverStatus {
+	return d.run("detach", func() flexvolume.DriverStatus { return d.next.Detach(mountDevice, nodeName) })
+}
+
+func (d timeoutDriver) WaitForAttach(mountDevice string, opts flexvolume.Options) flexvolume.DriverStatus {
+	return d.run("waitforattach", func() flexvolume.DriverStatus { return d.next.WaitForAttach(mountDevice, opts) })
+}
+
+func (d timeoutDriver) IsAttached(opts flexvolume.Options, nodeName string) flexvolume.DriverStatus {
+	return d.run("isattached", func() flexvolume.DriverStatus { return d.next.IsAttached(opts, nodeName) })
+}
+
+func (d timeoutDriver) GetVolumeName(opts flexvolume.Options) flexvolume.DriverStatus {
+	return d.run("getvolumename", func() flexvolume.DriverStatus { return d.next.GetVolumeName(opts) })
+}
+
+func (d timeoutDriver) ExpandVolume(devicePath string, opts flexvolume.Options, newSize, oldSize string) flexvolume.DriverStatus {
+	return d.run("expandvolume", func() flexvolume.DriverStatus { return d.next.ExpandVolume(devicePath, opts, newSize, oldSize) })
+}
+
+func (d timeoutDriver) ExpandFS(devicePath, deviceMountPath string, opts flexvolume.Options, newSize, oldSize string) flexvolume.DriverStatus {
+	return d.run("expandfs", func() flexvolume.DriverStatus { return d.next.ExpandFS(devicePath, deviceMountPath, opts, newSize, oldSize) })
+}
+
+func (d timeoutDriver) MountDevice(mountDir, mountDevice string, opts flexvolume.Options) flexvolume.DriverStatus {
+	return d.run("mountdevice", func() flexvolume.DriverStatus { return d.next.MountDevice(mountDir, mountDevice, opts) })
+}
+
+func (d timeoutDriver) UnmountDevice(mountDevice string) flexvolume.DriverStatus {
+	return d.run("unmountdevice", func() flexvolume.DriverStatus { return d.next.UnmountDevice(mountDevice) })
+}
+
+func (d timeoutDriver) Mount(mountDir string, opts flexvolume.Options) flexvolume.DriverStatus {
+	return d.run("mount", func() flexvolume.DriverStatus { return d.next.Mount(mountDir, opts) })
+}
+
+func (d timeoutDriver) Unmount(mountDir string) flexvolume.DriverStatus {
+	return d.run("unmount", func() flexvolume.DriverStatus { return d.next.Unmount(mountDir) })
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["upgrade"] = upgrade
+}
+
+// upgradeVerifyTimeout bounds how long upgrade waits for the new binary to
+// answer an "init" call-out before giving up and rolling back. This is
+// meant to catch a corrupt or wrong-architecture binary, not to exercise
+// Init()'s own OCI API calls, so it is deliberately short.
+const upgradeVerifyTimeout = 10 * time.Second
+
+// upgrade replaces the installed driver binary at -dest (by default the one
+// deploy.sh installs) with the binary at the given path, without ever
+// leaving dest missing or non-executable: the new binary is written
+// alongside dest, fsync'd, and proven to speak the Flexvolume protocol
+// before it's swapped in with a rename (atomic as long as both paths are on
+// the same filesystem, which they always are here - both live in dest's
+// directory). If the new binary fails verification, or the swap itself
+// fails partway, dest is left exactly as it was found.
+//
+// This exists because a DaemonSet-driven upgrade that just overwrites the
+// binary in place (as deploy.sh's `cp`/`mv` does today) can leave a node
+// with a half-written, non-executable plugin if the container is killed or
+// the disk is full mid-copy, and the kubelet has no way to tell that apart
+// from every other flexvolume callout failure.
+func upgrade(args []string) int {
+	fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	dest := fs.String("dest", filepath.Join(driver.GetDriverDirectory(), "oci"), "path of the installed driver binary to replace")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "upgrade: expected exactly one argument, the path to the new driver binary")
+		return 2
+	}
+
+	if err := upgradeBinary(fs.Arg(0), *dest); err != nil {
+		fmt.Fprintf(os.Stderr, "upgrade: %v\n", err)
+		return 1
+	}
+	fmt.Printf("upgrade: %s is now running %s\n", *dest, fs.Arg(0))
+	return 0
+}
+
+// upgradeBinary does the actual write-new/verify/rename/rollback dance
+// described on upgrade above.
+func upgradeBinary(src, dest string) error {
+	tmp := dest + ".new"
+	if err := copyExecutable(src, tmp); err != nil {
+		return fmt.Errorf("staging new binary: %v", err)
+	}
+
+	if err := verifyDriverBinary(tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("new binary failed verification, leaving %q untouched: %v", dest, err)
+	}
+
+	bak := dest + ".bak"
+	hadExisting := false
+	if _, err := os.Stat(dest); err == nil {
+		if err := os.Rename(dest, bak); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("backing up %q: %v", dest, err)
+		}
+		hadExisting = true
+	} else if !os.IsNotExist(err) {
+		os.Remove(tmp)
+		return fmt.Errorf("statting %q: %v", dest, err)
+	}
+
+	if err := os.Rename(tmp, dest); err != nil {
+		if hadExisting {
+			if rbErr := os.Rename(bak, dest); rbErr != nil {
+				return fmt.Errorf("installing new binary: %v (rollback also failed, %q may be missing: %v)", err, dest, rbErr)
+			}
+		}
+		return fmt.Errorf("installing new binary, rolled back %q: %v", dest, err)
+	}
+
+	if hadExisting {
+		os.Remove(bak)
+	}
+	return nil
+}
+
+// copyExecutable copies src to dest, fsyncing it before close so that a
+// subsequent rename of dest can't land a partially-written file - dest is
+// either fully present or not there at all.
+func copyExecutable(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// verifyDriverBinary runs path as a Flexvolume driver "init" call-out and
+// checks that it prints a well-formed DriverStatus, the same response shape
+// the kubelet itself parses. Whether init reports success or failure
+// doesn't matter here - a worker node legitimately fails Init() when it
+// isn't running on OCI - only that the binary is executable and speaks the
+// protocol, which rules out a truncated copy or a wrong-architecture build.
+func verifyDriverBinary(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), upgradeVerifyTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "init").Output()
+	// ExitWithResult (pkg/flexvolume) deliberately exits 1 on a StatusFailure
+	// result - e.g. Init() on a worker node not running on OCI - so that's
+	// not itself proof the binary is broken; out still holds the JSON it
+	// printed before exiting. Anything else (couldn't even exec it, timed
+	// out, killed) never got as far as printing a result at all.
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return fmt.Errorf("running %q init: %v", path, err)
+		}
+	}
+
+	var status flexvolume.DriverStatus
+	if err := json.Unmarshal(out, &status); err != nil {
+		return fmt.Errorf("parsing %q init output %q: %v", path, out, err)
+	}
+	if status.Status != flexvolume.StatusSuccess && status.Status != flexvolume.StatusFailure {
+		return fmt.Errorf("%q init returned unexpected status %q", path, status.Status)
+	}
+	return nil
+}
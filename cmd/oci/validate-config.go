@@ -0,0 +1,77 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+)
+
+func init() {
+	adminCommands["validate-config"] = validateConfig
+}
+
+// validateConfig runs client.ValidateConfig against the config file at
+// args[0] (or driver.GetConfigPath() if args is empty), then exercises the
+// credentials it describes with a live API call, printing every problem
+// found instead of leaving misconfiguration to surface for the first time
+// when an attach fails on some node, hours or days later.
+func validateConfig(args []string) int {
+	fs := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	configPath := driver.GetConfigPath()
+	if fs.NArg() > 0 {
+		configPath = fs.Arg(0)
+	}
+
+	cfg, err := client.ConfigFromFile(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "validate-config: %v\n", err)
+		return 1
+	}
+
+	ok := true
+	if errs := client.ValidateConfig(cfg); len(errs) > 0 {
+		ok = false
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%v\n", e)
+		}
+	}
+
+	if ok {
+		c, err := client.New(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "validate-config: building client: %v\n", err)
+			return 1
+		}
+		if err := c.CheckAPIReachable(); err != nil {
+			fmt.Fprintf(os.Stderr, "validate-config: credentials rejected or API unreachable: %v\n", err)
+			ok = false
+		}
+	}
+
+	if !ok {
+		return 1
+	}
+	fmt.Println("config OK")
+	return 0
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/version"
+)
+
+func init() {
+	adminCommands["version"] = versionCmd
+}
+
+// versionInfo is the "version --json" output, so fleet tooling can verify
+// which driver build is installed on a node without grepping logs.
+type versionInfo struct {
+	Version          string   `json:"version"`
+	Build            string   `json:"build"`
+	GitCommit        string   `json:"gitCommit"`
+	SupportedDrivers []string `json:"supportedDrivers"`
+}
+
+// versionCmd prints the driver's version, build, and git SHA, either as the
+// same plain-text line runFlexvolumeDriver logs on every call-out, or as
+// JSON with -json for tooling to parse.
+func versionCmd(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print version info as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	info := versionInfo{
+		Version:          version.Version,
+		Build:            version.Build,
+		GitCommit:        version.GitCommit,
+		SupportedDrivers: drivers.Names(),
+	}
+
+	if !*asJSON {
+		fmt.Printf("OCI FlexVolume Driver version: %s (%s)\n", info.Version, info.Build)
+		return 0
+	}
+
+	b, err := json.Marshal(info)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "version: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(b))
+	return 0
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/detachqueue"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+	"github.com/oracle/oci-flexvolume-driver/pkg/watch"
+)
+
+func init() {
+	adminCommands["watch"] = watchCommand
+}
+
+// watchCommand streams this node's mount, attachment, and pending-detach
+// state changes to stdout as newline-delimited JSON events, so an incident
+// responder can tail recovery in real time instead of polling the OCI
+// console. It runs until interrupted (e.g. Ctrl-C).
+func watchCommand(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := fs.Duration("interval", 2*time.Second, "how often to poll for state changes")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	sources := []watch.Source{
+		{Kind: "mount", Poll: driver.MountSnapshot},
+		{Kind: "attachment", Poll: driver.IsAttachedSnapshot},
+		{Kind: "pending-detach", Poll: pendingDetachSnapshot},
+	}
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+
+	if err := watch.Run(os.Stdout, sources, *interval, time.Now, stop); err != nil {
+		fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// pendingDetachSnapshot adapts the async detach queue (see
+// driver.AsyncDetachEnabled) to a watch.Source, so queued-but-not-yet-run
+// detaches show up as their own event stream alongside mount/attachment
+// state.
+func pendingDetachSnapshot() (map[string]interface{}, error) {
+	reqs, err := detachqueue.List(driver.AsyncDetachDir())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]interface{}, len(reqs))
+	for path, req := range reqs {
+		snapshot[path] = req
+	}
+	return snapshot, nil
+}
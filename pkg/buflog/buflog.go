@@ -0,0 +1,93 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package buflog provides a buffered log.Logger output, so that a slow
+// root disk delays a Flush rather than every individual log call. Each
+// Flexvolume callout is its own short-lived process exiting via os.Exit
+// (see flexvolume.ExitWithResult), which skips deferred calls, so Flush
+// must be wired into that exit path explicitly rather than deferred.
+package buflog
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// Writer is a buffered io.Writer, optionally moving the actual write to the
+// underlying writer off the caller's goroutine. Safe for concurrent use.
+type Writer struct {
+	mu   sync.Mutex
+	buf  *bufio.Writer
+	once sync.Once
+
+	queue   chan []byte
+	drained chan struct{}
+}
+
+// New returns a Writer that buffers writes to w. If async, Write only
+// queues the write and returns; a background goroutine applies queued
+// writes to the buffer in order. Either way, writes reach w only when Flush
+// is called or the buffer (bufio's default size) fills up.
+func New(w io.Writer, async bool) *Writer {
+	bw := &Writer{buf: bufio.NewWriter(w)}
+	if async {
+		bw.queue = make(chan []byte, 256)
+		bw.drained = make(chan struct{})
+		go bw.drain()
+	}
+	return bw
+}
+
+func (w *Writer) drain() {
+	defer close(w.drained)
+	for p := range w.queue {
+		w.mu.Lock()
+		w.buf.Write(p)
+		w.mu.Unlock()
+	}
+}
+
+// Write implements io.Writer. log.Logger reuses the slice it passes to Write
+// once Write returns, so the async path queues a copy of it rather than p
+// itself.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.queue != nil {
+		w.queue <- append([]byte(nil), p...)
+		return len(p), nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+// Flush waits for any queued async writes to reach the buffer, then
+// flushes the buffer to its underlying writer. It's safe to call more than
+// once; only the first call does anything. Callers exiting via
+// flexvolume.ExitWithResult must call this through flexvolume.SetBeforeExit
+// rather than defer, since os.Exit skips deferred calls.
+func (w *Writer) Flush() error {
+	var err error
+	w.once.Do(func() {
+		if w.queue != nil {
+			close(w.queue)
+			<-w.drained
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		err = w.buf.Flush()
+	})
+	return err
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buflog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteIsBufferedUntilFlush(t *testing.T) {
+	var dst bytes.Buffer
+	w := New(&dst, false)
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write() => %v, want nil", err)
+	}
+	if dst.Len() != 0 {
+		t.Fatalf("write reached the underlying writer before Flush: %q", dst.String())
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() => %v, want nil", err)
+	}
+	if dst.String() != "hello\n" {
+		t.Errorf("dst = %q, want %q", dst.String(), "hello\n")
+	}
+}
+
+func TestAsyncWriteReachesBufferBeforeFlushReturns(t *testing.T) {
+	var dst bytes.Buffer
+	w := New(&dst, true)
+
+	for i := 0; i < 100; i++ {
+		if _, err := w.Write([]byte("line\n")); err != nil {
+			t.Fatalf("Write() => %v, want nil", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() => %v, want nil", err)
+	}
+	if dst.Len() != 100*len("line\n") {
+		t.Errorf("dst.Len() = %d, want %d", dst.Len(), 100*len("line\n"))
+	}
+}
+
+func TestFlushIsSafeToCallTwice(t *testing.T) {
+	var dst bytes.Buffer
+	w := New(&dst, true)
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("first Flush() => %v, want nil", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("second Flush() => %v, want nil", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package detachqueue implements a durable, file-backed spool of pending
+// Detach requests. It exists so a mass eviction - which fires one kubelet
+// Detach callout per volume, each normally blocking on the OCI API until
+// the detach completes - can instead be recorded cheaply and processed
+// later with bounded concurrency and rate limiting, rather than turning
+// into a detach storm against the OCI API.
+package detachqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+// Request is a single queued Detach call, persisted to disk so it survives
+// the process that enqueued it exiting.
+type Request struct {
+	PVOrVolumeName string `json:"pvOrVolumeName"`
+	NodeName       string `json:"nodeName"`
+
+	// Critical marks a request as backing cluster-critical infrastructure
+	// (see flexvolume.OptionCriticalVolume). Drain processes these ahead of
+	// everything else, so that a critical pod's volume is back before the
+	// rest of a mass rescheduling's detaches have even started.
+	Critical bool `json:"critical,omitempty"`
+}
+
+// Enqueue persists req to dir for later processing by Drain, returning the
+// path it was written to. dir is created if it doesn't already exist.
+func Enqueue(dir string, req Request) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("detachqueue: creating queue directory %q: %v", dir, err)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("detachqueue: marshaling request: %v", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%d-%s.json", time.Now().UnixNano(), sanitize(req.PVOrVolumeName)))
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return "", fmt.Errorf("detachqueue: writing request: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("detachqueue: committing request: %v", err)
+	}
+
+	return path, nil
+}
+
+// sanitize strips path-unsafe characters from volumeName so it can be used
+// as (part of) a queue entry's file name.
+func sanitize(volumeName string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, volumeName)
+}
+
+// List returns the requests currently queued in dir, keyed by the path each
+// was read from (Drain needs this to remove an entry once it's processed).
+// A missing dir is treated as an empty queue rather than an error, since
+// that's simply the state before anything has ever been enqueued.
+func List(dir string) (map[string]Request, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("detachqueue: listing queue directory %q: %v", dir, err)
+	}
+
+	reqs := make(map[string]Request, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			loglevel.Warnf("detachqueue: skipping unreadable entry %q: %v", path, err)
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			loglevel.Warnf("detachqueue: skipping malformed entry %q: %v", path, err)
+			continue
+		}
+
+		reqs[path] = req
+	}
+	return reqs, nil
+}
+
+// Remove deletes the queue entry at path once it has been successfully
+// processed.
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("detachqueue: removing entry %q: %v", path, err)
+	}
+	return nil
+}
+
+// orderByPriority returns reqs' keys with every Critical request ordered
+// ahead of non-critical ones, so Drain starts them first.
+func orderByPriority(reqs map[string]Request) []string {
+	var critical, rest []string
+	for path, req := range reqs {
+		if req.Critical {
+			critical = append(critical, path)
+		} else {
+			rest = append(rest, path)
+		}
+	}
+	return append(critical, rest...)
+}
+
+// Drain calls detach for every request currently queued in dir, running up
+// to concurrency at a time and starting no more than one every interval.
+// Critical requests (see Request.Critical) are started before any
+// non-critical one, so that under throttling or a mass rescheduling the
+// volumes backing cluster-critical pods are detached, and so reattachable
+// elsewhere, first. Requests detach fails for are left queued to retry on
+// the next Drain; everything else is removed.
+func Drain(dir string, concurrency int, interval time.Duration, detach func(req Request) error) error {
+	reqs, err := List(dir)
+	if err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, concurrency)
+	throttle := time.NewTicker(interval)
+	defer throttle.Stop()
+
+	var wg sync.WaitGroup
+	for _, path := range orderByPriority(reqs) {
+		req := reqs[path]
+		<-throttle.C
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(path string, req Request) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := detach(req); err != nil {
+				loglevel.Warnf("detachqueue: detaching %q for node %q failed, will retry: %v", req.PVOrVolumeName, req.NodeName, err)
+				return
+			}
+			if err := Remove(path); err != nil {
+				loglevel.Errorf("detachqueue: %v", err)
+			}
+		}(path, req)
+	}
+	wg.Wait()
+
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detachqueue
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEnqueueAndList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "detachqueue-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := Request{PVOrVolumeName: "my-volume", NodeName: "node-1"}
+	if _, err := Enqueue(dir, want); err != nil {
+		t.Fatalf("Enqueue() => %v; want nil", err)
+	}
+
+	reqs, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() => %v; want nil", err)
+	}
+	if len(reqs) != 1 {
+		t.Fatalf("List() returned %d requests; want 1", len(reqs))
+	}
+	for _, got := range reqs {
+		if got != want {
+			t.Errorf("List() = %+v; want %+v", got, want)
+		}
+	}
+}
+
+func TestListOnMissingDirIsEmpty(t *testing.T) {
+	reqs, err := List("/does/not/exist")
+	if err != nil {
+		t.Fatalf("List() => %v; want nil", err)
+	}
+	if len(reqs) != 0 {
+		t.Errorf("List() returned %d requests; want 0", len(reqs))
+	}
+}
+
+func TestDrainRemovesSucceededAndKeepsFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "detachqueue-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := Enqueue(dir, Request{PVOrVolumeName: "ok-volume", NodeName: "node-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Enqueue(dir, Request{PVOrVolumeName: "bad-volume", NodeName: "node-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Drain(dir, 2, time.Millisecond, func(req Request) error {
+		if req.PVOrVolumeName == "bad-volume" {
+			return fmt.Errorf("simulated detach failure")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Drain() => %v; want nil", err)
+	}
+
+	reqs, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 {
+		t.Fatalf("List() after Drain() returned %d requests; want 1", len(reqs))
+	}
+	for _, req := range reqs {
+		if req.PVOrVolumeName != "bad-volume" {
+			t.Errorf("List() after Drain() left %q queued; want only the failed entry", req.PVOrVolumeName)
+		}
+	}
+}
+
+func TestDrainRespectsConcurrency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "detachqueue-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 5; i++ {
+		if _, err := Enqueue(dir, Request{PVOrVolumeName: fmt.Sprintf("volume-%d", i), NodeName: "node-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var current, max int32
+	err = Drain(dir, 2, time.Millisecond, func(req Request) error {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Drain() => %v; want nil", err)
+	}
+	if max > 2 {
+		t.Errorf("Drain() ran up to %d detaches concurrently; want at most 2", max)
+	}
+}
+
+func TestDrainProcessesCriticalRequestsFirst(t *testing.T) {
+	dir, err := ioutil.TempDir("", "detachqueue-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 3; i++ {
+		if _, err := Enqueue(dir, Request{PVOrVolumeName: fmt.Sprintf("normal-volume-%d", i), NodeName: "node-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Enqueue(dir, Request{PVOrVolumeName: "critical-volume", NodeName: "node-1", Critical: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	err = Drain(dir, 1, time.Millisecond, func(req Request) error {
+		order = append(order, req.PVOrVolumeName)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Drain() => %v; want nil", err)
+	}
+
+	if len(order) == 0 || order[0] != "critical-volume" {
+		t.Errorf("Drain() processing order = %v; want critical-volume first", order)
+	}
+}
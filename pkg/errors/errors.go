@@ -0,0 +1,117 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errors classifies OCI API and iscsi command failures into a
+// small set of kinds, so a driver callout can decide between a fail-fast
+// DriverStatus and a message telling the kubelet (which retries the same
+// callout on its own schedule) that the failure is worth waiting out,
+// without every caller having to know the shape of an OCI service error or
+// an iscsiadm failure itself.
+package errors
+
+import (
+	"net/http"
+
+	"github.com/oracle/oci-go-sdk/common"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/iscsi"
+)
+
+// Kind classifies an error into one of a small set of buckets a caller can
+// act on without inspecting the underlying error itself.
+type Kind string
+
+const (
+	// NotFound means the thing the call operated on doesn't exist (or no
+	// longer does) - retrying without changing anything else won't help.
+	NotFound Kind = "NotFound"
+
+	// Conflict means the call failed because of the current state of the
+	// thing it operated on (e.g. a volume already attached elsewhere).
+	// Whether this is worth retrying depends on what caused the conflict,
+	// which Kind alone can't tell a caller.
+	Conflict Kind = "Conflict"
+
+	// Throttled means the call was rate-limited and should be retried
+	// after a delay.
+	Throttled Kind = "Throttled"
+
+	// Transient means the call failed for a reason that's likely to clear
+	// up on its own: a 5xx service error, or a network-level failure such
+	// as a timeout or connection reset.
+	Transient Kind = "Transient"
+
+	// Terminal means the call failed for a reason retrying won't fix.
+	Terminal Kind = "Terminal"
+)
+
+// Retryable reports whether kind represents a condition worth retrying
+// rather than failing fast on.
+func Retryable(kind Kind) bool {
+	switch kind {
+	case Throttled, Transient:
+		return true
+	default:
+		return false
+	}
+}
+
+// ClassifyAPIError inspects err - an error returned by the oci/client
+// package - and returns the Kind that best describes it. A nil err
+// classifies as the zero Kind.
+func ClassifyAPIError(err error) Kind {
+	if err == nil {
+		return Kind("")
+	}
+
+	svcErr, ok := common.IsServiceError(err)
+	if !ok {
+		// common.IsServiceError returns false for network-level failures
+		// (timeouts, connection resets, DNS lookups), which an OCI API
+		// call is as likely to hit as a real service error - the same
+		// fallback isRetryableAPIError (see oci/client/retry.go) treats as
+		// worth retrying.
+		return Transient
+	}
+
+	switch svcErr.GetHTTPStatusCode() {
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusTooManyRequests:
+		return Throttled
+	}
+	if svcErr.GetHTTPStatusCode() >= 500 {
+		return Transient
+	}
+	return Terminal
+}
+
+// ClassifyMountError inspects err - an error returned by the iscsi package
+// - and returns the Kind that best describes it. A nil err classifies as
+// the zero Kind.
+func ClassifyMountError(err error) Kind {
+	if err == nil {
+		return Kind("")
+	}
+	if err == iscsi.ErrMountPointNotFound {
+		return NotFound
+	}
+	// iscsiadm/iscsid failures (device busy, login refused, daemon
+	// unreachable, ...) don't carry enough structure here to tell a
+	// transient one from a terminal one; Terminal is the safer default
+	// since blindly retrying a real failure just repeats it.
+	return Terminal
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/iscsi"
+)
+
+func TestClassifyAPIErrorNil(t *testing.T) {
+	if got := ClassifyAPIError(nil); got != Kind("") {
+		t.Errorf("ClassifyAPIError(nil) = %v; want \"\"", got)
+	}
+}
+
+func TestClassifyAPIErrorNetworkFailure(t *testing.T) {
+	// common.IsServiceError only recognises the OCI SDK's own internal
+	// error type, so a plain network-level error (the only kind this
+	// package can construct without a real OCI API round trip) exercises
+	// the same !ok fallback isRetryableAPIError relies on.
+	if got := ClassifyAPIError(errors.New("dial tcp: i/o timeout")); got != Transient {
+		t.Errorf("ClassifyAPIError(network error) = %v; want %v", got, Transient)
+	}
+}
+
+func TestClassifyMountError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"nil", nil, Kind("")},
+		{"mount point not found", iscsi.ErrMountPointNotFound, NotFound},
+		{"other iscsi failure", errors.New("iscsi: error logging in target: exit status 1"), Terminal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyMountError(tt.err); got != tt.want {
+				t.Errorf("ClassifyMountError(%v) = %v; want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		kind Kind
+		want bool
+	}{
+		{Throttled, true},
+		{Transient, true},
+		{NotFound, false},
+		{Conflict, false},
+		{Terminal, false},
+		{Kind(""), false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.kind); got != tt.want {
+			t.Errorf("Retryable(%v) = %v; want %v", tt.kind, got, tt.want)
+		}
+	}
+}
@@ -18,14 +18,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
+	"strings"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
 )
 
 // Defined to enable overriding in tests.
 var out io.Writer = os.Stdout
 var exit = os.Exit
 
+// beforeExit runs immediately before ExitWithResult exits the process. It
+// exists because exit (os.Exit in production) terminates the process
+// without running deferred calls, so a caller that needs to flush buffered
+// state - e.g. a buffered log writer - can't rely on defer for that and
+// must hook in here instead. See SetBeforeExit.
+var beforeExit = func() {}
+
+// SetBeforeExit registers f to run immediately before ExitWithResult exits
+// the process. There is only one hook slot, since the driver has a single
+// log destination to flush.
+func SetBeforeExit(f func()) {
+	beforeExit = f
+}
+
 // Status denotes the state of a Flexvolume call.
 type Status string
 
@@ -41,7 +57,16 @@ const (
 	StatusNotSupported Status = "Not supported"
 )
 
-// DriverStatus of a Flexvolume driver call.
+// DriverStatus of a Flexvolume driver call. Its JSON encoding is relied on
+// by the kubelet (which parses it) and, in practice, by log scrapers and
+// operator tooling that diff successive callout results - so its byte
+// output needs to be stable across calls, not just structurally equal.
+// encoding/json already gives this for free here: struct fields always
+// encode in the declaration order below, and Capabilities (the only map
+// field) is always encoded with its keys sorted, so no custom MarshalJSON
+// is needed to keep the output deterministic. What callers do need to keep
+// stable themselves is Message, since it's assembled ad hoc per call site;
+// see canonicalMessage.
 type DriverStatus struct {
 	// Status of the callout. One of "Success", "Failure" or "Not supported".
 	Status Status `json:"status"`
@@ -52,21 +77,73 @@ type DriverStatus struct {
 	Device string `json:"device,omitempty"`
 	// Represents volume is attached on the node.
 	Attached bool `json:"attached,omitempty"`
+	// VolumeName returned by a getvolumename callout.
+	VolumeName string `json:"volumeName,omitempty"`
+	// Capabilities reports, keyed by callout name, which version-sensitive
+	// callouts the driver determined it can answer on this kubelet/apiserver
+	// version. Only ever set on the init response.
+	Capabilities map[string]bool `json:"capabilities,omitempty"`
 }
 
 // Option keys
 const (
-	OptionFSType    = "kubernetes.io/fsType"
-	OptionReadWrite = "kubernetes.io/readwrite"
-	OptionKeySecret = "kubernetes.io/secret"
-	OptionFSGroup   = "kubernetes.io/fsGroup"
-	OptionMountsDir = "kubernetes.io/mountsDir"
+	OptionFSType     = "kubernetes.io/fsType"
+	OptionReadWrite  = "kubernetes.io/readwrite"
+	OptionKeySecret  = "kubernetes.io/secret"
+	OptionFSGroup    = "kubernetes.io/fsGroup"
+	OptionMountsDir  = "kubernetes.io/mountsDir"
+	OptionVolumeMode = "kubernetes.io/volumeMode"
+
+	// OptionEncryptInTransit overrides the driver's configured
+	// EncryptionInTransitEnabled default (see client.Config) for a single
+	// PV, requesting (or declining) in-transit encryption of its iSCSI
+	// attachment. Recognised values are "true" and "false"; any other value
+	// (including unset) falls back to the configured default.
+	OptionEncryptInTransit = "kubernetes.io/encryptInTransit"
+
+	// OptionAttachmentAccessMode requests a shareable attachment, so that the
+	// same volume can be attached to more than one instance at once (e.g. for
+	// a ReadWriteMany/ReadOnlyMany PV). The only recognised value is
+	// "shareable"; anything else (including unset) gets an ordinary,
+	// exclusive attachment.
+	OptionAttachmentAccessMode = "kubernetes.io/attachmentAccessMode"
+
+	// OptionCriticalVolume marks a PV as backing cluster-critical
+	// infrastructure (e.g. monitoring, logging). Recognised values are
+	// "true" and "false"; anything else (including unset) is treated as
+	// not critical. The async detach queue and "drain-detach-queue"
+	// processes critical volumes' detaches first, so that critical pods
+	// evicted during a mass rescheduling recover ahead of everything else.
+	OptionCriticalVolume = "kubernetes.io/criticalVolume"
 
 	OptionKeyPodName      = "kubernetes.io/pod.name"
 	OptionKeyPodNamespace = "kubernetes.io/pod.namespace"
 	OptionKeyPodUID       = "kubernetes.io/pod.uid"
 
 	OptionKeyServiceAccountName = "kubernetes.io/serviceAccount.name"
+
+	// OptionMountOptions carries the PersistentVolume's spec.mountOptions,
+	// joined with commas, for drivers that format/mount a filesystem
+	// themselves rather than letting the kubelet's generic mounter apply
+	// them. Unset (or empty) means no extra mount options were requested.
+	OptionMountOptions = "kubernetes.io/mountOptions"
+
+	// OptionFsckBeforeMount requests a read-only consistency check of an
+	// existing filesystem before MountDevice mounts it, surfacing
+	// corruption as a failed mount instead of letting a pod start against a
+	// damaged filesystem. Recognised values are "true" and "false"; any
+	// other value (including unset) leaves this off, matching the driver's
+	// long-standing default of not second-guessing a filesystem it's about
+	// to mount.
+	OptionFsckBeforeMount = "kubernetes.io/fsckBeforeMount"
+
+	// OptionDiscard requests the "discard" mount option, so that deleted
+	// blocks are unmapped and released back to OCI block storage instead
+	// of sitting around allocated until the whole volume is deleted.
+	// Recognised values are "true" and "false"; anything else (including
+	// unset) leaves it off. It's silently dropped rather than failing the
+	// mount if the attached device doesn't actually support discard.
+	OptionDiscard = "kubernetes.io/discard"
 )
 
 // Driver is the main Flexvolume interface.
@@ -76,6 +153,17 @@ type Driver interface {
 	Detach(mountDevice, nodeName string) DriverStatus
 	WaitForAttach(mountDevice string, opts Options) DriverStatus
 	IsAttached(opts Options, nodeName string) DriverStatus
+	GetVolumeName(opts Options) DriverStatus
+
+	// ExpandVolume grows the underlying block device at devicePath to
+	// newSize (from oldSize). It's called before the device is mounted, so
+	// it must not assume a filesystem is mounted on it yet.
+	ExpandVolume(devicePath string, opts Options, newSize, oldSize string) DriverStatus
+
+	// ExpandFS grows the filesystem mounted from devicePath at
+	// deviceMountPath to match devicePath's (already expanded, see
+	// ExpandVolume) size.
+	ExpandFS(devicePath, deviceMountPath string, opts Options, newSize, oldSize string) DriverStatus
 	MountDevice(mountDir, mountDevice string, opts Options) DriverStatus
 	UnmountDevice(mountDevice string) DriverStatus
 	Mount(mountDir string, opts Options) DriverStatus
@@ -92,22 +180,32 @@ func ExitWithResult(result DriverStatus) {
 
 	res, err := json.Marshal(result)
 	if err != nil {
-		log.Printf("Error marshaling result: %v", err)
+		loglevel.Errorf("Error marshaling result: %v", err)
 		fmt.Fprintln(out, `{"status":"Failure","message":"Error marshaling result to JSON"}`)
 	} else {
 		s := string(res)
-		log.Printf("Command result: %s", s)
+		loglevel.Infof("Command result: %s", s)
 		fmt.Fprintln(out, s)
 	}
+	beforeExit()
 	exit(code)
 }
 
+// canonicalMessage builds a DriverStatus.Message from call-site arguments the
+// same way every constructor below does, so that two call sites describing
+// the same condition (e.g. a wrong argument count) produce byte-identical
+// messages rather than differing by incidental leading/trailing whitespace
+// from how their arguments happened to be split. It does not otherwise
+// reformat the message: callers remain free to choose their own wording.
+func canonicalMessage(a ...interface{}) string {
+	return strings.TrimSpace(fmt.Sprint(a...))
+}
+
 // Fail creates a StatusFailure Result with a given message.
 func Fail(a ...interface{}) DriverStatus {
-	msg := fmt.Sprint(a...)
 	return DriverStatus{
 		Status:  StatusFailure,
-		Message: msg,
+		Message: canonicalMessage(a...),
 	}
 }
 
@@ -115,7 +213,7 @@ func Fail(a ...interface{}) DriverStatus {
 func Succeed(a ...interface{}) DriverStatus {
 	return DriverStatus{
 		Status:  StatusSuccess,
-		Message: fmt.Sprint(a...),
+		Message: canonicalMessage(a...),
 	}
 }
 
@@ -123,7 +221,7 @@ func Succeed(a ...interface{}) DriverStatus {
 func NotSupported(a ...interface{}) DriverStatus {
 	return DriverStatus{
 		Status:  StatusNotSupported,
-		Message: fmt.Sprint(a...),
+		Message: canonicalMessage(a...),
 	}
 }
 
@@ -148,7 +246,7 @@ func ExecDriver(driver Driver, args []string) {
 		ExitWithResult(Fail("Expected at least one argument"))
 	}
 
-	log.Printf("'%s %s' called with %s", args[0], args[1], args[2:])
+	loglevel.Debugf("'%s %s' called with %s", args[0], args[1], args[2:])
 
 	switch args[1] {
 	// <driver executable> init
@@ -156,12 +254,17 @@ func ExecDriver(driver Driver, args []string) {
 		ExitWithResult(driver.Init())
 
 	// <driver executable> getvolumename <json options>
-	// Currently broken as of lates kube release (1.6.4). Work around hardcodes
-	// exiting with StatusNotSupported.
-	// TODO(apryde): Investigate current situation and version support
-	// requirements.
 	case "getvolumename":
-		ExitWithResult(NotSupported("getvolumename is broken as of kube 1.6.4"))
+		if len(args) != 3 {
+			ExitWithResult(Fail("getvolumename expected exactly 3 arguments; got ", args))
+		}
+
+		opts, err := processOpts(args[2])
+		if err != nil {
+			ExitWithResult(Fail(err))
+		}
+
+		ExitWithResult(driver.GetVolumeName(opts))
 
 	// <driver executable> attach <json options> <node name>
 	case "attach":
@@ -263,7 +366,43 @@ func ExecDriver(driver Driver, args []string) {
 		mountDir := args[2]
 		ExitWithResult(driver.Unmount(mountDir))
 
+	// <driver executable> expandvolume <device path> <json options> <new size> <old size>
+	case "expandvolume":
+		if len(args) != 6 {
+			ExitWithResult(Fail("expandvolume expected exactly 6 arguments; got ", args))
+		}
+
+		devicePath := args[2]
+		opts, err := processOpts(args[3])
+		if err != nil {
+			ExitWithResult(Fail(err))
+		}
+		newSize := args[4]
+		oldSize := args[5]
+
+		ExitWithResult(driver.ExpandVolume(devicePath, opts, newSize, oldSize))
+
+	// <driver executable> expandfs <device path> <device mount path> <json options> <new size> <old size>
+	case "expandfs":
+		if len(args) != 7 {
+			ExitWithResult(Fail("expandfs expected exactly 7 arguments; got ", args))
+		}
+
+		devicePath := args[2]
+		deviceMountPath := args[3]
+		opts, err := processOpts(args[4])
+		if err != nil {
+			ExitWithResult(Fail(err))
+		}
+		newSize := args[5]
+		oldSize := args[6]
+
+		ExitWithResult(driver.ExpandFS(devicePath, deviceMountPath, opts, newSize, oldSize))
+
+	// Newer kubelets may invoke callouts this driver doesn't implement.
+	// Respond NotSupported rather than Failure so the kubelet treats it as
+	// an optional capability gap instead of an error.
 	default:
-		ExitWithResult(Fail("Invalid command; got ", args))
+		ExitWithResult(NotSupported("Unknown command; got ", args))
 	}
 }
@@ -36,6 +36,18 @@ func (driver mockFlexvolumeDriver) IsAttached(opts Options, nodeName string) Dri
 	return Succeed()
 }
 
+func (driver mockFlexvolumeDriver) GetVolumeName(opts Options) DriverStatus {
+	return NotSupported()
+}
+
+func (driver mockFlexvolumeDriver) ExpandVolume(devicePath string, opts Options, newSize, oldSize string) DriverStatus {
+	return Succeed()
+}
+
+func (driver mockFlexvolumeDriver) ExpandFS(devicePath, deviceMountPath string, opts Options, newSize, oldSize string) DriverStatus {
+	return Succeed()
+}
+
 func (driver mockFlexvolumeDriver) MountDevice(mountDir, mountDevice string, opts Options) DriverStatus {
 	return Succeed()
 }
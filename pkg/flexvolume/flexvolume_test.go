@@ -16,6 +16,7 @@ package flexvolume
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 )
 
@@ -42,9 +43,10 @@ func TestInit(t *testing.T) {
 	}
 }
 
-// TestVolumeName tests that the getvolumename call-out results in
-// StatusNotSupported as the call-out is broken as of the latest stable Kube
-// release (1.6.4).
+// TestGetVolumeName tests that the getvolumename call-out is dispatched to
+// the driver, rather than being hardcoded at this layer, so that a driver
+// can decide for itself (e.g. based on detected kubelet/apiserver version)
+// whether to answer it.
 func TestGetVolumeName(t *testing.T) {
 	bak := out
 	out = new(bytes.Buffer)
@@ -57,8 +59,92 @@ func TestGetVolumeName(t *testing.T) {
 
 	ExecDriver(mockFlexvolumeDriver{}, []string{"oci", "getvolumename", defaultTestOps})
 
-	if out.(*bytes.Buffer).String() != `{"status":"Not supported","message":"getvolumename is broken as of kube 1.6.4"}`+"\n" {
-		t.Fatalf(`Expected '{"status":"Not supported","message":"getvolumename is broken as of kube 1.6.4"}}'; got %s`, out.(*bytes.Buffer).String())
+	if out.(*bytes.Buffer).String() != `{"status":"Not supported"}`+"\n" {
+		t.Fatalf(`Expected '{"status":"Not supported"}'; got %s`, out.(*bytes.Buffer).String())
+	}
+
+	if code != 0 {
+		t.Fatalf("Expected 'exit 0'; got 'exit %d'", code)
+	}
+}
+
+// TestUnknownCommands tests that callouts unknown to this driver result in
+// StatusNotSupported rather than StatusFailure, covering both genuinely
+// unrecognised commands and callouts from newer kubelet releases that this
+// driver doesn't implement.
+func TestUnknownCommands(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{"nodeexpand", []string{"oci", "nodeexpand", defaultTestOps}},
+		{"resize", []string{"oci", "resize", defaultTestOps}},
+		{"madeUpCommand", []string{"oci", "madeUpCommand"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			bak := out
+			out = new(bytes.Buffer)
+			defer func() { out = bak }()
+
+			code := 0
+			osexit := exit
+			exit = func(c int) { code = c }
+			defer func() { exit = osexit }()
+
+			ExecDriver(mockFlexvolumeDriver{}, tt.args)
+
+			var result DriverStatus
+			if err := json.Unmarshal(out.(*bytes.Buffer).Bytes(), &result); err != nil {
+				t.Fatalf("failed to unmarshal result: %v", err)
+			}
+			if result.Status != StatusNotSupported {
+				t.Errorf("ExecDriver(%v) => status %q; want %q", tt.args, result.Status, StatusNotSupported)
+			}
+
+			if code != 0 {
+				t.Errorf("Expected 'exit 0'; got 'exit %d'", code)
+			}
+		})
+	}
+}
+
+func TestExpandVolume(t *testing.T) {
+	bak := out
+	out = new(bytes.Buffer)
+	defer func() { out = bak }()
+
+	code := 0
+	osexit := exit
+	exit = func(c int) { code = c }
+	defer func() { exit = osexit }()
+
+	ExecDriver(mockFlexvolumeDriver{}, []string{"oci", "expandvolume", "/dev/sdb", defaultTestOps, "10Gi", "5Gi"})
+
+	if out.(*bytes.Buffer).String() != `{"status":"Success"}`+"\n" {
+		t.Fatalf(`Expected '{"status":"Success"}'; got %s`, out.(*bytes.Buffer).String())
+	}
+
+	if code != 0 {
+		t.Fatalf("Expected 'exit 0'; got 'exit %d'", code)
+	}
+}
+
+func TestExpandFS(t *testing.T) {
+	bak := out
+	out = new(bytes.Buffer)
+	defer func() { out = bak }()
+
+	code := 0
+	osexit := exit
+	exit = func(c int) { code = c }
+	defer func() { exit = osexit }()
+
+	ExecDriver(mockFlexvolumeDriver{}, []string{"oci", "expandfs", "/dev/sdb", "/var/lib/kubelet/plugins/mount", defaultTestOps, "10Gi", "5Gi"})
+
+	if out.(*bytes.Buffer).String() != `{"status":"Success"}`+"\n" {
+		t.Fatalf(`Expected '{"status":"Success"}'; got %s`, out.(*bytes.Buffer).String())
 	}
 
 	if code != 0 {
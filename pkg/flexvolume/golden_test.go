@@ -0,0 +1,128 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flexvolume
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestDriverStatusGoldenJSON pins the exact JSON encoding of a
+// representative DriverStatus for every failure class and for a populated
+// success response, so a future field addition/reordering that changes the
+// wire format is caught here rather than downstream, in a log scraper or an
+// error-code consumer parsing this driver's output.
+func TestDriverStatusGoldenJSON(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status DriverStatus
+		want   string
+	}{
+		{"bareSuccess", Succeed(), `{"status":"Success"}`},
+		{"bareNotSupported", NotSupported(), `{"status":"Not supported"}`},
+		{
+			"fail",
+			Fail("attach expected exactly 4 arguments; got ", []string{"oci", "attach"}),
+			`{"status":"Failure","message":"attach expected exactly 4 arguments; got [oci attach]"}`,
+		},
+		{
+			"succeedWithMessage",
+			Succeed("already mounted"),
+			`{"status":"Success","message":"already mounted"}`,
+		},
+		{
+			"notSupportedWithMessage",
+			NotSupported("Unknown command; got ", []string{"oci", "madeUpCommand"}),
+			`{"status":"Not supported","message":"Unknown command; got [oci madeUpCommand]"}`,
+		},
+		{
+			"attachSuccess",
+			DriverStatus{Status: StatusSuccess, Device: "/dev/sdb"},
+			`{"status":"Success","device":"/dev/sdb"}`,
+		},
+		{
+			"isAttached",
+			DriverStatus{Status: StatusSuccess, Attached: true},
+			`{"status":"Success","attached":true}`,
+		},
+		{
+			"getVolumeName",
+			DriverStatus{Status: StatusSuccess, VolumeName: "pvc-1234"},
+			`{"status":"Success","volumeName":"pvc-1234"}`,
+		},
+		{
+			"initCapabilities",
+			DriverStatus{Status: StatusSuccess, Capabilities: map[string]bool{"getvolumename": true, "attach": false}},
+			`{"status":"Success","capabilities":{"attach":false,"getvolumename":true}}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.status)
+			if err != nil {
+				t.Fatalf("json.Marshal(%+v) => %v", tt.status, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("json.Marshal(%+v) = %s; want %s", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDriverStatusJSONIsStableAcrossEncodes guards the property the golden
+// cases above rely on for the Capabilities map specifically: re-encoding the
+// same DriverStatus always produces the same bytes, because encoding/json
+// sorts map keys rather than walking them in the map's (randomised)
+// iteration order.
+func TestDriverStatusJSONIsStableAcrossEncodes(t *testing.T) {
+	status := DriverStatus{
+		Status: StatusSuccess,
+		Capabilities: map[string]bool{
+			"getvolumename": true,
+			"attach":        false,
+			"expandvolume":  true,
+			"expandfs":      false,
+		},
+	}
+
+	first, err := json.Marshal(status)
+	if err != nil {
+		t.Fatalf("json.Marshal() => %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		got, err := json.Marshal(status)
+		if err != nil {
+			t.Fatalf("json.Marshal() on attempt %d => %v", i, err)
+		}
+		if string(got) != string(first) {
+			t.Fatalf("json.Marshal() on attempt %d = %s; want %s (same as first encode)", i, got, first)
+		}
+	}
+}
+
+// TestCanonicalMessageTrimsIncidentalWhitespace guards the property the
+// "fail"/"notSupportedWithMessage" golden cases above rely on: trailing
+// whitespace left over from a call site building its message out of a
+// literal with a trailing separator and zero further arguments collapses to
+// the same message as one written without it.
+func TestCanonicalMessageTrimsIncidentalWhitespace(t *testing.T) {
+	got := canonicalMessage("already mounted; got ")
+	want := "already mounted; got"
+	if got != want {
+		t.Errorf("canonicalMessage() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iscsi
+
+import (
+	"fmt"
+
+	"k8s.io/utils/exec"
+)
+
+// iscsiErrNoObjsFound is the iscsiadm exit status (ISCSI_ERR_NO_OBJS_FOUND)
+// returned by `-m session` when iscsid is up but has no active sessions -
+// a healthy daemon, not a failure.
+const iscsiErrNoObjsFound = 21
+
+// CheckDaemon verifies iscsid is installed and reachable by asking
+// iscsiadm to list its active sessions, the same way Login/Logout/Rescan
+// do for a real target. It returns nil whether or not there happen to be
+// any sessions currently logged in; a non-nil error means either iscsiadm
+// isn't on the $PATH or iscsid isn't running/reachable, either of which
+// would also make a subsequent Login fail.
+func CheckDaemon() error {
+	return checkDaemon(exec.New())
+}
+
+func checkDaemon(runner exec.Interface) error {
+	path, err := runner.LookPath(iscsiadmCommand)
+	if err != nil {
+		return fmt.Errorf("iscsi: iscsiadm not found: %v", err)
+	}
+
+	if _, err := runner.Command(path, "-m", "session").CombinedOutput(); err != nil {
+		if exitErr, ok := err.(exec.ExitError); ok && exitErr.ExitStatus() == iscsiErrNoObjsFound {
+			return nil
+		}
+		return fmt.Errorf("iscsi: iscsid not reachable: %v", err)
+	}
+	return nil
+}
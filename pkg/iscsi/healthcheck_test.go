@@ -0,0 +1,77 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iscsi
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/utils/exec"
+	fakeexec "k8s.io/utils/exec/testing"
+)
+
+func fakeDaemonRunner(lookPathErr error, combinedOutputErr error) exec.Interface {
+	return &fakeexec.FakeExec{
+		LookPathFunc: func(cmd string) (string, error) {
+			if lookPathErr != nil {
+				return "", lookPathErr
+			}
+			return "/sbin/" + cmd, nil
+		},
+		CommandScript: []fakeexec.FakeCommandAction{
+			func(cmd string, args ...string) exec.Cmd {
+				return &fakeexec.FakeCmd{
+					CombinedOutputScript: []fakeexec.FakeCombinedOutputAction{
+						func() ([]byte, error) { return nil, combinedOutputErr },
+					},
+				}
+			},
+		},
+	}
+}
+
+func TestCheckDaemon(t *testing.T) {
+	testCases := map[string]struct {
+		lookPathErr       error
+		combinedOutputErr error
+		wantErr           bool
+	}{
+		"daemon up with active sessions": {},
+		"daemon up with no sessions": {
+			combinedOutputErr: fakeexec.FakeExitError{Status: iscsiErrNoObjsFound},
+		},
+		"iscsiadm not installed": {
+			lookPathErr: fmt.Errorf("not found"),
+			wantErr:     true,
+		},
+		"daemon not running": {
+			combinedOutputErr: fmt.Errorf("iscsiadm: can not connect to iSCSI daemon"),
+			wantErr:           true,
+		},
+		"unexpected exit status": {
+			combinedOutputErr: fakeexec.FakeExitError{Status: 1},
+			wantErr:           true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := checkDaemon(fakeDaemonRunner(tc.lookPathErr, tc.combinedOutputErr))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("checkDaemon() = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
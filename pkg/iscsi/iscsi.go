@@ -17,20 +17,30 @@ package iscsi
 import (
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"time"
 
 	"k8s.io/utils/exec"
 
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
 	"github.com/oracle/oci-flexvolume-driver/pkg/mount"
+	"github.com/oracle/oci-flexvolume-driver/pkg/nsenter"
 )
 
 const (
 	iscsiadmCommand = "iscsiadm"
 	mountCommand    = "/bin/mount"
+
+	// iscsiErrSessExists is the iscsiadm exit status (ISCSI_ERR_SESS_EXISTS)
+	// returned by `-m node ... -l` when a session for the target is already
+	// logged in. A kubelet callout that's retrying after being killed
+	// partway through a prior MountDevice (see driver.OCIFlexvolumeDriver.MountDevice)
+	// can land here on a target it already logged into; that's success, not
+	// a failure to surface.
+	iscsiErrSessExists = 15
 )
 
 // ErrMountPointNotFound is returned when a given path does not appear to be
@@ -57,7 +67,17 @@ type Interface interface {
 	// will format it first then mount it. Otherwise, if the disk is already
 	// formatted or it is being mounted as read-only, it will be mounted without
 	// formatting.
-	FormatAndMount(source string, target string, fstype string, options []string) error
+	//
+	// fsckBeforeMount requests a consistency check of an existing
+	// filesystem before mounting it, see mount.SafeFormatAndMount's doc
+	// comment for why and how.
+	FormatAndMount(source string, target string, fstype string, options []string, fsckBeforeMount bool) error
+
+	// MountRawBlock bind-mounts the raw device node at source onto target
+	// without formatting or a filesystem, for volumes consumed as
+	// volumeMode: Block. target must already exist as a regular file, per
+	// the usual raw block bind-mount convention.
+	MountRawBlock(source, target string) error
 
 	// Login logs into the iSCSI target.
 	Login() error
@@ -68,13 +88,28 @@ type Interface interface {
 	// RemoveFromDB removes the iSCSI target from the database.
 	RemoveFromDB() error
 
+	// Rescan triggers a rescan of the iSCSI session backing this target, so
+	// the kernel picks up a block volume resized at the OCI end before its
+	// filesystem is grown to match (see ResizeFS).
+	Rescan() error
+
+	// ResizeFS grows the filesystem mounted from devicePath at mountPath to
+	// fill the (already rescanned, see Rescan) underlying block device.
+	ResizeFS(devicePath, mountPath string) error
+
 	// SetAutomaticLogin sets the iSCSI node to automatically login at machine
 	// start-up.
 	SetAutomaticLogin() error
 
 	// UnmountPath is a common unmount routine that unmounts the given path and
-	// deletes the remaining directory if successful.
-	UnmountPath(path string) error
+	// deletes the remaining directory if successful. A busy unmount is
+	// retried, escalating to a lazy unmount, until gracePeriod elapses; see
+	// mount.UnmountPathWithGracePeriod.
+	UnmountPath(path string, gracePeriod time.Duration) error
+
+	// Version returns the installed iscsiadm's version number, for logging
+	// alongside a login failure.
+	Version() (string, error)
 }
 
 // iSCSIMounter implements Interface.
@@ -106,7 +141,7 @@ func newWithMounter(mounter mount.Interface, iqn, ipv4 string, port int) Interfa
 			IPv4: ipv4,
 			Port: port,
 		},
-		runner:  exec.New(),
+		runner:  nsenter.MaybeWrap(exec.New()),
 		mounter: mounter,
 	}
 }
@@ -116,36 +151,60 @@ func New(iqn, ipv4 string, port int) Interface {
 	return newWithMounter(mount.New(mountCommand), iqn, ipv4, port)
 }
 
-// NewFromDevicePath extracts the IQN, IPv4 address, and port from a
-// iSCSI mount device path.
-// i.e. /dev/disk/by-path/ip-<ip>:<port>-iscsi-<IQN>-lun-1
-func NewFromDevicePath(mountDevice string) (Interface, error) {
+// ParseDevicePath extracts the IQN, IPv4 address, and port from an iSCSI
+// mount device path, i.e. /dev/disk/by-path/ip-<ip>:<port>-iscsi-<IQN>-lun-1.
+// It's exported (rather than folded into NewFromDevicePath) so that callers
+// which need these components before the device node exists - e.g. to
+// install a udev rule ahead of the iSCSI login that will create it - don't
+// have to instantiate a mounter to get them.
+func ParseDevicePath(mountDevice string) (iqn, ipv4 string, port int, err error) {
 	m := diskByPathPattern.FindStringSubmatch(mountDevice)
 	if len(m) != 4 {
-		return nil, fmt.Errorf("mount device path %q did not match pattern; got %v", mountDevice, m)
+		return "", "", 0, fmt.Errorf("mount device path %q did not match pattern; got %v", mountDevice, m)
 	}
 
-	port, err := strconv.Atoi(m[2])
+	port, err = strconv.Atoi(m[2])
 	if err != nil {
-		return nil, fmt.Errorf("invalid port: %v", err)
+		return "", "", 0, fmt.Errorf("invalid port: %v", err)
 	}
 
-	return New(m[3], m[1], port), nil
+	return m[3], m[1], port, nil
+}
+
+// NewFromDevicePath extracts the IQN, IPv4 address, and port from a
+// iSCSI mount device path.
+// i.e. /dev/disk/by-path/ip-<ip>:<port>-iscsi-<IQN>-lun-1
+func NewFromDevicePath(mountDevice string) (Interface, error) {
+	iqn, ipv4, port, err := ParseDevicePath(mountDevice)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(iqn, ipv4, port), nil
 }
 
 // NewFromMountPointPath gets /dev/disk/by-path/ip-<ip>:<port>-iscsi-<IQN>-lun-1
 // from the given mount point path.
 func NewFromMountPointPath(mountPath string) (Interface, error) {
-	mounter := mount.New(mountCommand)
-	mountPoint, err := getMountPointForPath(mounter, mountPath)
+	diskByPath, err := DiskByPathForMountPointPath(mountPath)
 	if err != nil {
 		return nil, err
 	}
-	diskByPath, err := diskByPathForMountPoint(mountPoint)
+	return NewFromDevicePath(diskByPath)
+}
+
+// DiskByPathForMountPointPath resolves the given mount point back to its
+// /dev/disk/by-path/ip-<ip>:<port>-iscsi-<IQN>-lun-1 device. It's exported
+// for callers (e.g. pkg/udev, via UnmountDevice) that need to identify the
+// underlying disk to clean up device-path bookkeeping that isn't itself
+// iSCSI-specific.
+func DiskByPathForMountPointPath(mountPath string) (string, error) {
+	mounter := mount.New(mountCommand)
+	mountPoint, err := getMountPointForPath(mounter, mountPath)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return NewFromDevicePath(diskByPath)
+	return diskByPathForMountPoint(mountPoint)
 }
 
 // getISCSIAdmPath gets the absolute path to the iscsiadm executable on the
@@ -160,7 +219,7 @@ func (c *iSCSIMounter) getISCSIAdmPath() (string, error) {
 		return "", err
 	}
 	c.iscsiadmPath = path
-	log.Printf("Full iscsiadm path: %q", c.iscsiadmPath)
+	loglevel.Debugf("Full iscsiadm path: %q", c.iscsiadmPath)
 	return path, nil
 }
 
@@ -179,7 +238,7 @@ func (c *iSCSIMounter) iscsiadm(parts ...string) (string, error) {
 }
 
 func (c *iSCSIMounter) AddToDB() error {
-	log.Printf("iscsi: adding node record to db IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	loglevel.Debugf("iscsi: adding node record to db IQN=%q target=%q", c.disk.IQN, c.disk.Target())
 
 	_, err := c.iscsiadm(
 		"-m", "node",
@@ -190,13 +249,13 @@ func (c *iSCSIMounter) AddToDB() error {
 		return fmt.Errorf("iscsi: error adding node record to db: %v", err)
 	}
 
-	log.Printf("iscsi: added node record to db IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	loglevel.Debugf("iscsi: added node record to db IQN=%q target=%q", c.disk.IQN, c.disk.Target())
 
 	return nil
 }
 
 func (c *iSCSIMounter) SetAutomaticLogin() error {
-	log.Printf("iscsi: configuring automatic node login IQN=%q", c.disk.IQN)
+	loglevel.Debugf("iscsi: configuring automatic node login IQN=%q", c.disk.IQN)
 
 	_, err := c.iscsiadm(
 		"-m", "node",
@@ -208,13 +267,13 @@ func (c *iSCSIMounter) SetAutomaticLogin() error {
 		return fmt.Errorf("iscsi: error configuring automatic node login: %v", err)
 	}
 
-	log.Printf("iscsi: configured automatic node login IQN=%q", c.disk.IQN)
+	loglevel.Debugf("iscsi: configured automatic node login IQN=%q", c.disk.IQN)
 
 	return nil
 }
 
 func (c *iSCSIMounter) Login() error {
-	log.Printf("iscsi: logging into target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	loglevel.Debugf("iscsi: logging into target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
 
 	_, err := c.iscsiadm(
 		"-m", "node",
@@ -222,10 +281,14 @@ func (c *iSCSIMounter) Login() error {
 		"-p", c.disk.Target(),
 		"-l")
 	if err != nil {
+		if exitErr, ok := err.(exec.ExitError); ok && exitErr.ExitStatus() == iscsiErrSessExists {
+			loglevel.Debugf("iscsi: already logged into target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+			return nil
+		}
 		return fmt.Errorf("iscsi: error logging in target: %v", err)
 	}
 
-	log.Printf("iscsi: logged into target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	loglevel.Debugf("iscsi: logged into target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
 
 	return nil
 }
@@ -233,7 +296,7 @@ func (c *iSCSIMounter) Login() error {
 // Logout logs out the iSCSI target.
 // sudo iscsiadm -m node -T <IQN> -p <ip>:<port>  -u
 func (c *iSCSIMounter) Logout() error {
-	log.Printf("iscsi: logging out target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	loglevel.Debugf("iscsi: logging out target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
 	_, err := c.iscsiadm(
 		"-m", "node",
 		"-T", c.disk.IQN,
@@ -243,13 +306,13 @@ func (c *iSCSIMounter) Logout() error {
 		return fmt.Errorf("iscsi: error logging out target: %v", err)
 	}
 
-	log.Printf("iscsi: logged out target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	loglevel.Debugf("iscsi: logged out target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
 
 	return nil
 }
 
 func (c *iSCSIMounter) RemoveFromDB() error {
-	log.Printf("iscsi: removing target from db IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	loglevel.Debugf("iscsi: removing target from db IQN=%q target=%q", c.disk.IQN, c.disk.Target())
 	_, err := c.iscsiadm(
 		"-m", "node",
 		"-o", "delete",
@@ -259,24 +322,53 @@ func (c *iSCSIMounter) RemoveFromDB() error {
 		return fmt.Errorf("iscsi: error removing target from db: %v", err)
 	}
 
-	log.Printf("iscsi: removed target from db IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	loglevel.Debugf("iscsi: removed target from db IQN=%q target=%q", c.disk.IQN, c.disk.Target())
 
 	return nil
 }
 
+// Rescan rescans the iSCSI target.
+// sudo iscsiadm -m node -T <IQN> -p <ip>:<port> -R
+func (c *iSCSIMounter) Rescan() error {
+	loglevel.Debugf("iscsi: rescanning target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+	_, err := c.iscsiadm(
+		"-m", "node",
+		"-T", c.disk.IQN,
+		"-p", c.disk.Target(),
+		"-R")
+	if err != nil {
+		return fmt.Errorf("iscsi: error rescanning target: %v", err)
+	}
+
+	loglevel.Debugf("iscsi: rescanned target IQN=%q target=%q", c.disk.IQN, c.disk.Target())
+
+	return nil
+}
+
+func (c *iSCSIMounter) ResizeFS(devicePath, mountPath string) error {
+	return (&mount.SafeFormatAndMount{
+		Interface: c.mounter,
+		Runner:    c.runner,
+	}).ResizeFS(devicePath, mountPath)
+}
+
 func (c *iSCSIMounter) DeviceOpened(path string) (bool, error) {
 	return c.mounter.DeviceOpened(path)
 }
 
-func (c *iSCSIMounter) FormatAndMount(source string, target string, fstype string, options []string) error {
+func (c *iSCSIMounter) FormatAndMount(source string, target string, fstype string, options []string, fsckBeforeMount bool) error {
 	return (&mount.SafeFormatAndMount{
 		Interface: c.mounter,
 		Runner:    c.runner,
-	}).FormatAndMount(source, target, fstype, options)
+	}).FormatAndMount(source, target, fstype, options, fsckBeforeMount)
+}
+
+func (c *iSCSIMounter) MountRawBlock(source, target string) error {
+	return c.mounter.Mount(source, target, "", []string{"bind"})
 }
 
-func (c *iSCSIMounter) UnmountPath(path string) error {
-	return mount.UnmountPath(path, c.mounter)
+func (c *iSCSIMounter) UnmountPath(path string, gracePeriod time.Duration) error {
+	return mount.UnmountPathWithGracePeriod(path, c.mounter, gracePeriod)
 }
 
 // mountLister is a minimal subset of mount.Interface (used to enable testing).
@@ -0,0 +1,29 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iscsi
+
+import "testing"
+
+// BenchmarkNewFromDevicePath tracks the cost of parsing the by-path device
+// string, which happens on every MountDevice call in the Attach->MountDevice
+// critical path.
+func BenchmarkNewFromDevicePath(b *testing.B) {
+	device := "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.2015-12.com.oracleiaas:aaaaaa-lun-1"
+	for i := 0; i < b.N; i++ {
+		if _, err := NewFromDevicePath(device); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -18,6 +18,9 @@ import (
 	"reflect"
 	"testing"
 
+	"k8s.io/utils/exec"
+	fakeexec "k8s.io/utils/exec/testing"
+
 	"github.com/oracle/oci-flexvolume-driver/pkg/mount"
 )
 
@@ -29,6 +32,31 @@ func (ml *mockMountLister) List() ([]mount.MountPoint, error) {
 	return ml.mps, nil
 }
 
+// fakeOutputCmd is a minimal exec.Cmd that scripts Output(), which
+// k8s.io/utils/exec/testing.FakeCmd doesn't support.
+type fakeOutputCmd struct {
+	fakeexec.FakeCmd
+	output []byte
+	err    error
+}
+
+func (c *fakeOutputCmd) Output() ([]byte, error) { return c.output, c.err }
+
+func newFakeISCSIMounter(output []byte, err error) *iSCSIMounter {
+	runner := &fakeexec.FakeExec{
+		CommandScript: []fakeexec.FakeCommandAction{
+			func(cmd string, args ...string) exec.Cmd {
+				return &fakeOutputCmd{output: output, err: err}
+			},
+		},
+	}
+	return &iSCSIMounter{
+		disk:         &iSCSDisk{IQN: "iqn.2015-12.com.oracleiaas:test", IPv4: "169.254.0.2", Port: 3260},
+		runner:       runner,
+		iscsiadmPath: "iscsiadm",
+	}
+}
+
 func TestGetMountPointForPath(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -79,3 +107,41 @@ func TestGetMountPointForPath(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginSucceeds(t *testing.T) {
+	m := newFakeISCSIMounter(nil, nil)
+	if err := m.Login(); err != nil {
+		t.Errorf("Login() => %v; expected nil", err)
+	}
+}
+
+// TestLoginAlreadyLoggedIn simulates a kubelet callout retry landing on a
+// target it already logged into on a prior, killed invocation of
+// MountDevice - iscsiadm reports ISCSI_ERR_SESS_EXISTS rather than erroring.
+func TestLoginAlreadyLoggedIn(t *testing.T) {
+	m := newFakeISCSIMounter(nil, fakeexec.FakeExitError{Status: iscsiErrSessExists})
+	if err := m.Login(); err != nil {
+		t.Errorf("Login() on an already-logged-in target => %v; expected nil", err)
+	}
+}
+
+func TestRescanSucceeds(t *testing.T) {
+	m := newFakeISCSIMounter(nil, nil)
+	if err := m.Rescan(); err != nil {
+		t.Errorf("Rescan() => %v; expected nil", err)
+	}
+}
+
+func TestRescanErrorPropagates(t *testing.T) {
+	m := newFakeISCSIMounter(nil, fakeexec.FakeExitError{Status: 1})
+	if err := m.Rescan(); err == nil {
+		t.Error("Rescan() => nil; expected an error")
+	}
+}
+
+func TestLoginOtherErrorPropagates(t *testing.T) {
+	m := newFakeISCSIMounter(nil, fakeexec.FakeExitError{Status: 1})
+	if err := m.Login(); err == nil {
+		t.Error("Login() => nil; expected an error")
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iscsi
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// versionPattern matches the version number on the first line of
+// `iscsiadm --version` output, e.g. "iscsiadm version 6.2.0.874-7" on
+// Oracle Linux or "iscsiadm version 2.0.874-7.1ubuntu6" on Ubuntu.
+var versionPattern = regexp.MustCompile(`iscsiadm version (\S+)`)
+
+// ParseVersion extracts the open-iscsi version number from the output of
+// `iscsiadm --version`.
+func ParseVersion(output string) (string, error) {
+	m := versionPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "", fmt.Errorf("iscsi: could not find a version number in %q", output)
+	}
+	return m[1], nil
+}
+
+// Version returns the installed iscsiadm's version number, as reported by
+// `iscsiadm --version`. It exists so the version can be logged alongside
+// login failures for diagnosis (see loginWithRetries in pkg/oci/driver).
+//
+// Login and Rescan only ever look at iscsiadm's exit status, not its
+// output (see iscsiErrSessExists), and that exit status has been stable
+// across every open-iscsi release this driver has shipped against,
+// including Oracle Linux 7/8/9 and Ubuntu's open-iscsi packages - so
+// there's currently no per-version output format for Version to select
+// between.
+func (c *iSCSIMounter) Version() (string, error) {
+	output, err := c.iscsiadm("--version")
+	if err != nil {
+		return "", fmt.Errorf("iscsi: error getting iscsiadm version: %v", err)
+	}
+	return ParseVersion(output)
+}
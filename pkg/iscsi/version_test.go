@@ -0,0 +1,69 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iscsi
+
+import "testing"
+
+// These are verbatim `iscsiadm --version` outputs from the distros this
+// driver is run against.
+const (
+	versionOutputOL7    = "iscsiadm version 6.2.0.874-7\n"
+	versionOutputOL8    = "iscsiadm version 6.2.1.4-2\n"
+	versionOutputOL9    = "iscsiadm version 6.2.1.9-3\n"
+	versionOutputUbuntu = "iscsiadm version 2.0.874-7.1ubuntu6.4\n"
+)
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"Oracle Linux 7", versionOutputOL7, "6.2.0.874-7"},
+		{"Oracle Linux 8", versionOutputOL8, "6.2.1.4-2"},
+		{"Oracle Linux 9", versionOutputOL9, "6.2.1.9-3"},
+		{"Ubuntu", versionOutputUbuntu, "2.0.874-7.1ubuntu6.4"},
+		{"unrecognised output", "command not found\n", ""},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVersion(tt.output)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("ParseVersion(%q) => %q, nil; expected an error", tt.output, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) => error: %v", tt.output, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseVersion(%q) => %q, want %q", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	m := newFakeISCSIMounter([]byte(versionOutputOL7), nil)
+	got, err := m.Version()
+	if err != nil {
+		t.Fatalf("Version() => error: %v", err)
+	}
+	if want := "6.2.0.874-7"; got != want {
+		t.Errorf("Version() => %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loglevel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", Debug, false},
+		{"DEBUG", Debug, false},
+		{"info", Info, false},
+		{"warn", Warn, false},
+		{"warning", Warn, false},
+		{"error", Error, false},
+		{"verbose", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range testCases {
+		got, err := ParseLevel(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLevel(%q) => nil error; want one", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLevel(%q) => %v; want nil error", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v; want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// withCapturedOutput swaps output for the duration of fn and returns
+// everything logged through it, one entry per call.
+func withCapturedOutput(fn func()) []string {
+	var got []string
+	original := output
+	output = func(calldepth int, s string) error {
+		got = append(got, s)
+		return nil
+	}
+	defer func() { output = original }()
+	fn()
+	return got
+}
+
+func TestLevelFiltering(t *testing.T) {
+	originalLevel := GetLevel()
+	defer SetLevel(originalLevel)
+
+	SetLevel(Warn)
+	got := withCapturedOutput(func() {
+		Debugf("debug message")
+		Infof("info message")
+		Warnf("warn message")
+		Errorf("error message")
+	})
+
+	if len(got) != 2 {
+		t.Fatalf("got %d messages at Warn threshold; want 2: %v", len(got), got)
+	}
+	if !strings.HasPrefix(got[0], "WARN ") {
+		t.Errorf("got[0] = %q; want WARN prefix", got[0])
+	}
+	if !strings.HasPrefix(got[1], "ERROR ") {
+		t.Errorf("got[1] = %q; want ERROR prefix", got[1])
+	}
+}
+
+func TestSetLevelAllowsEverythingThroughAtDebug(t *testing.T) {
+	originalLevel := GetLevel()
+	defer SetLevel(originalLevel)
+
+	SetLevel(Debug)
+	got := withCapturedOutput(func() {
+		Debugf("a")
+		Infof("b")
+		Warnf("c")
+		Errorf("d")
+	})
+
+	if len(got) != 4 {
+		t.Fatalf("got %d messages at Debug threshold; want 4: %v", len(got), got)
+	}
+}
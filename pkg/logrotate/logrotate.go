@@ -0,0 +1,74 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logrotate rotates the driver's own log file by size, so it
+// doesn't grow unbounded and fill the node's root disk. logrotate(8) isn't
+// a good fit here: a Flexvolume callout is a short-lived process rather
+// than a daemon it could signal to reopen its log file, and a cron-driven
+// logrotate racing a callout's own append is exactly the kind of race this
+// package is meant to avoid by doing the check inline, in the same process,
+// immediately before the log file is opened for the callout's own writes.
+package logrotate
+
+import (
+	"fmt"
+	"os"
+)
+
+// RotateIfNeeded renames path to path+".1" (after dropping any existing
+// path+"."+maxBackups and shifting path+".1".."N-1" up by one) if path is
+// at least maxBytes in size. With maxBackups <= 0 it removes path instead.
+// It's a no-op if path doesn't exist or is smaller than maxBytes.
+//
+// Rotation here races harmlessly against another callout process that has
+// path open for append: renaming a file doesn't affect file descriptors
+// already open on it, so a concurrent writer just keeps appending to the
+// renamed (now ".1") file rather than the fresh one this call creates -
+// its data isn't lost, it's merely one rotation late. So rotation takes
+// no file lock, unlike the isattached and volume-priority caches, whose
+// read-modify-write runs under driver.withFileLock.
+func RotateIfNeeded(path string, maxBytes int64, maxBackups int) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("logrotate: stat %q: %v", path, err)
+	}
+	if info.Size() < maxBytes {
+		return nil
+	}
+
+	if maxBackups <= 0 {
+		return os.Remove(path)
+	}
+
+	oldest := fmt.Sprintf("%s.%d", path, maxBackups)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("logrotate: removing %q: %v", oldest, err)
+	}
+
+	for n := maxBackups - 1; n >= 1; n-- {
+		src := fmt.Sprintf("%s.%d", path, n)
+		dst := fmt.Sprintf("%s.%d", path, n+1)
+		if err := os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("logrotate: renaming %q to %q: %v", src, dst, err)
+		}
+	}
+
+	if err := os.Rename(path, path+".1"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("logrotate: renaming %q to %q.1: %v", path, path, err)
+	}
+	return nil
+}
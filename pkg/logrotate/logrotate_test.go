@@ -0,0 +1,110 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateIfNeededNoopWhenMissingOrSmall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "missing.log")
+	if err := RotateIfNeeded(path, 10, 3); err != nil {
+		t.Fatalf("RotateIfNeeded() on missing file => %v, want nil", err)
+	}
+
+	path = filepath.Join(dir, "small.log")
+	if err := ioutil.WriteFile(path, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RotateIfNeeded(path, 100, 3); err != nil {
+		t.Fatalf("RotateIfNeeded() on small file => %v, want nil", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("small file was rotated away: %v", err)
+	}
+}
+
+func TestRotateIfNeededShiftsBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "driver.log")
+	write := func(p, content string) {
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(path, "current")
+	write(path+".1", "backup-1")
+	write(path+".2", "backup-2")
+
+	if err := RotateIfNeeded(path, int64(len("current")), 2); err != nil {
+		t.Fatalf("RotateIfNeeded() => %v, want nil", err)
+	}
+
+	// backup-2 (the oldest, at maxBackups) should be dropped entirely.
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("path.3 = %v, want IsNotExist", err)
+	}
+
+	wantContent := map[string]string{
+		path + ".1": "current",
+		path + ".2": "backup-1",
+	}
+	for p, want := range wantContent {
+		got, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatalf("ReadFile(%q) => %v", p, err)
+		}
+		if string(got) != want {
+			t.Errorf("ReadFile(%q) = %q; want %q", p, got, want)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("original path still exists after rotation: %v", err)
+	}
+}
+
+func TestRotateIfNeededRemovesWhenNoBackupsKept(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "driver.log")
+	if err := ioutil.WriteFile(path, []byte("current"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RotateIfNeeded(path, int64(len("current")), 0); err != nil {
+		t.Fatalf("RotateIfNeeded() => %v, want nil", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("path still exists with maxBackups=0: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics accumulates flexvolume callout counters and durations
+// across invocations and renders them as a node_exporter textfile collector
+// input, since every callout is its own short-lived process with nowhere to
+// hold Prometheus counters in memory between them and nothing listening for
+// a /metrics scrape to hand them to even if it could. There's no vendored
+// Prometheus client library in this tree either (and no network access here
+// to add one), so this writes the exposition text format by hand - it's
+// simple enough that doing so is no great loss.
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// stateFileName is this package's own ledger of accumulated counts and
+// durations, kept alongside the rendered textfile. It exists because the
+// Prometheus text exposition format isn't something this package re-parses
+// to recover the previous values to increment - the ledger is, and the
+// textfile below is re-rendered from it in full on every call.
+const stateFileName = "oci_flexvolume_driver_metrics_state.json"
+
+// TextfileName is the file RecordCallout renders into dir, matching the
+// *.prom glob node_exporter's textfile collector scrapes.
+const TextfileName = "oci_flexvolume_driver.prom"
+
+// state is keyed callout -> status -> value, both because that is the label
+// pair RecordCallout is called with and because it marshals to JSON without
+// needing a custom key type.
+type state struct {
+	Count       map[string]map[string]float64 `json:"count"`
+	DurationSum map[string]map[string]float64 `json:"durationSum"`
+}
+
+func newState() state {
+	return state{
+		Count:       make(map[string]map[string]float64),
+		DurationSum: make(map[string]map[string]float64),
+	}
+}
+
+// RecordCallout records one occurrence of callout finishing with status,
+// having taken duration, then re-renders dir's textfile from the updated
+// totals. A missing or corrupt ledger is treated as a fresh one rather than
+// an error, the same as this driver's other accumulated node-local state
+// (see driver.loadIsAttachedCacheFile's doc comment); the ledger and textfile
+// are otherwise written unlocked (unlike the caches driver.withFileLock
+// guards), racing with a concurrent callout process's own read-modify-write
+// - a lost update here costs one undercounted sample, not correctness.
+func RecordCallout(dir, callout, status string, duration time.Duration) error {
+	statePath := filepath.Join(dir, stateFileName)
+
+	st := loadState(statePath)
+	if st.Count[callout] == nil {
+		st.Count[callout] = make(map[string]float64)
+	}
+	st.Count[callout][status]++
+	if st.DurationSum[callout] == nil {
+		st.DurationSum[callout] = make(map[string]float64)
+	}
+	st.DurationSum[callout][status] += duration.Seconds()
+
+	if err := saveState(statePath, st); err != nil {
+		return fmt.Errorf("metrics: failed to save state: %v", err)
+	}
+
+	if err := writeTextfile(filepath.Join(dir, TextfileName), st); err != nil {
+		return fmt.Errorf("metrics: failed to write textfile: %v", err)
+	}
+	return nil
+}
+
+func loadState(path string) state {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return newState()
+	}
+
+	st := newState()
+	if err := json.Unmarshal(b, &st); err != nil {
+		return newState()
+	}
+	if st.Count == nil {
+		st.Count = make(map[string]map[string]float64)
+	}
+	if st.DurationSum == nil {
+		st.DurationSum = make(map[string]map[string]float64)
+	}
+	return st
+}
+
+func saveState(path string, st state) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// writeTextfile renders st as Prometheus exposition text and writes it to
+// path via a write-then-rename, so that node_exporter's textfile collector -
+// which polls the directory on its own schedule, independent of any
+// callout - never reads a file this process is still in the middle of
+// writing.
+func writeTextfile(path string, st state) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintln(&buf, "# HELP oci_flexvolume_driver_callout_total Total number of flexvolume callouts completed, by callout and result status.")
+	fmt.Fprintln(&buf, "# TYPE oci_flexvolume_driver_callout_total counter")
+	for _, callout := range sortedOuterKeys(st.Count) {
+		for _, status := range sortedInnerKeys(st.Count[callout]) {
+			fmt.Fprintf(&buf, "oci_flexvolume_driver_callout_total{callout=%q,status=%q} %g\n", callout, status, st.Count[callout][status])
+		}
+	}
+
+	fmt.Fprintln(&buf, "# HELP oci_flexvolume_driver_callout_duration_seconds_sum Sum of flexvolume callout durations in seconds, by callout and result status.")
+	fmt.Fprintln(&buf, "# TYPE oci_flexvolume_driver_callout_duration_seconds_sum counter")
+	for _, callout := range sortedOuterKeys(st.DurationSum) {
+		for _, status := range sortedInnerKeys(st.DurationSum[callout]) {
+			fmt.Fprintf(&buf, "oci_flexvolume_driver_callout_duration_seconds_sum{callout=%q,status=%q} %g\n", callout, status, st.DurationSum[callout][status])
+		}
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func sortedOuterKeys(m map[string]map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedInnerKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordCalloutAccumulatesAcrossCalls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := RecordCallout(dir, "attach", "Success", 2*time.Second); err != nil {
+		t.Fatalf("RecordCallout() => %v, want nil", err)
+	}
+	if err := RecordCallout(dir, "attach", "Success", 3*time.Second); err != nil {
+		t.Fatalf("RecordCallout() => %v, want nil", err)
+	}
+	if err := RecordCallout(dir, "attach", "Failure", time.Second); err != nil {
+		t.Fatalf("RecordCallout() => %v, want nil", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, TextfileName))
+	if err != nil {
+		t.Fatalf("failed to read textfile: %v", err)
+	}
+	got := string(b)
+
+	wantLines := []string{
+		`oci_flexvolume_driver_callout_total{callout="attach",status="Failure"} 1`,
+		`oci_flexvolume_driver_callout_total{callout="attach",status="Success"} 2`,
+		`oci_flexvolume_driver_callout_duration_seconds_sum{callout="attach",status="Failure"} 1`,
+		`oci_flexvolume_driver_callout_duration_seconds_sum{callout="attach",status="Success"} 5`,
+	}
+	for _, want := range wantLines {
+		if !strings.Contains(got, want) {
+			t.Errorf("textfile missing line %q; got:\n%s", want, got)
+		}
+	}
+}
+
+func TestRecordCalloutTreatsCorruptStateAsEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, stateFileName), []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RecordCallout(dir, "detach", "Success", time.Millisecond); err != nil {
+		t.Fatalf("RecordCallout() => %v, want nil", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, TextfileName))
+	if err != nil {
+		t.Fatalf("failed to read textfile: %v", err)
+	}
+	if !strings.Contains(string(b), `oci_flexvolume_driver_callout_total{callout="detach",status="Success"} 1`) {
+		t.Errorf("textfile = %q, want a fresh count of 1 for detach/Success", string(b))
+	}
+}
+
+func TestWriteTextfileIsDeterministicallyOrdered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := RecordCallout(dir, "mountdevice", "Success", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordCallout(dir, "attach", "Success", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, TextfileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+
+	attachIdx := strings.Index(got, `callout="attach"`)
+	mountIdx := strings.Index(got, `callout="mountdevice"`)
+	if attachIdx == -1 || mountIdx == -1 {
+		t.Fatalf("expected both callouts present; got:\n%s", got)
+	}
+	if attachIdx > mountIdx {
+		t.Errorf("expected \"attach\" to sort before \"mountdevice\"; got:\n%s", got)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProcessUsingPath describes a process found holding a reference under a
+// mount path that failed to unmount.
+type ProcessUsingPath struct {
+	PID  int
+	Comm string
+	// ContainerID is the best-effort container ID parsed out of the
+	// process's cgroup membership (the Docker/containerd/CRI-O convention
+	// of naming a pod/container's cgroup after its ID), or "" if the
+	// process isn't running in a container or the ID couldn't be
+	// determined this way. This tree has no vendored CRI client to ask the
+	// runtime directly, so this is as precise as it gets without one.
+	ContainerID string
+}
+
+// containerIDPattern matches the container/pod ID segment Docker,
+// containerd and CRI-O all embed in a process's cgroup path.
+var containerIDPattern = regexp.MustCompile(`(?:^|[-/])([0-9a-f]{64}|crio-[0-9a-f]{64})(?:\.scope)?(?:$|/)`)
+
+// ProcessesUsingPath scans /proc for processes with an open file
+// descriptor, current working directory, or executable under mountPath, so
+// a failed unmount's error can name the culprits instead of just EBUSY.
+func ProcessesUsingPath(mountPath string) ([]ProcessUsingPath, error) {
+	procDirs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("listing /proc: %v", err)
+	}
+
+	var procs []ProcessUsingPath
+	for _, procDir := range procDirs {
+		pid, err := strconv.Atoi(procDir.Name())
+		if err != nil {
+			continue
+		}
+
+		if !processRefersToPath(pid, mountPath) {
+			continue
+		}
+
+		procs = append(procs, ProcessUsingPath{
+			PID:         pid,
+			Comm:        processComm(pid),
+			ContainerID: processContainerID(pid),
+		})
+	}
+
+	sort.Slice(procs, func(i, j int) bool { return procs[i].PID < procs[j].PID })
+	return procs, nil
+}
+
+// processRefersToPath reports whether pid's cwd, root, executable, or any
+// open file descriptor resolves under mountPath.
+func processRefersToPath(pid int, mountPath string) bool {
+	base := filepath.Join("/proc", strconv.Itoa(pid))
+
+	for _, link := range []string{"cwd", "root", "exe"} {
+		if resolvesUnder(filepath.Join(base, link), mountPath) {
+			return true
+		}
+	}
+
+	fds, err := ioutil.ReadDir(filepath.Join(base, "fd"))
+	if err != nil {
+		// Process may have exited, or (more commonly) we don't have
+		// permission to read another process's fds; either way it's not
+		// something we can blame for the busy unmount.
+		return false
+	}
+	for _, fd := range fds {
+		if resolvesUnder(filepath.Join(base, "fd", fd.Name()), mountPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resolvesUnder(link, mountPath string) bool {
+	target, err := os.Readlink(link)
+	if err != nil {
+		return false
+	}
+	return target == mountPath || strings.HasPrefix(target, mountPath+string(filepath.Separator))
+}
+
+func processComm(pid int) string {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return "?"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func processContainerID(pid int) string {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return ""
+	}
+
+	m := containerIDPattern.FindStringSubmatch(string(data))
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(m[1], "crio-"), ".scope")
+}
+
+// describeProcessesUsingPath renders procs for inclusion in an unmount
+// error message.
+func describeProcessesUsingPath(procs []ProcessUsingPath) string {
+	descs := make([]string, 0, len(procs))
+	for _, p := range procs {
+		if p.ContainerID != "" {
+			descs = append(descs, fmt.Sprintf("pid %d (%s, container %s)", p.PID, p.Comm, p.ContainerID))
+		} else {
+			descs = append(descs, fmt.Sprintf("pid %d (%s)", p.PID, p.Comm))
+		}
+	}
+	return strings.Join(descs, ", ")
+}
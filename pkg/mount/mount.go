@@ -20,12 +20,13 @@ package mount
 
 import (
 	"fmt"
-	"log"
 	"path"
 	"path/filepath"
 	"strings"
 
 	"k8s.io/utils/exec"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
 )
 
 const (
@@ -39,6 +40,11 @@ type Interface interface {
 	Mount(source string, target string, fstype string, options []string) error
 	// Unmount unmounts given target.
 	Unmount(target string) error
+	// UnmountLazy detaches target from the mount table immediately (umount
+	// -l), leaving the underlying filesystem to finish unmounting once
+	// whatever's still using it - see ProcessesUsingPath - closes it or is
+	// killed, instead of blocking (or failing with EBUSY) until then.
+	UnmountLazy(target string) error
 	// List returns a list of all mounted filesystems.  This can be large.
 	// On some platforms, reading mounts is not guaranteed consistent (i.e.
 	// it could change between chunked reads). This is guaranteed to be
@@ -84,7 +90,22 @@ type SafeFormatAndMount struct {
 // read-only it will format it first then mount it. Otherwise, if the
 // disk is already formatted or it is being mounted as read-only, it
 // will be mounted without formatting.
-func (mounter *SafeFormatAndMount) FormatAndMount(source string, target string, fstype string, options []string) error {
+//
+// fsckBeforeMount requests a consistency check of whatever filesystem
+// source already holds - fsck -a, which also repairs what it safely can, or
+// the read-only xfs_repair -n for xfs, since fsck itself doesn't
+// meaningfully check an xfs filesystem - before
+// attempting to mount it, failing fast on damage instead of letting a pod
+// start against a corrupt filesystem. It's a no-op for a freshly
+// unformatted disk, which formatAndMount below will format rather than
+// mount as-is.
+func (mounter *SafeFormatAndMount) FormatAndMount(source string, target string, fstype string, options []string, fsckBeforeMount bool) error {
+	if fsckBeforeMount {
+		if err := mounter.checkFilesystem(source, fstype); err != nil {
+			return err
+		}
+	}
+
 	// Don't attempt to format if mounting as readonly. Go straight to mounting.
 	for _, option := range options {
 		if option == "ro" {
@@ -128,7 +149,7 @@ func GetMountRefs(mounter Interface, mountPath string) ([]string, error) {
 	// Find all references to the device.
 	var refs []string
 	if deviceName == "" {
-		log.Printf("could not determine device for path: %q", mountPath)
+		loglevel.Warnf("could not determine device for path: %q", mountPath)
 	} else {
 		for i := range mps {
 			if mps[i].Device == deviceName && mps[i].Path != slTarget {
@@ -178,11 +199,11 @@ func GetDeviceNameFromMount(mounter Interface, mountPath string) (string, int, e
 func getDeviceNameFromMount(mounter Interface, mountPath, pluginDir string) (string, error) {
 	refs, err := GetMountRefs(mounter, mountPath)
 	if err != nil {
-		log.Printf("GetMountRefs failed for mount path %q: %v", mountPath, err)
+		loglevel.Warnf("GetMountRefs failed for mount path %q: %v", mountPath, err)
 		return "", err
 	}
 	if len(refs) == 0 {
-		log.Printf("Directory %s is not mounted", mountPath)
+		loglevel.Warnf("Directory %s is not mounted", mountPath)
 		return "", fmt.Errorf("directory %s is not mounted", mountPath)
 	}
 	basemountPath := path.Join(pluginDir, MountsInGlobalPDPath)
@@ -190,7 +211,7 @@ func getDeviceNameFromMount(mounter Interface, mountPath, pluginDir string) (str
 		if strings.HasPrefix(ref, basemountPath) {
 			volumeID, err := filepath.Rel(basemountPath, ref)
 			if err != nil {
-				log.Printf("Failed to get volume id from mount %s - %v", mountPath, err)
+				loglevel.Warnf("Failed to get volume id from mount %s - %v", mountPath, err)
 				return "", err
 			}
 			return volumeID, nil
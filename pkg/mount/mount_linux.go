@@ -23,14 +23,17 @@ import (
 	"fmt"
 	"hash/fnv"
 	"io"
-	"log"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
 
 	utilexec "k8s.io/utils/exec"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
 )
 
 const (
@@ -116,11 +119,11 @@ func doMount(mounterPath string, mountCmd string, source string, target string,
 		mountCmd = mounterPath
 	}
 
-	log.Printf("Mounting cmd (%s) with arguments (%s)", mountCmd, mountArgs)
+	loglevel.Debugf("Mounting cmd (%s) with arguments (%s)", mountCmd, mountArgs)
 	command := exec.Command(mountCmd, mountArgs...)
 	output, err := command.CombinedOutput()
 	if err != nil {
-		log.Printf("Mount failed: %v\nMounting command: %s\nMounting arguments: %s %s %s %v\nOutput: %s\n", err, mountCmd, source, target, fstype, options, string(output))
+		loglevel.Errorf("Mount failed: %v\nMounting command: %s\nMounting arguments: %s %s %s %v\nOutput: %s\n", err, mountCmd, source, target, fstype, options, string(output))
 		return fmt.Errorf("mount failed: %v\nMounting command: %s\nMounting arguments: %s %s %s %v\nOutput: %s\n",
 			err, mountCmd, source, target, fstype, options, string(output))
 	}
@@ -148,7 +151,7 @@ func makeMountArgs(source, target, fstype string, options []string) []string {
 
 // Unmount unmounts the target.
 func (mounter *Mounter) Unmount(target string) error {
-	log.Printf("Unmounting %s", target)
+	loglevel.Debugf("Unmounting %s", target)
 	command := exec.Command("umount", target)
 	output, err := command.CombinedOutput()
 	if err != nil {
@@ -157,6 +160,18 @@ func (mounter *Mounter) Unmount(target string) error {
 	return nil
 }
 
+// UnmountLazy detaches target immediately via umount -l, rather than
+// waiting (or failing with EBUSY) for whatever's still using it to stop.
+func (mounter *Mounter) UnmountLazy(target string) error {
+	loglevel.Debugf("Lazily unmounting %s", target)
+	command := exec.Command("umount", "-l", target)
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("lazy unmount failed: %v\nUnmounting arguments: %s\nOutput: %s\n", err, target, string(output))
+	}
+	return nil
+}
+
 // List returns a list of all mounted filesystems.
 func (*Mounter) List() ([]MountPoint, error) {
 	return listProcMounts(procMountsPath)
@@ -213,7 +228,7 @@ func exclusiveOpenFailsOnDevice(pathname string) (bool, error) {
 			err)
 	}
 	if !isDevice {
-		log.Printf("Path %q is not refering to a device.", pathname)
+		loglevel.Debugf("Path %q is not refering to a device.", pathname)
 		return false, nil
 	}
 	fd, errno := syscall.Open(pathname, syscall.O_RDONLY|syscall.O_EXCL, 0)
@@ -331,7 +346,7 @@ func (mounter *SafeFormatAndMount) formatAndMount(source string, target string,
 	options = append(options, "defaults")
 
 	// Run fsck on the disk to fix repairable issues
-	log.Printf("Checking for issues with fsck on disk: %s", source)
+	loglevel.Debugf("Checking for issues with fsck on disk: %s", source)
 	args := []string{"-a", source}
 	cmd := mounter.Runner.Command("fsck", args...)
 	out, err := cmd.CombinedOutput()
@@ -339,18 +354,18 @@ func (mounter *SafeFormatAndMount) formatAndMount(source string, target string,
 		ee, isExitError := err.(utilexec.ExitError)
 		switch {
 		case err == utilexec.ErrExecutableNotFound:
-			log.Printf("'fsck' not found on system; continuing mount without running 'fsck'.")
+			loglevel.Warnf("'fsck' not found on system; continuing mount without running 'fsck'.")
 		case isExitError && ee.ExitStatus() == fsckErrorsCorrected:
-			log.Printf("Device %s has errors which were corrected by fsck.", source)
+			loglevel.Warnf("Device %s has errors which were corrected by fsck.", source)
 		case isExitError && ee.ExitStatus() == fsckErrorsUncorrected:
 			return fmt.Errorf("'fsck' found errors on device %s but could not correct them: %s.", source, string(out))
 		case isExitError && ee.ExitStatus() > fsckErrorsUncorrected:
-			log.Printf("`fsck` error %s", string(out))
+			loglevel.Warnf("`fsck` error %s", string(out))
 		}
 	}
 
 	// Try to mount the disk
-	log.Printf("Attempting to mount disk: %s %s %s", fstype, source, target)
+	loglevel.Debugf("Attempting to mount disk: %s %s %s", fstype, source, target)
 	mountErr := mounter.Interface.Mount(source, target, fstype, options)
 	if mountErr != nil {
 		// Mount failed. This indicates either that the disk is unformatted or
@@ -370,15 +385,15 @@ func (mounter *SafeFormatAndMount) formatAndMount(source string, target string,
 			if fstype == "ext4" || fstype == "ext3" {
 				args = []string{"-F", source}
 			}
-			log.Printf("Disk %q appears to be unformatted, attempting to format as type: %q with options: %v", source, fstype, args)
+			loglevel.Infof("Disk %q appears to be unformatted, attempting to format as type: %q with options: %v", source, fstype, args)
 			cmd := mounter.Runner.Command("mkfs."+fstype, args...)
 			_, err := cmd.CombinedOutput()
 			if err == nil {
 				// the disk has been formatted successfully try to mount it again.
-				log.Printf("Disk successfully formatted (mkfs): %s - %s %s", fstype, source, target)
+				loglevel.Infof("Disk successfully formatted (mkfs): %s - %s %s", fstype, source, target)
 				return mounter.Interface.Mount(source, target, fstype, options)
 			}
-			log.Printf("format of disk %q failed: type:(%q) target:(%q) options:(%q)error:(%v)", source, fstype, target, options, err)
+			loglevel.Errorf("format of disk %q failed: type:(%q) target:(%q) options:(%q)error:(%v)", source, fstype, target, options, err)
 			return err
 		} else {
 			// Disk is already formatted and failed to mount
@@ -394,17 +409,114 @@ func (mounter *SafeFormatAndMount) formatAndMount(source string, target string,
 	return mountErr
 }
 
+// DeviceSupportsDiscard reports whether the block device at devicePath can
+// honour a discard/TRIM request, by checking its sysfs
+// queue/discard_granularity - zero means the device (or whatever's
+// emulating it, e.g. an iSCSI target) doesn't support discard, per the
+// kernel's own convention for that file.
+func DeviceSupportsDiscard(devicePath string) (bool, error) {
+	real, err := filepath.EvalSymlinks(devicePath)
+	if err != nil {
+		return false, err
+	}
+
+	granularity, err := ioutil.ReadFile(filepath.Join("/sys/block", filepath.Base(real), "queue", "discard_granularity"))
+	if err != nil {
+		return false, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(string(granularity)))
+	if err != nil {
+		return false, fmt.Errorf("parsing discard_granularity for %q: %v", devicePath, err)
+	}
+	return n > 0, nil
+}
+
+// checkFilesystem runs a consistency check on source's existing
+// filesystem, for FormatAndMount's fsckBeforeMount option. It dispatches to
+// the read-only xfs_repair -n for xfs, since fsck/fsck.xfs doesn't meaningfully check an
+// xfs filesystem, and to fsck -a (the same tool and exit-code handling
+// formatAndMount above already runs unconditionally before every mount) for
+// everything else. A disk getDiskFormat reports as unformatted has nothing
+// to check, since formatAndMount is about to format it rather than mount it
+// as-is.
+func (mounter *SafeFormatAndMount) checkFilesystem(source, fstype string) error {
+	existingFormat, err := mounter.getDiskFormat(source)
+	if err != nil {
+		return err
+	}
+	if existingFormat == "" {
+		return nil
+	}
+
+	if existingFormat == "xfs" {
+		loglevel.Debugf("Checking for issues with xfs_repair -n on disk: %s", source)
+		out, err := mounter.Runner.Command("xfs_repair", "-n", source).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("'xfs_repair -n' found uncorrected errors on device %s: %v: %s", source, err, out)
+		}
+		return nil
+	}
+
+	loglevel.Debugf("Checking for issues with fsck on disk: %s", source)
+	out, err := mounter.Runner.Command("fsck", "-a", source).CombinedOutput()
+	if err != nil {
+		ee, isExitError := err.(utilexec.ExitError)
+		switch {
+		case err == utilexec.ErrExecutableNotFound:
+			loglevel.Warnf("'fsck' not found on system; continuing mount without checking fsckBeforeMount.")
+		case isExitError && ee.ExitStatus() == fsckErrorsCorrected:
+			loglevel.Warnf("Device %s has errors which were corrected by fsck.", source)
+		case isExitError && ee.ExitStatus() == fsckErrorsUncorrected:
+			return fmt.Errorf("'fsck' found errors on device %s but could not correct them: %s.", source, string(out))
+		case isExitError && ee.ExitStatus() > fsckErrorsUncorrected:
+			loglevel.Warnf("`fsck` error %s", string(out))
+		}
+	}
+	return nil
+}
+
+// ResizeFS grows the filesystem on devicePath (already mounted at
+// mountPath) to fill its underlying block device, dispatching to
+// resize2fs or xfs_growfs based on the filesystem type already on disk -
+// the same way formatAndMount dispatches to mkfs.<fstype> when formatting.
+func (mounter *SafeFormatAndMount) ResizeFS(devicePath, mountPath string) error {
+	format, err := mounter.getDiskFormat(devicePath)
+	if err != nil {
+		return fmt.Errorf("determining filesystem type of %q: %v", devicePath, err)
+	}
+
+	switch format {
+	case "ext3", "ext4":
+		loglevel.Infof("Resizing %s filesystem on %q with resize2fs", format, devicePath)
+		out, err := mounter.Runner.Command("resize2fs", devicePath).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("resize2fs %s failed: %v: %s", devicePath, err, out)
+		}
+	case "xfs":
+		loglevel.Infof("Resizing xfs filesystem on %q with xfs_growfs", mountPath)
+		out, err := mounter.Runner.Command("xfs_growfs", mountPath).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("xfs_growfs %s failed: %v: %s", mountPath, err, out)
+		}
+	default:
+		return fmt.Errorf("resizing a %q filesystem is not supported", format)
+	}
+
+	return nil
+}
+
 // diskLooksUnformatted uses 'lsblk' to see if the given disk is unformated
 func (mounter *SafeFormatAndMount) getDiskFormat(disk string) (string, error) {
 	args := []string{"-n", "-o", "FSTYPE", disk}
 	cmd := mounter.Runner.Command("lsblk", args...)
-	log.Printf("Attempting to determine if disk %q is formatted using lsblk with args: (%v)", disk, args)
+	loglevel.Debugf("Attempting to determine if disk %q is formatted using lsblk with args: (%v)", disk, args)
 	dataOut, err := cmd.CombinedOutput()
 	output := string(dataOut)
-	log.Printf("Output: %q", output)
+	loglevel.Debugf("Output: %q", output)
 
 	if err != nil {
-		log.Printf("Could not determine if disk %q is formatted (%v)", disk, err)
+		loglevel.Warnf("Could not determine if disk %q is formatted (%v)", disk, err)
 		return "", err
 	}
 
@@ -30,6 +30,10 @@ func (mounter *Mounter) Unmount(target string) error {
 	return nil
 }
 
+func (mounter *Mounter) UnmountLazy(target string) error {
+	return nil
+}
+
 func (mounter *Mounter) List() ([]MountPoint, error) {
 	return []MountPoint{}, nil
 }
@@ -58,6 +62,14 @@ func (mounter *SafeFormatAndMount) diskLooksUnformatted(disk string) (bool, erro
 	return true, nil
 }
 
+func (mounter *SafeFormatAndMount) checkFilesystem(source, fstype string) error {
+	return nil
+}
+
+func DeviceSupportsDiscard(devicePath string) (bool, error) {
+	return false, nil
+}
+
 func IsNotMountPoint(file string) (bool, error) {
 	return true, nil
 }
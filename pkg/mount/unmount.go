@@ -18,17 +18,34 @@ package mount
 
 import (
 	"fmt"
-	"log"
 	"os"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
 )
 
+// unmountRetryInterval is how long UnmountPathWithGracePeriod waits between
+// retries of a busy unmount, while it still has grace period left.
+const unmountRetryInterval = 2 * time.Second
+
 // UnmountPath is a common unmount routine that unmounts the given path and
-// deletes the remaining directory if successful.
+// deletes the remaining directory if successful. A busy unmount fails
+// immediately; see UnmountPathWithGracePeriod to retry and escalate instead.
 func UnmountPath(mountPath string, mounter Interface) error {
+	return UnmountPathWithGracePeriod(mountPath, mounter, 0)
+}
+
+// UnmountPathWithGracePeriod is UnmountPath, but a busy unmount is retried
+// (logging which processes are holding mountPath open each time, via
+// diagnoseBusyPath) until gracePeriod elapses, at which point it escalates
+// to a lazy unmount (see Interface.UnmountLazy) rather than failing
+// outright. A zero gracePeriod behaves exactly like UnmountPath: the first
+// busy unmount fails, with the same diagnosis attached to its error.
+func UnmountPathWithGracePeriod(mountPath string, mounter Interface, gracePeriod time.Duration) error {
 	if pathExists, pathErr := PathExists(mountPath); pathErr != nil {
 		return fmt.Errorf("Error checking if path exists: %v", pathErr)
 	} else if !pathExists {
-		log.Printf("Warning: Unmount skipped because path does not exist: %v", mountPath)
+		loglevel.Warnf("Unmount skipped because path does not exist: %v", mountPath)
 		return nil
 	}
 
@@ -37,23 +54,56 @@ func UnmountPath(mountPath string, mounter Interface) error {
 		return err
 	}
 	if notMnt {
-		log.Printf("Warning: %q is not a mountpoint, deleting", mountPath)
+		loglevel.Warnf("%q is not a mountpoint, deleting", mountPath)
 		return os.Remove(mountPath)
 	}
 
-	// Unmount the mount path
-	if err := mounter.Unmount(mountPath); err != nil {
-		return err
+	deadline := time.Now().Add(gracePeriod)
+	var lastErr error
+	for {
+		if unmountErr := mounter.Unmount(mountPath); unmountErr != nil {
+			lastErr = unmountErr
+		} else if notMnt, mntErr := mounter.IsLikelyNotMountPoint(mountPath); mntErr != nil {
+			return mntErr
+		} else if notMnt {
+			loglevel.Infof("%q is unmounted, deleting the directory", mountPath)
+			return os.Remove(mountPath)
+		} else {
+			lastErr = fmt.Errorf("%q is still a mountpoint after an unmount that reported success", mountPath)
+		}
+
+		if !time.Now().Before(deadline) {
+			break
+		}
+		loglevel.Warnf("%q busy, retrying in %s before a lazy unmount: %s", mountPath, unmountRetryInterval, diagnoseBusyPath(mountPath))
+		time.Sleep(unmountRetryInterval)
 	}
-	notMnt, mntErr := mounter.IsLikelyNotMountPoint(mountPath)
-	if mntErr != nil {
-		return err
+
+	if gracePeriod <= 0 {
+		return fmt.Errorf("Failed to unmount path %v: %v: %s", mountPath, lastErr, diagnoseBusyPath(mountPath))
 	}
-	if notMnt {
-		log.Printf("%q is unmounted, deleting the directory", mountPath)
-		return os.Remove(mountPath)
+
+	loglevel.Warnf("%q still busy after %s; forcing a lazy unmount: %s", mountPath, gracePeriod, diagnoseBusyPath(mountPath))
+	if err := mounter.UnmountLazy(mountPath); err != nil {
+		return fmt.Errorf("Failed to unmount path %v, then failed the lazy fallback: %v", mountPath, err)
+	}
+	return os.Remove(mountPath)
+}
+
+// diagnoseBusyPath names whichever processes (and, best effort, containers)
+// are still holding mountPath open, turning a bare EBUSY into something
+// that doesn't need a separate investigation to act on. If the scan itself
+// fails, or finds nothing, it says so rather than leaving the caller
+// guessing whether the scan ran at all.
+func diagnoseBusyPath(mountPath string) string {
+	procs, err := ProcessesUsingPath(mountPath)
+	if err != nil {
+		return fmt.Sprintf("could not determine which processes are using it: %v", err)
+	}
+	if len(procs) == 0 {
+		return "no process on this node appears to be using it; it may be held open in another mount namespace"
 	}
-	return fmt.Errorf("Failed to unmount path %v", mountPath)
+	return "in use by " + describeProcessesUsingPath(procs)
 }
 
 // PathExists returns true if the specified path exists.
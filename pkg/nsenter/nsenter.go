@@ -0,0 +1,103 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nsenter optionally wraps the driver's iscsiadm/mount/udevadm
+// callouts so they run inside the host's mount and network namespaces
+// instead of the driver's own. The traditional deployment runs the driver
+// binary directly on the host as a kubelet exec plugin, where this is a
+// no-op. A containerized deployment that can't grant the driver's container
+// a full root shell on the host can instead enable this wrapping and rely on
+// nsenter (itself still privileged) to reach just the namespaces the
+// callouts need, rather than the driver process needing broader host access
+// itself.
+package nsenter
+
+import (
+	"os"
+
+	"k8s.io/utils/exec"
+)
+
+const (
+	// EnableEnvVar, when set to a non-empty value, routes every command this
+	// driver execs through nsenter into the target PID's mount and network
+	// namespaces (see TargetPIDEnvVar). Unset (the default), commands run
+	// exactly as they always have.
+	EnableEnvVar = "OCI_FLEXD_NSENTER"
+
+	// TargetPIDEnvVar overrides which host PID's namespaces nsenter attaches
+	// to. Defaults to 1, the host's init process, which is what a
+	// containerized deployment reaches when it runs the driver with
+	// hostPID enabled.
+	TargetPIDEnvVar = "OCI_FLEXD_NSENTER_TARGET_PID"
+
+	nsenterCommand   = "nsenter"
+	defaultTargetPID = "1"
+)
+
+// Enabled reports whether command execution should be wrapped with nsenter,
+// per EnableEnvVar.
+func Enabled() bool {
+	return os.Getenv(EnableEnvVar) != ""
+}
+
+// targetPID returns the host PID whose namespaces commands are nsentered
+// into, per TargetPIDEnvVar.
+func targetPID() string {
+	if pid := os.Getenv(TargetPIDEnvVar); pid != "" {
+		return pid
+	}
+	return defaultTargetPID
+}
+
+// MaybeWrap returns runner unchanged if nsenter wrapping isn't enabled (see
+// Enabled), and otherwise returns an exec.Interface that runs every command
+// nsentered into the target PID's mount and network namespaces, picking up
+// no other elevated capabilities along the way - no full root shell, just
+// the two namespaces the callouts actually need to see the host's iSCSI
+// sessions and mounts.
+func MaybeWrap(runner exec.Interface) exec.Interface {
+	if !Enabled() {
+		return runner
+	}
+	return &wrapper{inner: runner, targetPID: targetPID()}
+}
+
+// wrapper implements exec.Interface by prefixing every command with an
+// nsenter invocation.
+type wrapper struct {
+	inner     exec.Interface
+	targetPID string
+}
+
+func (w *wrapper) Command(cmd string, args ...string) exec.Cmd {
+	return w.inner.Command(nsenterCommand, w.nsenterArgs(cmd, args...)...)
+}
+
+func (w *wrapper) LookPath(file string) (string, error) {
+	// Resolution needs to happen against the target namespace's $PATH, not
+	// this process's, and nsenter does that itself when it execs the command
+	// we hand it. Returning file unchanged defers that resolution to nsenter.
+	return file, nil
+}
+
+func (w *wrapper) nsenterArgs(cmd string, args ...string) []string {
+	nsenterArgs := []string{
+		"--mount=/proc/" + w.targetPID + "/ns/mnt",
+		"--net=/proc/" + w.targetPID + "/ns/net",
+		"--",
+		cmd,
+	}
+	return append(nsenterArgs, args...)
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsenter
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/exec"
+	fakeexec "k8s.io/utils/exec/testing"
+)
+
+func TestMaybeWrapDisabledByDefault(t *testing.T) {
+	os.Unsetenv(EnableEnvVar)
+
+	inner := &fakeexec.FakeExec{}
+	got := MaybeWrap(inner)
+	if got != exec.Interface(inner) {
+		t.Errorf("MaybeWrap() = %v; want the unwrapped runner when %s is unset", got, EnableEnvVar)
+	}
+}
+
+func TestMaybeWrapPrependsNsenter(t *testing.T) {
+	os.Setenv(EnableEnvVar, "1")
+	defer os.Unsetenv(EnableEnvVar)
+	os.Unsetenv(TargetPIDEnvVar)
+
+	var gotCmd string
+	var gotArgs []string
+	inner := &fakeexec.FakeExec{
+		CommandScript: []fakeexec.FakeCommandAction{
+			func(cmd string, args ...string) exec.Cmd {
+				gotCmd = cmd
+				gotArgs = args
+				return &fakeexec.FakeCmd{}
+			},
+		},
+	}
+
+	wrapped := MaybeWrap(inner)
+	wrapped.Command("iscsiadm", "-m", "node", "-l")
+
+	if gotCmd != nsenterCommand {
+		t.Fatalf("inner.Command() called with %q; want %q", gotCmd, nsenterCommand)
+	}
+	want := []string{"--mount=/proc/1/ns/mnt", "--net=/proc/1/ns/net", "--", "iscsiadm", "-m", "node", "-l"}
+	if !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("inner.Command() args = %v; want %v", gotArgs, want)
+	}
+}
+
+func TestMaybeWrapHonoursTargetPIDEnvVar(t *testing.T) {
+	os.Setenv(EnableEnvVar, "1")
+	defer os.Unsetenv(EnableEnvVar)
+	os.Setenv(TargetPIDEnvVar, "4242")
+	defer os.Unsetenv(TargetPIDEnvVar)
+
+	var gotArgs []string
+	inner := &fakeexec.FakeExec{
+		CommandScript: []fakeexec.FakeCommandAction{
+			func(cmd string, args ...string) exec.Cmd {
+				gotArgs = args
+				return &fakeexec.FakeCmd{}
+			},
+		},
+	}
+
+	MaybeWrap(inner).Command("mount")
+
+	want := []string{"--mount=/proc/4242/ns/mnt", "--net=/proc/4242/ns/net", "--", "mount"}
+	if !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("inner.Command() args = %v; want %v", gotArgs, want)
+	}
+}
+
+func TestWrapperLookPathDefersToNsenter(t *testing.T) {
+	os.Setenv(EnableEnvVar, "1")
+	defer os.Unsetenv(EnableEnvVar)
+
+	wrapped := MaybeWrap(&fakeexec.FakeExec{})
+	got, err := wrapped.LookPath("iscsiadm")
+	if err != nil {
+		t.Fatalf("LookPath() => %v; want nil error", err)
+	}
+	if got != "iscsiadm" {
+		t.Errorf("LookPath() = %q; want %q", got, "iscsiadm")
+	}
+}
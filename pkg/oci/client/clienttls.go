@@ -0,0 +1,73 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+)
+
+// ClientTLS holds a client certificate and key presented to any
+// TLS-terminating egress proxy (see OCI_PROXY in configureCustomTransport)
+// that requires mutual TLS. Each half may be supplied either as a path to a
+// PEM file or as inline PEM content; if both are set for a given half, the
+// path takes precedence.
+type ClientTLS struct {
+	CertPath string `yaml:"certPath"`
+	CertPEM  string `yaml:"certPEM"`
+	KeyPath  string `yaml:"keyPath"`
+	KeyPEM   string `yaml:"keyPEM"`
+}
+
+// certificate loads c's certificate/key pair, returning ok=false if neither
+// half has been configured.
+func (c ClientTLS) certificate() (cert tls.Certificate, ok bool, err error) {
+	if c.CertPath == "" && c.CertPEM == "" && c.KeyPath == "" && c.KeyPEM == "" {
+		return tls.Certificate{}, false, nil
+	}
+
+	certPEM, err := resolvePEM(c.CertPath, c.CertPEM, "client certificate")
+	if err != nil {
+		return tls.Certificate{}, false, err
+	}
+	keyPEM, err := resolvePEM(c.KeyPath, c.KeyPEM, "client key")
+	if err != nil {
+		return tls.Certificate{}, false, err
+	}
+
+	cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("failed to load client TLS certificate: %v", err)
+	}
+	return cert, true, nil
+}
+
+// resolvePEM returns PEM content either read from path or, if path is unset,
+// the given inline content. what describes the PEM content for error
+// messages.
+func resolvePEM(path, inline, what string) ([]byte, error) {
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from %q: %v", what, path, err)
+		}
+		return b, nil
+	}
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	return nil, fmt.Errorf("%s not provided", what)
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func generateTestCertAndKey(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate test key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to generate test certificate: %v", err)
+	}
+
+	var certBuf, keyBuf bytes.Buffer
+	pem.Encode(&certBuf, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	pem.Encode(&keyBuf, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certBuf.Bytes(), keyBuf.Bytes()
+}
+
+func TestClientTLSCertificate(t *testing.T) {
+	certPEM, keyPEM := generateTestCertAndKey(t)
+
+	dir, err := ioutil.TempDir("", "clienttls")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(certPath, certPEM, 0600); err != nil {
+		t.Fatalf("failed to write test cert: %v", err)
+	}
+	if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatalf("failed to write test key: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		in        ClientTLS
+		wantOK    bool
+		shouldErr bool
+	}{
+		{"unset", ClientTLS{}, false, false},
+		{"from paths", ClientTLS{CertPath: certPath, KeyPath: keyPath}, true, false},
+		{"inline", ClientTLS{CertPEM: string(certPEM), KeyPEM: string(keyPEM)}, true, false},
+		{"missing key", ClientTLS{CertPEM: string(certPEM)}, false, true},
+		{"bad path", ClientTLS{CertPath: "/nonexistent", KeyPath: keyPath}, false, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok, err := tt.in.certificate()
+			if tt.shouldErr && err == nil {
+				t.Fatal("expected an error, got none")
+			}
+			if !tt.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("certificate() ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
@@ -19,16 +19,23 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
 	"github.com/oracle/oci-flexvolume-driver/pkg/oci/instancemeta"
+	"github.com/oracle/oci-flexvolume-driver/pkg/secret"
 )
 
+// newVaultSecretProvider is a package var so tests can substitute a
+// Provider that actually returns something for PrivateKeyVaultSecretOCID,
+// without this build needing a real Vault secrets client (see
+// secret.VaultProvider).
+var newVaultSecretProvider = func() secret.Provider { return secret.VaultProvider{} }
+
 var ociRegions = map[string]string{
 	"iad": "us-ashburn-1",
 	"fra": "eu-frankfurt-1",
@@ -40,24 +47,141 @@ var ociRegions = map[string]string{
 // AuthConfig holds the configuration required for communicating with the OCI
 // API.
 type AuthConfig struct {
-	Region               string `yaml:"region"`
-	RegionKey            string `yaml:"regionKey"`
-	TenancyOCID          string `yaml:"tenancy"`
-	CompartmentOCID      string `yaml:"compartment"` // DEPRECATED (we no longer directly use this)
-	UserOCID             string `yaml:"user"`
-	PrivateKey           string `yaml:"key"`
+	Region          string `yaml:"region"`
+	RegionKey       string `yaml:"regionKey"`
+	TenancyOCID     string `yaml:"tenancy"`
+	CompartmentOCID string `yaml:"compartment"` // DEPRECATED (we no longer directly use this)
+	UserOCID        string `yaml:"user"`
+	PrivateKey      string `yaml:"key"`
+
+	// PrivateKeyVaultSecretOCID, if set instead of PrivateKey, is resolved
+	// at config load time via an OCI Vault secret lookup (bootstrapped
+	// using instance principals, so no separate key is needed just to
+	// fetch this one) and the result kept in AuthConfig.PrivateKey in
+	// memory only - it is never written back to config.yaml on disk. See
+	// resolveVaultKey.
+	PrivateKeyVaultSecretOCID string `yaml:"keyVaultSecretOcid"`
+
 	Passphrase           string `yaml:"passphrase"`
 	PrivateKeyPassphrase string `yaml:"key_passphase"` // DEPRECATED
 	Fingerprint          string `yaml:"fingerprint"`
 	VcnOCID              string `yaml:"vcn"`
+
+	// VcnOCIDs is VcnOCID's plural counterpart, for a cluster whose nodes
+	// are spread across more than one VCN. Set at most one of vcn/vcns -
+	// see Config.AllVcnOCIDs for the combined list this driver actually
+	// consults.
+	VcnOCIDs []string `yaml:"vcns"`
+
+	// SecurityTokenFile and SecurityTokenPrivateKeyFile, if set instead of
+	// the user/fingerprint/key triple above, authenticate with an OCI
+	// session token (as produced by `oci session authenticate` or kept
+	// fresh by `oci session refresh`) rather than a long-lived API
+	// signing key - see sessionTokenConfigurationProvider. The session's
+	// ephemeral keypair signs requests; the token itself, re-read from
+	// SecurityTokenFile on every request, is what the OCI API checks it
+	// against.
+	SecurityTokenFile           string `yaml:"securityTokenFile"`
+	SecurityTokenPrivateKeyFile string `yaml:"securityTokenPrivateKeyFile"`
 }
 
 // Config holds the configuration for the OCI flexvolume driver.
 type Config struct {
 	Auth AuthConfig `yaml:"auth"`
 
+	// RealmProfile identifies the OCI realm this driver is deployed into.
+	// Defaults to RealmOC1 (commercial). See Endpoints.
+	RealmProfile string `yaml:"realmProfile"`
+
+	// Endpoints holds explicit per-service endpoint overrides, required on
+	// realms where endpoints cannot be derived from the region (see
+	// RealmProfile).
+	Endpoints Endpoints `yaml:"endpoints"`
+
+	// ClientTLS holds a client certificate to present to an OCI_PROXY egress
+	// proxy that requires mutual TLS.
+	ClientTLS ClientTLS `yaml:"clientTLS"`
+
+	// TLS holds minimum version/cipher suite overrides for the OCI
+	// transport. FIPS mode (see OCI_FLEXD_FIPS_MODE) takes precedence over
+	// these where the two overlap.
+	TLS TLSConfig `yaml:"tls"`
+
+	// NodeResolutionStrategies orders the strategies used to resolve a
+	// Kubernetes node to its OCI instance, tried in turn until one
+	// matches. Defaults to DefaultNodeResolutionStrategies
+	// (providerID-only) when unset, this driver's long-standing
+	// behaviour. A cluster whose node names don't match any of the
+	// fields providerID falls back to can pin a different strategy (or
+	// order) here instead.
+	NodeResolutionStrategies []string `yaml:"nodeResolutionStrategies"`
+
+	// AttachmentWaitStrategy selects how the client polls the OCI API while
+	// waiting for a volume attach/detach/availability operation to
+	// complete. One of "fixed" (default) or "exponential". See
+	// WaitStrategyEvent for why "event" is accepted here but rejected by
+	// validation.
+	AttachmentWaitStrategy string `yaml:"attachmentWaitStrategy"`
+
+	// AttachmentPolling overrides how long and how often the client polls
+	// the OCI API for a volume attach/detach/availability operation to
+	// complete (see AttachmentWaitStrategy). Attach and detach share these
+	// values - there's one polling loop shape in this client, not a
+	// separate one per operation - so clusters against a slow AD or with
+	// unusually large volumes can tune the wait without a code change.
+	AttachmentPolling AttachmentPolling `yaml:"attachmentPolling"`
+
+	// Timeouts overrides the per-request OCI API call timeout and the
+	// dialer/TLS handshake timeouts used to build this client's transport.
+	// Unset fields keep this client's historical hardcoded defaults.
+	Timeouts Timeouts `yaml:"timeouts"`
+
 	metadata              instancemeta.Interface
 	UseInstancePrincipals bool `yaml:"useInstancePrincipals"`
+
+	// EncryptionInTransitEnabled is the default for whether iSCSI
+	// attachments request in-transit encryption, used unless a PV overrides
+	// it with the kubernetes.io/encryptInTransit option (see
+	// flexvolume.OptionEncryptInTransit).
+	EncryptionInTransitEnabled bool `yaml:"encryptionInTransitEnabled"`
+
+	// AuthProfiles maps a pod service account name (as seen in the
+	// kubernetes.io/serviceAccount.name option) to an AuthConfig used in
+	// place of Auth for calls made on that pod's behalf, so different
+	// namespaces can attach volumes with differently-scoped credentials.
+	// Unlike Auth, a profile's fields are never filled in from the OCI
+	// metadata service: each profile must be fully specified.
+	AuthProfiles map[string]AuthConfig `yaml:"authProfiles"`
+}
+
+// AttachmentPolling overrides the interval and overall timeout used while
+// polling the OCI API for a volume attach/detach/availability operation to
+// complete. A zero IntervalSeconds or TimeoutSeconds falls back to this
+// client's historical hardcoded defaults (ociWaitDuration, ociMaxRetries).
+type AttachmentPolling struct {
+	IntervalSeconds int `yaml:"intervalSeconds"`
+	TimeoutSeconds  int `yaml:"timeoutSeconds"`
+}
+
+// AuthConfigForServiceAccount returns the AuthProfiles entry for
+// serviceAccountName, or c.Auth if serviceAccountName is empty or has no
+// matching profile.
+func (c *Config) AuthConfigForServiceAccount(serviceAccountName string) AuthConfig {
+	if profile, ok := c.AuthProfiles[serviceAccountName]; serviceAccountName != "" && ok {
+		return profile
+	}
+	return c.Auth
+}
+
+// AllVcnOCIDs returns every VCN OCID this config's auth block names,
+// combining the singular auth.vcn and the plural auth.vcns into one list so
+// callers don't need to check both fields themselves. ValidateConfig
+// ensures at most one of the two is actually set.
+func (a AuthConfig) AllVcnOCIDs() []string {
+	if a.VcnOCID != "" {
+		return []string{a.VcnOCID}
+	}
+	return a.VcnOCIDs
 }
 
 // NewConfig creates a new Config based on the contents of the given io.Reader.
@@ -79,6 +203,10 @@ func NewConfig(r io.Reader) (*Config, error) {
 
 	c.metadata = instancemeta.New()
 
+	if err := c.resolveVaultKey(); err != nil {
+		return nil, err
+	}
+
 	if !c.UseInstancePrincipals {
 		if err := c.setDefaults(); err != nil {
 			return nil, err
@@ -103,7 +231,35 @@ func ConfigFromFile(path string) (*Config, error) {
 	return NewConfig(f)
 }
 
+// resolveVaultKey fills in c.Auth.PrivateKey from
+// c.Auth.PrivateKeyVaultSecretOCID, if set, clearing the latter once
+// resolved so the two fields stay mutually exclusive from validate's point
+// of view. AuthProfiles don't get this: each profile must already be
+// fully specified (see the AuthProfiles doc comment), same as everywhere
+// else a profile diverges from Auth's instance-metadata-assisted
+// defaulting.
+func (c *Config) resolveVaultKey() error {
+	if c.Auth.PrivateKeyVaultSecretOCID == "" {
+		return nil
+	}
+	key, err := newVaultSecretProvider().Get(c.Auth.PrivateKeyVaultSecretOCID)
+	if err != nil {
+		return fmt.Errorf("resolving auth.keyVaultSecretOcid: %v", err)
+	}
+	c.Auth.PrivateKey = key
+	c.Auth.PrivateKeyVaultSecretOCID = ""
+	return nil
+}
+
 func (c *Config) setDefaults() error {
+	if c.RealmProfile == "" {
+		c.RealmProfile = RealmOC1
+	}
+
+	if c.AttachmentWaitStrategy == "" {
+		c.AttachmentWaitStrategy = WaitStrategyFixed
+	}
+
 	if c.Auth.Region == "" || c.Auth.CompartmentOCID == "" {
 		meta, err := c.metadata.Get()
 		if err != nil {
@@ -124,7 +280,7 @@ func (c *Config) setDefaults() error {
 	}
 
 	if c.Auth.Passphrase == "" && c.Auth.PrivateKeyPassphrase != "" {
-		log.Print("config: auth.key_passphrase is DEPRECIATED and will be removed in a later release. Please set auth.passphrase instead.")
+		loglevel.Warnf("config: auth.key_passphrase is DEPRECIATED and will be removed in a later release. Please set auth.passphrase instead.")
 		c.Auth.Passphrase = c.Auth.PrivateKeyPassphrase
 	}
 
@@ -183,9 +339,41 @@ func validateAuthConfig(c *Config, fldPath *field.Path) field.ErrorList {
 		if c.Auth.PrivateKey != "" {
 			errList = append(errList, field.Forbidden(fldPath.Child("key"), "cannot be used when useInstancePrincipals is enabled"))
 		}
+		if c.Auth.PrivateKeyVaultSecretOCID != "" {
+			errList = append(errList, field.Forbidden(fldPath.Child("keyVaultSecretOcid"), "cannot be used when useInstancePrincipals is enabled"))
+		}
 		if c.Auth.Fingerprint != "" {
 			errList = append(errList, field.Forbidden(fldPath.Child("fingerprint"), "cannot be used when useInstancePrincipals is enabled"))
 		}
+		if c.Auth.SecurityTokenFile != "" {
+			errList = append(errList, field.Forbidden(fldPath.Child("securityTokenFile"), "cannot be used when useInstancePrincipals is enabled"))
+		}
+	} else if c.Auth.SecurityTokenFile != "" {
+		if c.Auth.Region == "" {
+			errList = append(errList, field.Required(fldPath.Child("region"), ""))
+		}
+		if c.Auth.SecurityTokenPrivateKeyFile == "" {
+			errList = append(errList, field.Required(fldPath.Child("securityTokenPrivateKeyFile"), ""))
+		}
+		// A session token already encodes the tenancy and user it was
+		// issued for (see sessionTokenConfigurationProvider), and the OCI
+		// API identifies its signing key from the token itself rather
+		// than a fingerprint - so none of these belong alongside it.
+		if c.Auth.TenancyOCID != "" {
+			errList = append(errList, field.Forbidden(fldPath.Child("tenancy"), "cannot be used alongside securityTokenFile; the token already encodes the tenancy"))
+		}
+		if c.Auth.UserOCID != "" {
+			errList = append(errList, field.Forbidden(fldPath.Child("user"), "cannot be used alongside securityTokenFile; the token already encodes the user"))
+		}
+		if c.Auth.PrivateKey != "" {
+			errList = append(errList, field.Forbidden(fldPath.Child("key"), "cannot be used alongside securityTokenFile; set securityTokenPrivateKeyFile instead"))
+		}
+		if c.Auth.PrivateKeyVaultSecretOCID != "" {
+			errList = append(errList, field.Forbidden(fldPath.Child("keyVaultSecretOcid"), "cannot be used alongside securityTokenFile"))
+		}
+		if c.Auth.Fingerprint != "" {
+			errList = append(errList, field.Forbidden(fldPath.Child("fingerprint"), "cannot be used alongside securityTokenFile; the token is its own key identifier"))
+		}
 	} else {
 		if c.Auth.Region == "" {
 			errList = append(errList, field.Required(fldPath.Child("region"), ""))
@@ -196,8 +384,10 @@ func validateAuthConfig(c *Config, fldPath *field.Path) field.ErrorList {
 		if c.Auth.UserOCID == "" {
 			errList = append(errList, field.Required(fldPath.Child("user"), ""))
 		}
-		if c.Auth.PrivateKey == "" {
-			errList = append(errList, field.Required(fldPath.Child("key"), ""))
+		if c.Auth.PrivateKey == "" && c.Auth.PrivateKeyVaultSecretOCID == "" {
+			errList = append(errList, field.Required(fldPath.Child("key"), "or keyVaultSecretOcid must be set"))
+		} else if c.Auth.PrivateKey != "" && c.Auth.PrivateKeyVaultSecretOCID != "" {
+			errList = append(errList, field.Invalid(fldPath.Child("keyVaultSecretOcid"), c.Auth.PrivateKeyVaultSecretOCID, "cannot be set alongside key"))
 		}
 		if c.Auth.Fingerprint == "" {
 			errList = append(errList, field.Required(fldPath.Child("fingerprint"), ""))
@@ -208,8 +398,79 @@ func validateAuthConfig(c *Config, fldPath *field.Path) field.ErrorList {
 		errList = append(errList, field.Required(fldPath.Child("region_key"), ""))
 	}
 
-	if c.Auth.VcnOCID == "" {
-		errList = append(errList, field.Required(fldPath.Child("vcn"), ""))
+	if c.Auth.VcnOCID == "" && len(c.Auth.VcnOCIDs) == 0 {
+		errList = append(errList, field.Required(fldPath.Child("vcn"), "or vcns must be set"))
+	} else if c.Auth.VcnOCID != "" && len(c.Auth.VcnOCIDs) > 0 {
+		errList = append(errList, field.Invalid(fldPath.Child("vcns"), c.Auth.VcnOCIDs, "cannot be set alongside vcn"))
+	}
+
+	return errList
+}
+
+func validateAuthProfiles(c *Config) field.ErrorList {
+	errList := field.ErrorList{}
+
+	for name, profile := range c.AuthProfiles {
+		fldPath := field.NewPath("authProfiles").Key(name)
+		errList = append(errList, validateAuthConfig(&Config{Auth: profile}, fldPath)...)
+		if profile.PrivateKeyVaultSecretOCID != "" {
+			errList = append(errList, field.Forbidden(fldPath.Child("keyVaultSecretOcid"), "not supported in authProfiles; resolved only for auth"))
+		}
+	}
+
+	return errList
+}
+
+func validateRealmProfile(c *Config) field.ErrorList {
+	errList := field.ErrorList{}
+
+	if c.RealmProfile != "" && !knownRealmProfiles[c.RealmProfile] {
+		errList = append(errList, field.NotSupported(field.NewPath("realmProfile"), c.RealmProfile, knownRealmProfileNames()))
+	}
+
+	return errList
+}
+
+func validateAttachmentWaitStrategy(c *Config) field.ErrorList {
+	errList := field.ErrorList{}
+
+	if c.AttachmentWaitStrategy != "" && !knownWaitStrategies[c.AttachmentWaitStrategy] {
+		errList = append(errList, field.NotSupported(field.NewPath("attachmentWaitStrategy"), c.AttachmentWaitStrategy, knownWaitStrategyNames()))
+	} else if c.AttachmentWaitStrategy == WaitStrategyEvent {
+		errList = append(errList, field.Invalid(field.NewPath("attachmentWaitStrategy"), c.AttachmentWaitStrategy, "not supported by this build: the vendored OCI SDK has no work request client"))
+	}
+
+	return errList
+}
+
+func validateNodeResolutionStrategies(c *Config) field.ErrorList {
+	errList := field.ErrorList{}
+	fldPath := field.NewPath("nodeResolutionStrategies")
+
+	for i, strategy := range c.NodeResolutionStrategies {
+		if !knownNodeResolutionStrategies[strategy] {
+			errList = append(errList, field.NotSupported(fldPath.Index(i), strategy, knownNodeResolutionStrategyNames()))
+		} else if notImplementedNodeResolutionStrategies[strategy] {
+			errList = append(errList, field.Invalid(fldPath.Index(i), strategy, "not supported by this build: needs a VNIC attachment scan this driver doesn't perform"))
+		}
+	}
+
+	return errList
+}
+
+func validateAttachmentPolling(c *Config) field.ErrorList {
+	errList := field.ErrorList{}
+	fldPath := field.NewPath("attachmentPolling")
+
+	if c.AttachmentPolling.IntervalSeconds < 0 {
+		errList = append(errList, field.Invalid(fldPath.Child("intervalSeconds"), c.AttachmentPolling.IntervalSeconds, "must not be negative"))
+	}
+	if c.AttachmentPolling.TimeoutSeconds < 0 {
+		errList = append(errList, field.Invalid(fldPath.Child("timeoutSeconds"), c.AttachmentPolling.TimeoutSeconds, "must not be negative"))
+	}
+	if c.AttachmentPolling.IntervalSeconds > 0 && c.AttachmentPolling.TimeoutSeconds > 0 &&
+		c.AttachmentPolling.TimeoutSeconds < c.AttachmentPolling.IntervalSeconds {
+		errList = append(errList, field.Invalid(fldPath.Child("timeoutSeconds"), c.AttachmentPolling.TimeoutSeconds, "must not be less than intervalSeconds"))
 	}
 
 	return errList
@@ -219,5 +480,10 @@ func validateAuthConfig(c *Config, fldPath *field.Path) field.ErrorList {
 func ValidateConfig(c *Config) field.ErrorList {
 	allErrs := field.ErrorList{}
 	allErrs = append(allErrs, validateAuthConfig(c, field.NewPath("auth"))...)
+	allErrs = append(allErrs, validateAuthProfiles(c)...)
+	allErrs = append(allErrs, validateRealmProfile(c)...)
+	allErrs = append(allErrs, validateAttachmentWaitStrategy(c)...)
+	allErrs = append(allErrs, validateNodeResolutionStrategies(c)...)
+	allErrs = append(allErrs, validateAttachmentPolling(c)...)
 	return allErrs
 }
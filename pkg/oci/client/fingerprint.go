@@ -0,0 +1,45 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ConfigFingerprint summarises the identity- and VCN-defining parts of c as
+// a short opaque string. config.yaml is re-read from disk on every driver
+// invocation (there's no long-running process to hold it in memory), so
+// any on-disk, cross-invocation state keyed off it - see the isAttached
+// cache in pkg/oci/driver - needs its own way to tell "the same config,
+// reloaded" apart from "credentials or the VCN changed out from under me",
+// rather than risk mixing cache entries populated under one tenancy/VCN
+// with lookups made under another.
+func ConfigFingerprint(c *Config) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%t|%s|%s|%s|%s|%s|%s|%s",
+		c.UseInstancePrincipals,
+		c.Auth.TenancyOCID,
+		c.Auth.UserOCID,
+		c.Auth.Fingerprint,
+		c.Auth.CompartmentOCID,
+		strings.Join(c.Auth.AllVcnOCIDs(), ","),
+		c.Auth.SecurityTokenFile,
+		c.Auth.PrivateKeyVaultSecretOCID,
+	)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,62 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "testing"
+
+func TestConfigFingerprintStableForEquivalentConfig(t *testing.T) {
+	a := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCID: "ocid1.vcn.oc1.phx.aaaa"}}
+	b := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCID: "ocid1.vcn.oc1.phx.aaaa"}}
+
+	if ConfigFingerprint(a) != ConfigFingerprint(b) {
+		t.Error("ConfigFingerprint() differed for two configs with identical identity and VCN fields")
+	}
+}
+
+func TestConfigFingerprintChangesWithVcn(t *testing.T) {
+	a := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCID: "ocid1.vcn.oc1.phx.aaaa"}}
+	b := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCID: "ocid1.vcn.oc1.phx.bbbb"}}
+
+	if ConfigFingerprint(a) == ConfigFingerprint(b) {
+		t.Error("ConfigFingerprint() matched for two configs with different VCNs")
+	}
+}
+
+func TestConfigFingerprintStableForEquivalentVcnOCIDs(t *testing.T) {
+	a := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCID: "ocid1.vcn.oc1.phx.aaaa"}}
+	b := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCIDs: []string{"ocid1.vcn.oc1.phx.aaaa"}}}
+
+	if ConfigFingerprint(a) != ConfigFingerprint(b) {
+		t.Error("ConfigFingerprint() differed between a singular vcn and an equivalent single-element vcns")
+	}
+}
+
+func TestConfigFingerprintChangesWithVcnOCIDs(t *testing.T) {
+	a := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCIDs: []string{"ocid1.vcn.oc1.phx.aaaa"}}}
+	b := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCIDs: []string{"ocid1.vcn.oc1.phx.aaaa", "ocid1.vcn.oc1.phx.bbbb"}}}
+
+	if ConfigFingerprint(a) == ConfigFingerprint(b) {
+		t.Error("ConfigFingerprint() matched for two configs with different vcns lists")
+	}
+}
+
+func TestConfigFingerprintChangesWithTenancy(t *testing.T) {
+	a := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..aaaa", VcnOCID: "ocid1.vcn.oc1.phx.aaaa"}}
+	b := &Config{Auth: AuthConfig{TenancyOCID: "ocid1.tenancy.oc1..bbbb", VcnOCID: "ocid1.vcn.oc1.phx.aaaa"}}
+
+	if ConfigFingerprint(a) == ConfigFingerprint(b) {
+		t.Error("ConfigFingerprint() matched for two configs with different tenancies")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+// debugHTTPEnvVar, when set to "true", makes configureCustomTransport wrap
+// the OCI API clients' transport with a debugTransport, so that the full
+// request/response bodies behind an attach failure - not just the error
+// string the SDK call ultimately returns - end up in the driver's own log
+// file. It's opt-in and off by default because it's verbose even at the
+// "debug" log level and because, despite the redaction below, logging raw
+// API bodies at all is more than most deployments want on by default.
+const debugHTTPEnvVar = "OCI_FLEXD_DEBUG_HTTP"
+
+func debugHTTPEnabled() bool {
+	return os.Getenv(debugHTTPEnvVar) == "true"
+}
+
+// redactedJSONKey matches the JSON object keys, anywhere in a request or
+// response body, whose values get replaced before logging: the private key
+// material and passphrase from AuthConfig, and anything else that looks like
+// a credential by name. The OCI API itself doesn't echo these back, but the
+// driver's own request bodies can carry them (e.g. a future request that
+// embeds AuthConfig), so the redaction runs over both directions.
+var redactedJSONKey = regexp.MustCompile(`(?i)(key|secret|password|passphrase|token|fingerprint)`)
+
+// debugTransport wraps another http.RoundTripper, logging every request and
+// response it sees through loglevel.Debugf with sensitive headers and JSON
+// body fields redacted first. See debugHTTPEnvVar.
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+// wrapWithDebugTransport returns a RoundTripper that logs through next if
+// HTTP debug logging is enabled, or next itself unchanged otherwise.
+func wrapWithDebugTransport(next http.RoundTripper) http.RoundTripper {
+	if !debugHTTPEnabled() {
+		return next
+	}
+	return &debugTransport{next: next}
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := drainAndRestore(&req.Body)
+	if err != nil {
+		loglevel.Warnf("debug http: failed to read request body for %s %s: %v", req.Method, req.URL, err)
+	}
+	loglevel.Debugf("oci api request: %s %s headers=%s body=%s",
+		req.Method, req.URL, redactHeaders(req.Header), redactJSONBody(reqBody))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		loglevel.Debugf("oci api request failed: %s %s: %v", req.Method, req.URL, err)
+		return resp, err
+	}
+
+	respBody, berr := drainAndRestore(&resp.Body)
+	if berr != nil {
+		loglevel.Warnf("debug http: failed to read response body for %s %s: %v", req.Method, req.URL, berr)
+	}
+	loglevel.Debugf("oci api response: %s %s status=%s headers=%s body=%s",
+		req.Method, req.URL, resp.Status, redactHeaders(resp.Header), redactJSONBody(respBody))
+
+	return resp, err
+}
+
+// drainAndRestore reads *body fully and replaces it with a fresh reader over
+// the same bytes, so that logging a request/response doesn't consume the
+// body the SDK still needs to send or parse. A nil *body is left as nil.
+func drainAndRestore(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// redactHeaders returns a copy of h with the Authorization header - the OCI
+// request signature - replaced, since it's effectively a bearer credential
+// for the duration of the signed request.
+func redactHeaders(h http.Header) http.Header {
+	redacted := h.Clone()
+	if redacted.Get("Authorization") != "" {
+		redacted.Set("Authorization", "REDACTED")
+	}
+	return redacted
+}
+
+// redactJSONBody returns b with any JSON object value whose key matches
+// redactedJSONKey replaced by "REDACTED", for logging. An empty body (as on
+// most GET requests) is logged as "<empty>", and one that isn't valid JSON is
+// summarised by length instead of logged verbatim, since it can't be walked
+// key by key to redact it; a bare JSON scalar is logged as it is.
+func redactJSONBody(b []byte) string {
+	if len(b) == 0 {
+		return "<empty>"
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Sprintf("<%d bytes, not JSON>", len(b))
+	}
+
+	redacted, err := json.Marshal(redactJSONValue(v))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes, failed to redact: %v>", len(b), err)
+	}
+	return string(redacted)
+}
+
+// redactJSONValue walks v - the result of unmarshalling a request/response
+// body into interface{} - replacing any object value keyed by something
+// redactedJSONKey matches.
+func redactJSONValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			if redactedJSONKey.MatchString(k) {
+				redacted[k] = "REDACTED"
+				continue
+			}
+			redacted[k] = redactJSONValue(val)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(t))
+		for i, val := range t {
+			redacted[i] = redactJSONValue(val)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWrapWithDebugTransportNoopWhenDisabled(t *testing.T) {
+	os.Unsetenv(debugHTTPEnvVar)
+
+	next := http.DefaultTransport
+	if got := wrapWithDebugTransport(next); got != next {
+		t.Errorf("wrapWithDebugTransport() = %v; want %v unchanged when disabled", got, next)
+	}
+}
+
+func TestWrapWithDebugTransportWrapsWhenEnabled(t *testing.T) {
+	os.Setenv(debugHTTPEnvVar, "true")
+	defer os.Unsetenv(debugHTTPEnvVar)
+
+	next := http.DefaultTransport
+	got := wrapWithDebugTransport(next)
+	if _, ok := got.(*debugTransport); !ok {
+		t.Errorf("wrapWithDebugTransport() = %T; want *debugTransport", got)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Signature keyId=\"...\",algorithm=\"rsa-sha256\",signature=\"...\"")
+	h.Set("Content-Type", "application/json")
+
+	redacted := redactHeaders(h)
+	if redacted.Get("Authorization") != "REDACTED" {
+		t.Errorf("redactHeaders() Authorization = %q; want %q", redacted.Get("Authorization"), "REDACTED")
+	}
+	if redacted.Get("Content-Type") != "application/json" {
+		t.Errorf("redactHeaders() Content-Type = %q; want unchanged", redacted.Get("Content-Type"))
+	}
+	if h.Get("Authorization") == "REDACTED" {
+		t.Errorf("redactHeaders() mutated the original header set")
+	}
+}
+
+func TestRedactJSONBody(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", "", "<empty>"},
+		{"notJSON", "not json", "<8 bytes, not JSON>"},
+		{
+			"redactsNestedSecrets",
+			`{"displayName":"vol-1","auth":{"key":"supersecret","fingerprint":"aa:bb"}}`,
+			`{"auth":{"fingerprint":"REDACTED","key":"REDACTED"},"displayName":"vol-1"}`,
+		},
+		{
+			"redactsWithinArrays",
+			`[{"password":"hunter2"},{"displayName":"vol-2"}]`,
+			`[{"password":"REDACTED"},{"displayName":"vol-2"}]`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactJSONBody([]byte(tt.body))
+			if got != tt.want {
+				t.Errorf("redactJSONBody(%q) = %q; want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrainAndRestorePreservesBody(t *testing.T) {
+	body := ioutil.NopCloser(strings.NewReader(`{"displayName":"vol-1"}`))
+	var rc io.ReadCloser = body
+
+	got, err := drainAndRestore(&rc)
+	if err != nil {
+		t.Fatalf("drainAndRestore() => %v", err)
+	}
+	if string(got) != `{"displayName":"vol-1"}` {
+		t.Fatalf("drainAndRestore() = %q; want original body", got)
+	}
+
+	remaining, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll(restored body) => %v", err)
+	}
+	if string(remaining) != `{"displayName":"vol-1"}` {
+		t.Errorf("restored body = %q; want it replayable in full", remaining)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+const (
+	// NodeResolutionProviderID resolves a Kubernetes node to its OCI
+	// instance via spec.providerID, populated by the
+	// cloud-controller-manager. It needs no extra OCI API call and is the
+	// only strategy enabled when NodeResolutionStrategies is unset.
+	NodeResolutionProviderID = "providerID"
+
+	// NodeResolutionDisplayName resolves by looking up the instance whose
+	// display name exactly matches the Kubernetes node name, for a
+	// cluster where providerID isn't populated but node names were chosen
+	// to match the instance display name at boot.
+	NodeResolutionDisplayName = "displayName"
+
+	// NodeResolutionHostnameLabel, NodeResolutionPublicIP and
+	// NodeResolutionPrivateIP resolve via the primary VNIC attached to an
+	// instance. Accepted by config parsing; see
+	// driver.resolveNodeIDByStrategy for which, if any, are currently
+	// implemented.
+	NodeResolutionHostnameLabel = "hostnameLabel"
+	NodeResolutionPublicIP      = "publicIP"
+	NodeResolutionPrivateIP     = "privateIP"
+)
+
+// DefaultNodeResolutionStrategies is used when config.yaml's
+// nodeResolutionStrategies is empty, preserving this driver's
+// long-standing providerID-only behaviour.
+var DefaultNodeResolutionStrategies = []string{NodeResolutionProviderID}
+
+// knownNodeResolutionStrategies are the NodeResolutionStrategies values
+// validateNodeResolutionStrategies accepts as spelled correctly, whether
+// or not the driver currently implements them (see
+// notImplementedNodeResolutionStrategies).
+var knownNodeResolutionStrategies = map[string]bool{
+	NodeResolutionProviderID:    true,
+	NodeResolutionDisplayName:   true,
+	NodeResolutionHostnameLabel: true,
+	NodeResolutionPublicIP:      true,
+	NodeResolutionPrivateIP:     true,
+}
+
+// notImplementedNodeResolutionStrategies are known, correctly spelled
+// strategies this build can't yet resolve with - each needs a VNIC
+// attachment sweep like FindInstanceByPrivateIP's, which this driver
+// performs only for NodeResolutionPrivateIP. Listed here, rather than only failing at
+// lookup time, so a cluster that configures one finds out at
+// "validate-config" time instead of the first time a volume fails to
+// attach.
+var notImplementedNodeResolutionStrategies = map[string]bool{
+	NodeResolutionHostnameLabel: true,
+	NodeResolutionPublicIP:      true,
+}
+
+func knownNodeResolutionStrategyNames() []string {
+	names := make([]string, 0, len(knownNodeResolutionStrategies))
+	for name := range knownNodeResolutionStrategies {
+		names = append(names, name)
+	}
+	return names
+}
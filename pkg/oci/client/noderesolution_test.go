@@ -0,0 +1,45 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "testing"
+
+func TestValidateNodeResolutionStrategies(t *testing.T) {
+	testCases := []struct {
+		name       string
+		strategies []string
+		shouldErr  bool
+	}{
+		{"unset", nil, false},
+		{"providerID", []string{NodeResolutionProviderID}, false},
+		{"displayName", []string{NodeResolutionDisplayName}, false},
+		{"providerID then displayName", []string{NodeResolutionProviderID, NodeResolutionDisplayName}, false},
+		{"hostnameLabel not implemented", []string{NodeResolutionHostnameLabel}, true},
+		{"publicIP not implemented", []string{NodeResolutionPublicIP}, true},
+		{"unknown", []string{"not-a-strategy"}, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateNodeResolutionStrategies(&Config{NodeResolutionStrategies: tt.strategies})
+			if tt.shouldErr && len(errs) == 0 {
+				t.Errorf("validateNodeResolutionStrategies(%v) => no error; expected one", tt.strategies)
+			}
+			if !tt.shouldErr && len(errs) != 0 {
+				t.Errorf("validateNodeResolutionStrategies(%v) => %v; expected no error", tt.strategies, errs)
+			}
+		})
+	}
+}
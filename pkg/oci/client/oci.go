@@ -16,8 +16,11 @@ package client
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -29,6 +32,9 @@ import (
 	"github.com/oracle/oci-go-sdk/common"
 	"github.com/oracle/oci-go-sdk/common/auth"
 	"github.com/oracle/oci-go-sdk/core"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
 )
 
 const (
@@ -36,12 +42,30 @@ const (
 	ociMaxRetries   = 120
 )
 
+// ErrVolumeAttachmentNotFound is returned by FindVolumeAttachment when a
+// volume has no attachment in the ATTACHING or ATTACHED state.
+var ErrVolumeAttachmentNotFound = errors.New("volume attachment not found")
+
 // Interface abstracts the OCI SDK and application specific convenience methods
 // for interacting with the OCI API.
 type Interface interface {
-	// FindVolumeAttachment searches for a volume attachment in either the state
-	// ATTACHING or ATTACHED and returns the first volume attachment found.
-	FindVolumeAttachment(volumeID string) (core.VolumeAttachment, error)
+	// FindVolumeAttachment searches for volumeID's attachment, in either the
+	// ATTACHING or ATTACHED state, to the instance identified by instanceID.
+	// A shareable volume (see flexvolume.OptionAttachmentAccessMode) can have
+	// a concurrent attachment to more than one instance; instanceID is what
+	// picks the right one out of those rather than returning whichever the
+	// API happens to list first.
+	FindVolumeAttachment(volumeID, instanceID string) (core.VolumeAttachment, error)
+
+	// ListInstanceVolumeAttachments lists every ATTACHING/ATTACHED volume
+	// attachment to instanceID within compartmentID, for the orphaned-
+	// attachment reconciler (see cmd/oci's "reconcile" command) to cross-
+	// check against Kubernetes state. Unlike FindVolumeAttachment, which
+	// derives a single volume's own compartment via GetVolume, this is
+	// scoped by the caller-supplied compartmentID, since there's no single
+	// volume to look up a compartment from when listing everything an
+	// instance has attached.
+	ListInstanceVolumeAttachments(compartmentID, instanceID string) ([]core.VolumeAttachment, error)
 
 	// WaitForVolumeAttached polls waiting for a OCI block volume to be in the
 	// ATTACHED state.
@@ -50,9 +74,28 @@ type Interface interface {
 	// GetInstance retrieves the oci.Instance for a given OCID.
 	GetInstance(id string) (*core.Instance, error)
 
+	// FindInstanceByDisplayName looks up the instance whose display name
+	// exactly matches displayName, for the NodeResolutionDisplayName node
+	// resolution strategy. Returns (nil, nil) if no instance matches, and
+	// an error if more than one does - an ambiguous match is a config
+	// problem the caller should surface, not silently resolve by picking
+	// one.
+	FindInstanceByDisplayName(displayName string) (*core.Instance, error)
+
+	// FindInstanceByPrivateIP looks up the instance whose primary VNIC has
+	// privateIP, for the NodeResolutionPrivateIP node resolution strategy.
+	// Returns (nil, nil) if no instance matches. Unlike
+	// FindInstanceByDisplayName this is not a single filtered list call:
+	// the OCI API has no "find instance by VNIC IP" endpoint, so this
+	// pages through every VNIC attachment in the compartment and fetches
+	// each VNIC to check its IP, which is the VCN/subnet scan
+	// GetInstance's doc comment says this driver otherwise avoids. It
+	// exists only to back this one explicitly opted-in strategy.
+	FindInstanceByPrivateIP(privateIP string) (*core.Instance, error)
+
 	// AttachVolume attaches a block storage volume to the specified instance.
 	// See https://docs.us-phoenix-1.oraclecloud.com/api/#/en/iaas/20160918/VolumeAttachment/AttachVolume
-	AttachVolume(instanceID, volumeID string) (core.VolumeAttachment, int, error)
+	AttachVolume(instanceID, volumeID string, encryptInTransit, readOnly, shareable bool) (core.VolumeAttachment, int, error)
 
 	// DetachVolume detaches a storage volume from the specified instance.
 	// See: https://docs.us-phoenix-1.oraclecloud.com/api/#/en/iaas/20160918/Volume/DetachVolume
@@ -64,23 +107,74 @@ type Interface interface {
 
 	// GetConfig returns the Config associated with the OCI API client.
 	GetConfig() *Config
+
+	// CheckAPIReachable verifies the OCI Compute API is reachable,
+	// independent of whether the configured identity is authorized for
+	// anything. See the method doc comment for what counts as reachable.
+	CheckAPIReachable() error
+
+	// CreateVolume creates a new block volume in the given Availability
+	// Domain and compartment. availabilityDomain is taken as-is: deriving
+	// it from a pending pod's scheduled node or a storage class's allowed
+	// topologies is the caller's job, since this client has no visibility
+	// into pods, nodes, or storage classes.
+	CreateVolume(availabilityDomain, compartmentID, displayName string) (core.Volume, error)
+
+	// DeleteVolume deletes the block volume with the given OCID.
+	DeleteVolume(volumeID string) error
+
+	// WaitForVolumeAvailable polls waiting for a OCI block volume to be in the
+	// AVAILABLE state.
+	WaitForVolumeAvailable(volumeID string) (core.Volume, error)
+
+	// CheckPermissions exercises the OCI API operations this driver needs
+	// and reports which the configured identity is missing IAM policy for.
+	// See permcheck.go.
+	CheckPermissions() []PermissionCheck
 }
 
 // client extends a barmetal.Client.
 type client struct {
-	compute *core.ComputeClient
-	network *core.VirtualNetworkClient
-	config  *Config
-	ctx     context.Context
-	timeout time.Duration
+	compute      *core.ComputeClient
+	network      *core.VirtualNetworkClient
+	blockstorage *core.BlockstorageClient
+	config       *Config
+	ctx          context.Context
+	timeout      time.Duration
+
+	// pollInterval and pollTimeout drive waitStrategy below. They are
+	// fields rather than constants so that tests can shrink them to
+	// exercise timeout behaviour without waiting on the real clock.
+	pollInterval time.Duration
+	pollTimeout  time.Duration
+
+	// waitStrategy paces the WaitForVolume* polling loops below, per
+	// config.AttachmentWaitStrategy.
+	waitStrategy waitStrategy
 }
 
-// New initialises a OCI API client from a config file.
+// New initialises a OCI API client from a config file, using its top-level
+// auth block.
 func New(configPath string) (Interface, error) {
+	return NewForServiceAccount(configPath, "")
+}
+
+// NewForServiceAccount initialises a OCI API client from a config file,
+// using the AuthProfiles entry named by serviceAccountName in place of the
+// config's top-level auth block if one is configured for it - so pods in
+// different namespaces, identified by their service account, can attach
+// volumes with differently-scoped credentials. An empty serviceAccountName,
+// or one with no matching profile, falls back to the top-level auth block.
+func NewForServiceAccount(configPath, serviceAccountName string) (Interface, error) {
 	config, err := ConfigFromFile(configPath)
 	if err != nil {
 		return nil, err
 	}
+	if profile, ok := config.AuthProfiles[serviceAccountName]; serviceAccountName != "" && ok {
+		loglevel.Debugf("using OCI auth profile for service account %q", serviceAccountName)
+		config.Auth = profile
+	}
+
 	var configProvider common.ConfigurationProvider
 	if config.UseInstancePrincipals {
 		cp, err := auth.InstancePrincipalConfigurationProvider()
@@ -88,6 +182,17 @@ func New(configPath string) (Interface, error) {
 			return nil, err
 		}
 		configProvider = cp
+	} else if config.Auth.SecurityTokenFile != "" {
+		cp, err := newSessionTokenConfigurationProvider(
+			config.Auth.SecurityTokenFile,
+			config.Auth.SecurityTokenPrivateKeyFile,
+			config.Auth.Region,
+			&config.Auth.Passphrase,
+		)
+		if err != nil {
+			return nil, err
+		}
+		configProvider = cp
 	} else {
 		configProvider = common.NewRawConfigurationProvider(
 			config.Auth.TenancyOCID,
@@ -102,7 +207,8 @@ func New(configPath string) (Interface, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = configureCustomTransport(&computeClient.BaseClient)
+	applyEndpointOverride(&computeClient.BaseClient, config.Endpoints.Compute)
+	err = configureCustomTransport(&computeClient.BaseClient, config)
 	if err != nil {
 		return nil, err
 	}
@@ -111,66 +217,123 @@ func New(configPath string) (Interface, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = configureCustomTransport(&virtualNetworkClient.BaseClient)
+	applyEndpointOverride(&virtualNetworkClient.BaseClient, config.Endpoints.VirtualNetwork)
+	err = configureCustomTransport(&virtualNetworkClient.BaseClient, config)
+	if err != nil {
+		return nil, err
+	}
+
+	blockstorageClient, err := core.NewBlockstorageClientWithConfigurationProvider(configProvider)
+	if err != nil {
+		return nil, err
+	}
+	applyEndpointOverride(&blockstorageClient.BaseClient, config.Endpoints.Blockstorage)
+	err = configureCustomTransport(&blockstorageClient.BaseClient, config)
+	if err != nil {
+		return nil, err
+	}
+
+	pollInterval := ociWaitDuration
+	if s := config.AttachmentPolling.IntervalSeconds; s > 0 {
+		pollInterval = time.Duration(s) * time.Second
+	}
+	pollTimeout := ociWaitDuration * ociMaxRetries
+	if s := config.AttachmentPolling.TimeoutSeconds; s > 0 {
+		pollTimeout = time.Duration(s) * time.Second
+	}
+	strategy, err := newWaitStrategy(config.AttachmentWaitStrategy, pollInterval, pollTimeout)
 	if err != nil {
 		return nil, err
 	}
 
 	return &client{
-		compute: &computeClient,
-		network: &virtualNetworkClient,
-		config:  config,
-		ctx:     context.Background(),
-		timeout: time.Minute}, nil
+		compute:      &computeClient,
+		network:      &virtualNetworkClient,
+		blockstorage: &blockstorageClient,
+		config:       config,
+		ctx:          context.Background(),
+		timeout:      config.Timeouts.request(),
+		pollInterval: pollInterval,
+		pollTimeout:  pollTimeout,
+		waitStrategy: strategy}, nil
 }
 
 // WaitForVolumeAttached polls waiting for a OCI block volume to be in the
 // ATTACHED state.
 func (c *client) WaitForVolumeAttached(volumeAttachmentID string) (core.VolumeAttachment, error) {
-	// TODO: Replace with "k8s.io/apimachinery/pkg/util/wait".
 	request := core.GetVolumeAttachmentRequest{
 		VolumeAttachmentId: &volumeAttachmentID,
 	}
-	for i := 0; i < ociMaxRetries; i++ {
-		r, err := func() (core.GetVolumeAttachmentResponse, error) {
+	var attachment core.VolumeAttachment
+	err := c.waitStrategy.Wait(func() (bool, error) {
+		var r core.GetVolumeAttachmentResponse
+		err := withRetry(func() error {
+			var err error
 			ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
 			defer cancel()
-			return c.compute.GetVolumeAttachment(ctx, request)
-		}()
+			r, err = c.compute.GetVolumeAttachment(ctx, request)
+			return err
+		})
 		if err != nil {
-			return nil, err
+			return false, err
 		}
-		attachment := r.VolumeAttachment
-		state := attachment.GetLifecycleState()
-		switch state {
+		attachment = r.VolumeAttachment
+		switch state := attachment.GetLifecycleState(); state {
 		case core.VolumeAttachmentLifecycleStateAttaching:
-			time.Sleep(ociWaitDuration)
+			return false, nil
 		case core.VolumeAttachmentLifecycleStateAttached:
-			return attachment, nil
+			return true, nil
 		default:
-			return nil, fmt.Errorf("unexpected state %q while wating for volume attach", state)
+			return false, fmt.Errorf("unexpected state %q while wating for volume attach", state)
 		}
+	})
+	if err != nil {
+		if err == wait.ErrWaitTimeout {
+			return nil, fmt.Errorf("timed out waiting for volume to attach")
+		}
+		return nil, err
 	}
-	return nil, fmt.Errorf("maximum number of retries (%d) exceeed attaching volume", ociMaxRetries)
+	return attachment, nil
 }
 
-// FindVolumeAttachment searches for a volume attachment in either the state of
-// ATTACHING or ATTACHED and returns the first volume attachment found.
-func (c *client) FindVolumeAttachment(volumeID string) (core.VolumeAttachment, error) {
+// FindVolumeAttachment searches for volumeID's attachment, in either the
+// ATTACHING or ATTACHED state. If instanceID is non-empty, only an
+// attachment to that instance matches; otherwise the first matching
+// attachment to any instance is returned.
+//
+// A volumeID that doesn't exist at all (as opposed to existing but not
+// being attached) also reports ErrVolumeAttachmentNotFound rather than
+// erroring - see volumeCompartment - since a deleted volume trivially has
+// no attachment, and callers (DetachNow, IsAttached) rely on that to stay
+// idempotent/accurate against a volume that's already gone.
+func (c *client) FindVolumeAttachment(volumeID, instanceID string) (core.VolumeAttachment, error) {
+	compartmentID, err := c.volumeCompartment(volumeID)
+	if err == errVolumeNotFound {
+		return nil, ErrVolumeAttachmentNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
 	var page *string
 
 	for {
 		request := core.ListVolumeAttachmentsRequest{
-			CompartmentId: common.String(c.config.Auth.CompartmentOCID),
+			CompartmentId: &compartmentID,
 			Page:          page,
 			VolumeId:      &volumeID,
 		}
+		if instanceID != "" {
+			request.InstanceId = &instanceID
+		}
 
-		r, err := func() (core.ListVolumeAttachmentsResponse, error) {
+		var r core.ListVolumeAttachmentsResponse
+		err := withRetry(func() error {
+			var err error
 			ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
 			defer cancel()
-			return c.compute.ListVolumeAttachments(ctx, request)
-		}()
+			r, err = c.compute.ListVolumeAttachments(ctx, request)
+			return err
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -188,27 +351,101 @@ func (c *client) FindVolumeAttachment(volumeID string) (core.VolumeAttachment, e
 		}
 	}
 
-	return nil, fmt.Errorf("failed to find volume attachment for %q", volumeID)
+	return nil, ErrVolumeAttachmentNotFound
 }
 
-func (c *client) getVCNCompartment() (*string, error) {
-	ctx, cancel := context.WithTimeout(c.ctx, time.Minute)
-	defer cancel()
+// ListInstanceVolumeAttachments lists every ATTACHING/ATTACHED volume
+// attachment to instanceID within compartmentID. See the Interface doc
+// comment for why this takes compartmentID rather than deriving it the way
+// FindVolumeAttachment does.
+func (c *client) ListInstanceVolumeAttachments(compartmentID, instanceID string) ([]core.VolumeAttachment, error) {
+	var attachments []core.VolumeAttachment
+	var page *string
 
-	vcn, err := c.network.GetVcn(ctx, core.GetVcnRequest{VcnId: &c.config.Auth.VcnOCID})
-	if err != nil {
-		return nil, err
+	for {
+		request := core.ListVolumeAttachmentsRequest{
+			CompartmentId: &compartmentID,
+			InstanceId:    &instanceID,
+			Page:          page,
+		}
+
+		var r core.ListVolumeAttachmentsResponse
+		err := withRetry(func() error {
+			var err error
+			ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+			defer cancel()
+			r, err = c.compute.ListVolumeAttachments(ctx, request)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, attachment := range r.Items {
+			state := attachment.GetLifecycleState()
+			if state == core.VolumeAttachmentLifecycleStateAttaching ||
+				state == core.VolumeAttachmentLifecycleStateAttached {
+				attachments = append(attachments, attachment)
+			}
+		}
+
+		if page = r.OpcNextPage; r.OpcNextPage == nil {
+			break
+		}
 	}
 
-	return vcn.CompartmentId, nil
+	return attachments, nil
 }
 
-// GetInstance retrieves the corresponding core.Instance by OCID.
-func (c *client) GetInstance(id string) (*core.Instance, error) {
-	resp, err := c.compute.GetInstance(c.ctx, core.GetInstanceRequest{
-		InstanceId: &id,
+// errVolumeNotFound is volumeCompartment's internal signal that GetVolume
+// came back 404, as opposed to any other error resolving the compartment.
+// Not exported - FindVolumeAttachment is the only caller that needs to
+// distinguish this case, and it translates it to ErrVolumeAttachmentNotFound
+// immediately.
+var errVolumeNotFound = errors.New("volume not found")
+
+// volumeCompartment resolves volumeID's own compartment via GetVolume,
+// rather than assuming it matches config.Auth.CompartmentOCID. A PV's
+// backing volume can be provisioned in a different compartment than this
+// driver's configured one - ListVolumeAttachments is scoped by compartment,
+// so using the wrong one would silently miss the attachment rather than
+// error.
+func (c *client) volumeCompartment(volumeID string) (string, error) {
+	var r core.GetVolumeResponse
+	err := withRetry(func() error {
+		var err error
+		ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+		defer cancel()
+		r, err = c.blockstorage.GetVolume(ctx, core.GetVolumeRequest{VolumeId: &volumeID})
+		return err
 	})
+	if err != nil {
+		if svcErr, ok := common.IsServiceError(err); ok && svcErr.GetHTTPStatusCode() == http.StatusNotFound {
+			return "", errVolumeNotFound
+		}
+		return "", err
+	}
+	if r.CompartmentId == nil {
+		return "", fmt.Errorf("volume %q has no compartment", volumeID)
+	}
+	return *r.CompartmentId, nil
+}
 
+// GetInstance retrieves the corresponding core.Instance by OCID. The happy
+// path to get here is driver.lookupNodeID() resolving the Kubernetes node's
+// spec.providerID, so this is the only OCI call in the Attach happy path -
+// there is no VCN/subnet lookup to resolve an instance from a node name.
+func (c *client) GetInstance(id string) (*core.Instance, error) {
+	var resp core.GetInstanceResponse
+	err := withRetry(func() error {
+		var err error
+		ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+		defer cancel()
+		resp, err = c.compute.GetInstance(ctx, core.GetInstanceRequest{
+			InstanceId: &id,
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -216,19 +453,133 @@ func (c *client) GetInstance(id string) (*core.Instance, error) {
 	return &resp.Instance, nil
 }
 
+// FindInstanceByDisplayName looks up the instance whose display name
+// exactly matches displayName, for the NodeResolutionDisplayName node
+// resolution strategy (see driver.resolveNodeIDByStrategy). Unlike
+// GetInstance, this only ever lists instances already in the RUNNING
+// state, a stopped instance that still happens to share a display name
+// with a live node isn't a match worth returning.
+func (c *client) FindInstanceByDisplayName(displayName string) (*core.Instance, error) {
+	var resp core.ListInstancesResponse
+	err := withRetry(func() error {
+		var err error
+		ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+		defer cancel()
+		resp, err = c.compute.ListInstances(ctx, core.ListInstancesRequest{
+			CompartmentId:  common.String(c.config.Auth.CompartmentOCID),
+			DisplayName:    common.String(displayName),
+			LifecycleState: core.InstanceLifecycleStateRunning,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(resp.Items) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &resp.Items[0], nil
+	default:
+		return nil, fmt.Errorf("display name %q matches %d running instances in compartment %q; want exactly one", displayName, len(resp.Items), c.config.Auth.CompartmentOCID)
+	}
+}
+
+// FindInstanceByPrivateIP pages through every attached VNIC in the
+// compartment looking for one whose private IP matches privateIP, for the
+// NodeResolutionPrivateIP node resolution strategy (see
+// driver.resolveNodeIDByStrategy). See the Interface doc comment on this
+// method for why it's a sweep rather than a filtered lookup.
+func (c *client) FindInstanceByPrivateIP(privateIP string) (*core.Instance, error) {
+	var page *string
+
+	for {
+		var attachments core.ListVnicAttachmentsResponse
+		err := withRetry(func() error {
+			var err error
+			ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+			defer cancel()
+			attachments, err = c.compute.ListVnicAttachments(ctx, core.ListVnicAttachmentsRequest{
+				CompartmentId: common.String(c.config.Auth.CompartmentOCID),
+				Page:          page,
+			})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, attachment := range attachments.Items {
+			if attachment.LifecycleState != core.VnicAttachmentLifecycleStateAttached || attachment.VnicId == nil {
+				continue
+			}
+
+			var vnicResp core.GetVnicResponse
+			err := withRetry(func() error {
+				var err error
+				ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+				defer cancel()
+				vnicResp, err = c.network.GetVnic(ctx, core.GetVnicRequest{VnicId: attachment.VnicId})
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if vnicResp.PrivateIp != nil && *vnicResp.PrivateIp == privateIP {
+				return c.GetInstance(*attachment.InstanceId)
+			}
+		}
+
+		if page = attachments.OpcNextPage; attachments.OpcNextPage == nil {
+			break
+		}
+	}
+
+	return nil, nil
+}
+
 // AttachVolume attaches a block storage volume to the specified instance.
-func (c *client) AttachVolume(instanceID, volumeID string) (core.VolumeAttachment, int, error) {
+// encryptInTransit requests in-transit encryption of the resulting iSCSI
+// attachment. readOnly requests a read-only attachment, so that the volume
+// can't be written to even if the node later remounts it rw. shareable
+// requests a shareable attachment, allowing the volume to be attached to
+// more than one instance at once (see flexvolume.OptionAttachmentAccessMode).
+//
+// The vendored OCI SDK predates the AttachIScsiVolumeDetails fields that
+// carry these (IsPvEncryptionInTransitEnabled, IsReadOnly and IsShareable
+// upstream), so there is currently nowhere on the request to put them; all
+// three are accepted and logged but not yet enforced at the OCI API level
+// until the SDK is updated. In particular, a shareable attachment request
+// is NOT rejected here even though OCI itself would refuse a second,
+// concurrent attachment of a non-shareable volume - see FindVolumeAttachment
+// for the instance-scoped lookup this otherwise relies on.
+func (c *client) AttachVolume(instanceID, volumeID string, encryptInTransit, readOnly, shareable bool) (core.VolumeAttachment, int, error) {
+	if encryptInTransit {
+		loglevel.Warnf("AttachVolume: in-transit encryption was requested for volume %s, but the vendored OCI SDK does not yet support it; attaching without it", volumeID)
+	}
+	if readOnly {
+		loglevel.Warnf("AttachVolume: a read-only attachment was requested for volume %s, but the vendored OCI SDK does not yet support it; attaching read-write", volumeID)
+	}
+	if shareable {
+		loglevel.Warnf("AttachVolume: a shareable attachment was requested for volume %s, but the vendored OCI SDK does not yet support it; attaching exclusively", volumeID)
+	}
+
 	request := core.AttachVolumeRequest{
 		AttachVolumeDetails: core.AttachIScsiVolumeDetails{
 			InstanceId: &instanceID,
 			VolumeId:   &volumeID,
 		},
 	}
-	r, err := func() (core.AttachVolumeResponse, error) {
+	var r core.AttachVolumeResponse
+	err := withRetry(func() error {
+		var err error
 		ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
 		defer cancel()
-		return c.compute.AttachVolume(ctx, request)
-	}()
+		r, err = c.compute.AttachVolume(ctx, request)
+		return err
+	})
 	if err != nil {
 		return nil, r.RawResponse.StatusCode, err
 	}
@@ -240,12 +591,12 @@ func (c *client) DetachVolume(volumeAttachmentID string) error {
 	request := core.DetachVolumeRequest{
 		VolumeAttachmentId: &volumeAttachmentID,
 	}
-	err := func() error {
+	err := withRetry(func() error {
 		ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
 		defer cancel()
 		_, err := c.compute.DetachVolume(ctx, request)
 		return err
-	}()
+	})
 	if err != nil {
 		return err
 	}
@@ -255,31 +606,34 @@ func (c *client) DetachVolume(volumeAttachmentID string) error {
 // WaitForVolumeDetached polls waiting for a OCI block volume to be in the
 // DETACHED state.
 func (c *client) WaitForVolumeDetached(volumeAttachmentID string) error {
-	// TODO: Replace with "k8s.io/apimachinery/pkg/util/wait".
 	request := core.GetVolumeAttachmentRequest{
 		VolumeAttachmentId: &volumeAttachmentID,
 	}
-	for i := 0; i < ociMaxRetries; i++ {
-		r, err := func() (core.GetVolumeAttachmentResponse, error) {
+	err := c.waitStrategy.Wait(func() (bool, error) {
+		var r core.GetVolumeAttachmentResponse
+		err := withRetry(func() error {
+			var err error
 			ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
 			defer cancel()
-			return c.compute.GetVolumeAttachment(ctx, request)
-		}()
-		if err != nil {
+			r, err = c.compute.GetVolumeAttachment(ctx, request)
 			return err
+		})
+		if err != nil {
+			return false, err
 		}
-		attachment := r.VolumeAttachment
-		state := attachment.GetLifecycleState()
-		switch state {
+		switch state := r.VolumeAttachment.GetLifecycleState(); state {
 		case core.VolumeAttachmentLifecycleStateDetaching:
-			time.Sleep(ociWaitDuration)
+			return false, nil
 		case core.VolumeAttachmentLifecycleStateDetached:
-			return nil
+			return true, nil
 		default:
-			return fmt.Errorf("unexpected state %q while wating for volume detach", state)
+			return false, fmt.Errorf("unexpected state %q while wating for volume detach", state)
 		}
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for volume to detach")
 	}
-	return fmt.Errorf("maximum number of retries (%d) exceeed detaching volume", ociMaxRetries)
+	return err
 }
 
 // GetConfig returns the Config associated with the OCI API client.
@@ -287,9 +641,131 @@ func (c *client) GetConfig() *Config {
 	return c.config
 }
 
+// createVolumeRetryToken derives a stable OpcRetryToken for a CreateVolume
+// call from its own arguments, computed once per call rather than once per
+// retry attempt. Without it, withRetry retrying a CreateVolume whose
+// response was merely lost to a timeout - the request itself having
+// already succeeded on OCI's side - creates a second, distinct, orphaned
+// volume; OCI de-duplicates retried requests that carry the same token
+// instead.
+func createVolumeRetryToken(availabilityDomain, compartmentID, displayName string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s", availabilityDomain, compartmentID, displayName)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CreateVolume creates a new block volume in the given Availability Domain
+// and compartment.
+//
+// This client has no notion of a Kubernetes namespace - that's a concept
+// the caller (the separate oci-volume-provisioner project, which imports
+// this package) owns. Namespace-scoped quota enforcement belongs there,
+// ahead of the call to CreateVolume, not in this client.
+//
+// It also can't assign a backup policy to the volume it creates: the
+// vendored oci-go-sdk in this tree predates the backup policy assignment
+// APIs (there's no CreateVolumeBackupPolicy or policy association request
+// anywhere in vendor/github.com/oracle/oci-go-sdk/core). Storage-class-driven
+// backup policy assignment needs both a newer SDK vendored here and the
+// provisioner-side plumbing to read the storage class parameter.
+func (c *client) CreateVolume(availabilityDomain, compartmentID, displayName string) (core.Volume, error) {
+	retryToken := createVolumeRetryToken(availabilityDomain, compartmentID, displayName)
+	request := core.CreateVolumeRequest{
+		CreateVolumeDetails: core.CreateVolumeDetails{
+			AvailabilityDomain: &availabilityDomain,
+			CompartmentId:      &compartmentID,
+			DisplayName:        &displayName,
+		},
+		OpcRetryToken: &retryToken,
+	}
+	var r core.CreateVolumeResponse
+	err := withRetry(func() error {
+		var err error
+		ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+		defer cancel()
+		r, err = c.blockstorage.CreateVolume(ctx, request)
+		return err
+	})
+	if err != nil {
+		return core.Volume{}, err
+	}
+	return r.Volume, nil
+}
+
+// DeleteVolume deletes the block volume with the given OCID, refusing if the
+// volume still has an ATTACHING or ATTACHED attachment.
+//
+// Retain/Delete reclaim policy semantics and a grace window with CLI undo
+// are the provisioner's job (see the CreateVolume comment above) - this
+// client only owns the safety check that stops an attached volume from
+// being deleted out from under a running pod. It also can't check for a
+// "do-not-delete" tag: the vendored oci-go-sdk's core.Volume predates
+// freeform/defined tags, so there's nowhere to put one.
+func (c *client) DeleteVolume(volumeID string) error {
+	attachment, err := c.FindVolumeAttachment(volumeID, "")
+	if err == nil {
+		return fmt.Errorf("refusing to delete volume %q: still attached (attachment %q)", volumeID, *attachment.GetId())
+	}
+	if err != ErrVolumeAttachmentNotFound {
+		return err
+	}
+
+	return withRetry(func() error {
+		ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+		defer cancel()
+		_, err := c.blockstorage.DeleteVolume(ctx, core.DeleteVolumeRequest{VolumeId: &volumeID})
+		return err
+	})
+}
+
+// WaitForVolumeAvailable polls waiting for a OCI block volume to be in the
+// AVAILABLE state.
+func (c *client) WaitForVolumeAvailable(volumeID string) (core.Volume, error) {
+	request := core.GetVolumeRequest{VolumeId: &volumeID}
+	var volume core.Volume
+	err := c.waitStrategy.Wait(func() (bool, error) {
+		var r core.GetVolumeResponse
+		err := withRetry(func() error {
+			var err error
+			ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+			defer cancel()
+			r, err = c.blockstorage.GetVolume(ctx, request)
+			return err
+		})
+		if err != nil {
+			return false, err
+		}
+		volume = r.Volume
+		switch volume.LifecycleState {
+		case core.VolumeLifecycleStateProvisioning:
+			return false, nil
+		case core.VolumeLifecycleStateAvailable:
+			return true, nil
+		default:
+			return false, fmt.Errorf("unexpected state %q while waiting for volume to become available", volume.LifecycleState)
+		}
+	})
+	if err != nil {
+		if err == wait.ErrWaitTimeout {
+			return core.Volume{}, fmt.Errorf("timed out waiting for volume to become available")
+		}
+		return core.Volume{}, err
+	}
+	return volume, nil
+}
+
+// applyEndpointOverride replaces baseClient's host with endpoint, if set.
+// This is needed on realms such as Dedicated Region Cloud@Customer and
+// Cloud@Customer, where the SDK's region-derived endpoint does not apply.
+func applyEndpointOverride(baseClient *common.BaseClient, endpoint string) {
+	if endpoint != "" {
+		baseClient.Host = endpoint
+	}
+}
+
 // configureCustomTransport customises the base client's transport to use
 // the environment variable specified proxy and/or certificate.
-func configureCustomTransport(baseClient *common.BaseClient) error {
+func configureCustomTransport(baseClient *common.BaseClient, config *Config) error {
 
 	httpClient := baseClient.HTTPClient.(*http.Client)
 
@@ -297,13 +773,13 @@ func configureCustomTransport(baseClient *common.BaseClient) error {
 	if httpClient.Transport == nil {
 		transport = &http.Transport{
 			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
+				Timeout:   config.Timeouts.dial(),
 				KeepAlive: 30 * time.Second,
 				DualStack: true,
 			}).DialContext,
 			MaxIdleConns:          100,
 			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
+			TLSHandshakeTimeout:   config.Timeouts.tlsHandshake(),
 			ExpectContinueTimeout: 1 * time.Second,
 		}
 	} else {
@@ -334,6 +810,66 @@ func configureCustomTransport(baseClient *common.BaseClient) error {
 		}
 		transport.TLSClientConfig = &tls.Config{RootCAs: caCertPool}
 	}
-	httpClient.Transport = transport
+
+	clientCert, ok, err := config.ClientTLS.certificate()
+	if err != nil {
+		return err
+	}
+	if ok {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	minVersion, err := config.TLS.minVersion()
+	if err != nil {
+		return err
+	}
+	cipherSuiteIDs, err := config.TLS.cipherSuiteIDs()
+	if err != nil {
+		return err
+	}
+	if minVersion != 0 || cipherSuiteIDs != nil {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		if minVersion != 0 {
+			transport.TLSClientConfig.MinVersion = minVersion
+		}
+		if cipherSuiteIDs != nil {
+			transport.TLSClientConfig.CipherSuites = cipherSuiteIDs
+		}
+	}
+
+	if fipsModeEnabled() {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.MinVersion = tls.VersionTLS12
+		transport.TLSClientConfig.CipherSuites = fipsApprovedCipherSuites
+	}
+
+	httpClient.Transport = wrapWithThrottleTransport(wrapWithDebugTransport(wrapWithRequestIDTransport(transport)))
 	return nil
 }
+
+// fipsModeEnvVar enables FIPS-compliant TLS settings in
+// configureCustomTransport. It is intended to be used together with a
+// boringcrypto-linked binary (see "make build-fips"), which swaps Go's
+// standard crypto implementations for FIPS 140-2 validated ones; on its own
+// it only constrains the TLS parameters negotiated with the OCI API.
+const fipsModeEnvVar = "OCI_FLEXD_FIPS_MODE"
+
+// fipsApprovedCipherSuites is the set of TLS 1.2 cipher suites approved for
+// use in FIPS mode.
+var fipsApprovedCipherSuites = []uint16{
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+}
+
+func fipsModeEnabled() bool {
+	return os.Getenv(fipsModeEnvVar) == "true"
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/common"
+)
+
+// underlyingTransport unwraps baseClient's HTTP transport past the
+// throttleTransport and requestIDTransport configureCustomTransport always
+// installs, down to the *http.Transport underneath the TLS/proxy settings
+// under test here are actually set on.
+func underlyingTransport(t *testing.T, baseClient *common.BaseClient) *http.Transport {
+	t.Helper()
+	throttled, ok := baseClient.HTTPClient.(*http.Client).Transport.(*throttleTransport)
+	if !ok {
+		t.Fatalf("HTTPClient.Transport = %T; want *throttleTransport", baseClient.HTTPClient.(*http.Client).Transport)
+	}
+	wrapped, ok := throttled.next.(*requestIDTransport)
+	if !ok {
+		t.Fatalf("throttleTransport.next = %T; want *requestIDTransport", throttled.next)
+	}
+	transport, ok := wrapped.next.(*http.Transport)
+	if !ok {
+		t.Fatalf("requestIDTransport.next = %T; want *http.Transport", wrapped.next)
+	}
+	return transport
+}
+
+func TestConfigureCustomTransportFIPSMode(t *testing.T) {
+	defer os.Unsetenv(fipsModeEnvVar)
+	os.Setenv(fipsModeEnvVar, "true")
+
+	baseClient := &common.BaseClient{HTTPClient: &http.Client{}}
+	if err := configureCustomTransport(baseClient, &Config{}); err != nil {
+		t.Fatalf("configureCustomTransport() => %v, expected no error", err)
+	}
+
+	transport := underlyingTransport(t, baseClient)
+	if transport.TLSClientConfig == nil {
+		t.Fatal("expected TLSClientConfig to be set in FIPS mode")
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("expected MinVersion = TLS 1.2, got %v", transport.TLSClientConfig.MinVersion)
+	}
+	if len(transport.TLSClientConfig.CipherSuites) == 0 {
+		t.Error("expected a non-empty set of FIPS-approved cipher suites")
+	}
+}
+
+func TestConfigureCustomTransportTLSConfig(t *testing.T) {
+	config := &Config{TLS: TLSConfig{
+		MinVersion:   "1.2",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}}
+
+	baseClient := &common.BaseClient{HTTPClient: &http.Client{}}
+	if err := configureCustomTransport(baseClient, config); err != nil {
+		t.Fatalf("configureCustomTransport() => %v, expected no error", err)
+	}
+
+	transport := underlyingTransport(t, baseClient)
+	if transport.TLSClientConfig == nil {
+		t.Fatal("expected TLSClientConfig to be set")
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("expected MinVersion = TLS 1.2, got %v", transport.TLSClientConfig.MinVersion)
+	}
+	if len(transport.TLSClientConfig.CipherSuites) != 1 {
+		t.Errorf("expected 1 configured cipher suite, got %d", len(transport.TLSClientConfig.CipherSuites))
+	}
+}
+
+func TestConfigureCustomTransportNoFIPSMode(t *testing.T) {
+	os.Unsetenv(fipsModeEnvVar)
+
+	baseClient := &common.BaseClient{HTTPClient: &http.Client{}}
+	if err := configureCustomTransport(baseClient, &Config{}); err != nil {
+		t.Fatalf("configureCustomTransport() => %v, expected no error", err)
+	}
+
+	transport := underlyingTransport(t, baseClient)
+	if transport.TLSClientConfig != nil {
+		t.Errorf("expected no TLSClientConfig outside of FIPS mode, got %+v", transport.TLSClientConfig)
+	}
+}
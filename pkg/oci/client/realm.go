@@ -0,0 +1,58 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+// Realm profiles known to this driver. RealmOC1 is OCI's commercial realm,
+// the default assumed both here and by the wider OCI Go SDK. The others
+// identify government, dedicated region and Cloud@Customer deployments
+// whose endpoints generally cannot be derived from the region alone and so
+// require Endpoints to be set explicitly.
+const (
+	RealmOC1  = "oc1"
+	RealmOC2  = "oc2"
+	RealmOC3  = "oc3"
+	RealmOC4  = "oc4"
+	RealmOC8  = "oc8"
+	RealmDRCC = "drcc"
+	RealmC3   = "c3"
+)
+
+var knownRealmProfiles = map[string]bool{
+	RealmOC1:  true,
+	RealmOC2:  true,
+	RealmOC3:  true,
+	RealmOC4:  true,
+	RealmOC8:  true,
+	RealmDRCC: true,
+	RealmC3:   true,
+}
+
+func knownRealmProfileNames() []string {
+	names := make([]string, 0, len(knownRealmProfiles))
+	for name := range knownRealmProfiles {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Endpoints holds explicit per-service endpoint overrides. The default OCI
+// SDK clients derive their endpoint from the region, which does not hold on
+// Dedicated Region Cloud@Customer (DRCC) or Cloud@Customer (C3)
+// deployments, where these should be set explicitly instead.
+type Endpoints struct {
+	Compute        string `yaml:"compute"`
+	VirtualNetwork string `yaml:"virtualNetwork"`
+	Blockstorage   string `yaml:"blockstorage"`
+}
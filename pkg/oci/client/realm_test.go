@@ -0,0 +1,71 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/common"
+)
+
+func TestValidateRealmProfile(t *testing.T) {
+	testCases := []struct {
+		realmProfile string
+		shouldErr    bool
+	}{
+		{"", false},
+		{RealmOC1, false},
+		{RealmOC2, false},
+		{RealmOC3, false},
+		{RealmOC4, false},
+		{RealmOC8, false},
+		{RealmDRCC, false},
+		{RealmC3, false},
+		{"not-a-realm", true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.realmProfile, func(t *testing.T) {
+			errs := validateRealmProfile(&Config{RealmProfile: tt.realmProfile})
+			if tt.shouldErr && len(errs) == 0 {
+				t.Errorf("validateRealmProfile(%q) => no error; expected one", tt.realmProfile)
+			}
+			if !tt.shouldErr && len(errs) != 0 {
+				t.Errorf("validateRealmProfile(%q) => %v; expected no error", tt.realmProfile, errs)
+			}
+		})
+	}
+}
+
+func TestApplyEndpointOverride(t *testing.T) {
+	testCases := []struct {
+		name     string
+		endpoint string
+		want     string
+	}{
+		{"no override", "", "default-host"},
+		{"override", "drcc.example.com", "drcc.example.com"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			baseClient := &common.BaseClient{Host: "default-host"}
+			applyEndpointOverride(baseClient, tt.endpoint)
+			if baseClient.Host != tt.want {
+				t.Errorf("applyEndpointOverride(%q) => Host %q; want %q", tt.endpoint, baseClient.Host, tt.want)
+			}
+		})
+	}
+}
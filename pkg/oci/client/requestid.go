@@ -0,0 +1,56 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+// opcRequestIDHeader is the header the OCI API returns on every response
+// (including error responses) identifying that call for Oracle support.
+// Logging it for every call, rather than only under OCI_FLEXD_DEBUG_HTTP,
+// means there's an exact request ID to hand support when an attach/detach
+// misbehaves, without having to reproduce the failure with HTTP body
+// logging turned on first. It's logged at Debug level, so it reaches the
+// log file only while the log level is debug (cmd/oci's default).
+const opcRequestIDHeader = "Opc-Request-Id"
+
+// requestIDTransport wraps another http.RoundTripper, logging the
+// OCI-assigned request ID for every call it sees. It sits innermost in the
+// transport chain (see configureCustomTransport) so it logs once per actual
+// wire request, including any throttleTransport retries of the same
+// logical call.
+type requestIDTransport struct {
+	next http.RoundTripper
+}
+
+func wrapWithRequestIDTransport(next http.RoundTripper) http.RoundTripper {
+	return &requestIDTransport{next: next}
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		loglevel.Debugf("oci api request: %s %s: %v", req.Method, req.URL, err)
+		return resp, err
+	}
+
+	if requestID := resp.Header.Get(opcRequestIDHeader); requestID != "" {
+		loglevel.Debugf("oci api request: %s %s status=%s opc-request-id=%s", req.Method, req.URL, resp.Status, requestID)
+	}
+	return resp, err
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestRequestIDTransportPassesThroughSuccess(t *testing.T) {
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{opcRequestIDHeader: []string{"req-1"}},
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	})
+
+	transport := wrapWithRequestIDTransport(next)
+	req, _ := http.NewRequest("GET", "http://example.invalid", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() => %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("RoundTrip() status = %d; want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestRequestIDTransportPassesThroughError(t *testing.T) {
+	wantErr := errors.New("connection refused")
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, wantErr
+	})
+
+	transport := wrapWithRequestIDTransport(next)
+	req, _ := http.NewRequest("GET", "http://example.invalid", nil)
+	_, err := transport.RoundTrip(req)
+	if err != wantErr {
+		t.Errorf("RoundTrip() err = %v; want %v", err, wantErr)
+	}
+}
+
+func TestRequestIDTransportHandlesMissingHeader(t *testing.T) {
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	})
+
+	transport := wrapWithRequestIDTransport(next)
+	req, _ := http.NewRequest("GET", "http://example.invalid", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() => %v", err)
+	}
+}
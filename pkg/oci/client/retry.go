@@ -0,0 +1,91 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+const (
+	apiRetryInitialInterval = 500 * time.Millisecond
+	apiRetryFactor          = 2.0
+	apiRetryJitter          = 0.3
+	apiRetrySteps           = 5
+)
+
+// apiRetryBackoff is the exponential-backoff-with-jitter policy withRetry
+// applies to a single OCI API call: up to apiRetrySteps attempts, starting
+// at apiRetryInitialInterval and multiplying by apiRetryFactor each time,
+// with up to apiRetryJitter extra added to each wait so that every callout
+// on a node doesn't retry in lockstep after a shared network blip.
+func apiRetryBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: apiRetryInitialInterval,
+		Factor:   apiRetryFactor,
+		Jitter:   apiRetryJitter,
+		Steps:    apiRetrySteps,
+	}
+}
+
+// withRetry calls fn, retrying it under apiRetryBackoff as long as it keeps
+// failing with isRetryableAPIError. Without this, a single transient 5xx or
+// network-level error from any OCI API call used to fail the whole kubelet
+// callout outright, even though the kubelet would just invoke the same
+// callout again moments later anyway.
+//
+// wait.ExponentialBackoff discards the condition's error once its step
+// budget runs out, returning wait.ErrWaitTimeout instead, so the last
+// underlying error is captured separately here and substituted back in.
+func withRetry(fn func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(apiRetryBackoff(), func() (bool, error) {
+		lastErr = fn()
+		if lastErr == nil {
+			return true, nil
+		}
+		if !isRetryableAPIError(lastErr) {
+			return false, lastErr
+		}
+		loglevel.Warnf("retrying after transient OCI API error: %v", lastErr)
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return lastErr
+	}
+	return err
+}
+
+// isRetryableAPIError reports whether err is transient and worth retrying:
+// a 5xx or 429 (TooManyRequests) OCI service error, or a failure
+// common.IsServiceError doesn't even recognize as a service error (a
+// network-level failure such as a timeout, connection reset, or DNS lookup
+// failure). A 429 reaches here only once throttleTransport has given up on
+// it: after maxThrottleRetries resends, or straight away if it carried no
+// Retry-After that parseRetryAfter accepts; every other 4xx service error (bad request, not authorized, not found,
+// etc.) is not retryable - it would just fail the same way again.
+func isRetryableAPIError(err error) bool {
+	svcErr, ok := common.IsServiceError(err)
+	if !ok {
+		return true
+	}
+	code := svcErr.GetHTTPStatusCode()
+	return code >= 500 || code == http.StatusTooManyRequests
+}
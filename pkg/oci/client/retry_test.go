@@ -0,0 +1,58 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithRetrySucceedsAfterTransientFailures(t *testing.T) {
+	attempts := 0
+	err := withRetry(func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("dial tcp 1.2.3.4:443: i/o timeout")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withRetry() = %v; expected nil after succeeding on attempt 3", err)
+	}
+	if attempts != 3 {
+		t.Errorf("withRetry() made %d attempts; expected 3", attempts)
+	}
+}
+
+func TestWithRetryReturnsLastErrorOnExhaustion(t *testing.T) {
+	wantErr := errors.New("dial tcp 1.2.3.4:443: connection refused")
+	attempts := 0
+	err := withRetry(func() error {
+		attempts++
+		return wantErr
+	})
+	if attempts != apiRetrySteps {
+		t.Errorf("withRetry() made %d attempts; expected %d", attempts, apiRetrySteps)
+	}
+	if err != wantErr {
+		t.Errorf("withRetry() = %v; expected the underlying error %v, not wait.ErrWaitTimeout", err, wantErr)
+	}
+}
+
+func TestIsRetryableAPIError(t *testing.T) {
+	if !isRetryableAPIError(errors.New("dial tcp: i/o timeout")) {
+		t.Error("isRetryableAPIError() = false for a non-service (network) error; want true")
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "testing"
+
+func TestCreateVolumeRetryTokenStableForSameCall(t *testing.T) {
+	a := createVolumeRetryToken("fake-ad-1", "ocid1.compartment.oc1..aaaa", "pvc-1234")
+	b := createVolumeRetryToken("fake-ad-1", "ocid1.compartment.oc1..aaaa", "pvc-1234")
+
+	if a != b {
+		t.Error("createVolumeRetryToken() differed across two calls with identical arguments; retries would each get a distinct token")
+	}
+}
+
+func TestCreateVolumeRetryTokenChangesWithDisplayName(t *testing.T) {
+	a := createVolumeRetryToken("fake-ad-1", "ocid1.compartment.oc1..aaaa", "pvc-1234")
+	b := createVolumeRetryToken("fake-ad-1", "ocid1.compartment.oc1..aaaa", "pvc-5678")
+
+	if a == b {
+		t.Error("createVolumeRetryToken() matched for two different volume names")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/common"
+)
+
+// sessionTokenConfigurationProvider is a common.ConfigurationProvider for
+// the session tokens produced by `oci session authenticate` and kept fresh
+// by `oci session refresh`. Unlike rawConfigurationProvider, it has no
+// long-running refresh logic of its own: since the driver is re-exec'd by
+// kubelet for every volume operation (see cmd/oci's GetLogAsync doc comment
+// for the same reasoning), the simplest correct thing is
+// to re-read the token from disk on every call and let an external `oci
+// session refresh` process - run by whatever manages the node - keep it
+// current.
+type sessionTokenConfigurationProvider struct {
+	securityTokenFile string
+	privateKey        *rsa.PrivateKey
+	region            string
+}
+
+// newSessionTokenConfigurationProvider builds a ConfigurationProvider backed
+// by the session token in securityTokenFile, signing requests with the
+// session's own keypair (privateKeyFile), rather than a tenancy-issued API
+// signing key.
+func newSessionTokenConfigurationProvider(securityTokenFile, privateKeyFile, region string, passphrase *string) (common.ConfigurationProvider, error) {
+	keyData, err := ioutil.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading session token private key file: %v", err)
+	}
+	key, err := common.PrivateKeyFromBytes(keyData, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("parsing session token private key: %v", err)
+	}
+	return sessionTokenConfigurationProvider{
+		securityTokenFile: securityTokenFile,
+		privateKey:        key,
+		region:            region,
+	}, nil
+}
+
+func (p sessionTokenConfigurationProvider) readToken() (string, error) {
+	data, err := ioutil.ReadFile(p.securityTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("reading security token file: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// KeyID identifies the session's signing key to the OCI API as the token
+// itself, in the "ST$<token>" format OCI uses to distinguish session-token
+// auth from the tenancy/user/fingerprint triple a long-lived API key uses.
+func (p sessionTokenConfigurationProvider) KeyID() (string, error) {
+	token, err := p.readToken()
+	if err != nil {
+		return "", err
+	}
+	return "ST$" + token, nil
+}
+
+func (p sessionTokenConfigurationProvider) PrivateRSAKey() (*rsa.PrivateKey, error) {
+	return p.privateKey, nil
+}
+
+// TenancyOCID and UserOCID are not configured separately for session-token
+// auth - they're decoded from the token's own claims, same as the OCI CLI
+// and SDKs do.
+func (p sessionTokenConfigurationProvider) TenancyOCID() (string, error) {
+	claims, err := p.claims()
+	if err != nil {
+		return "", err
+	}
+	return claims.Tenant, nil
+}
+
+func (p sessionTokenConfigurationProvider) UserOCID() (string, error) {
+	claims, err := p.claims()
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// KeyFingerprint is meaningless for a session token: the API identifies the
+// signing key from the KeyID token itself, not a fingerprint.
+func (p sessionTokenConfigurationProvider) KeyFingerprint() (string, error) {
+	return "", nil
+}
+
+func (p sessionTokenConfigurationProvider) Region() (string, error) {
+	return p.region, nil
+}
+
+// sessionTokenClaims is the small subset of a session token's JWT claims
+// this provider needs.
+type sessionTokenClaims struct {
+	Tenant  string `json:"tenant"`
+	Subject string `json:"sub"`
+}
+
+// claims decodes the (unverified) payload segment of the session token -
+// the OCI API itself is what validates the token's signature and
+// expiration, so the driver only needs to read the claims it carries, not
+// authenticate them itself.
+func (p sessionTokenConfigurationProvider) claims() (sessionTokenClaims, error) {
+	token, err := p.readToken()
+	if err != nil {
+		return sessionTokenClaims{}, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return sessionTokenClaims{}, fmt.Errorf("security token is not a valid JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return sessionTokenClaims{}, fmt.Errorf("decoding security token claims: %v", err)
+	}
+
+	var claims sessionTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return sessionTokenClaims{}, fmt.Errorf("unmarshalling security token claims: %v", err)
+	}
+	return claims, nil
+}
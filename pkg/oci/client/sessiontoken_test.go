@@ -0,0 +1,127 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSessionToken builds a syntactically valid but unsigned JWT carrying
+// the given claims, good enough to exercise claim decoding - the OCI API,
+// not this provider, is what verifies a token's signature.
+func fakeSessionToken(t *testing.T, tenant, sub string) string {
+	t.Helper()
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload, err := json.Marshal(sessionTokenClaims{Tenant: tenant, Subject: sub})
+	if err != nil {
+		t.Fatalf("failed to marshal test claims: %v", err)
+	}
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func newTestSessionTokenProvider(t *testing.T, token string) (sessionTokenConfigurationProvider, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate test key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	dir, err := ioutil.TempDir("", "sessiontoken")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	keyPath := filepath.Join(dir, "session-key.pem")
+	if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatalf("failed to write test key: %v", err)
+	}
+
+	tokenPath := filepath.Join(dir, "session-token")
+	if err := ioutil.WriteFile(tokenPath, []byte(token), 0600); err != nil {
+		t.Fatalf("failed to write test token: %v", err)
+	}
+
+	cp, err := newSessionTokenConfigurationProvider(tokenPath, keyPath, "us-phoenix-1", nil)
+	if err != nil {
+		t.Fatalf("newSessionTokenConfigurationProvider() unexpected error: %v", err)
+	}
+	return cp.(sessionTokenConfigurationProvider), tokenPath
+}
+
+func TestSessionTokenConfigurationProvider(t *testing.T) {
+	token := fakeSessionToken(t, "ocid1.tenancy.oc1..aaaa", "ocid1.user.oc1..bbbb")
+	cp, tokenPath := newTestSessionTokenProvider(t, token)
+
+	keyID, err := cp.KeyID()
+	if err != nil {
+		t.Fatalf("KeyID() unexpected error: %v", err)
+	}
+	if want := "ST$" + token; keyID != want {
+		t.Errorf("KeyID() = %q; want %q", keyID, want)
+	}
+
+	tenancy, err := cp.TenancyOCID()
+	if err != nil || tenancy != "ocid1.tenancy.oc1..aaaa" {
+		t.Errorf("TenancyOCID() = (%q, %v); want (%q, nil)", tenancy, err, "ocid1.tenancy.oc1..aaaa")
+	}
+
+	user, err := cp.UserOCID()
+	if err != nil || user != "ocid1.user.oc1..bbbb" {
+		t.Errorf("UserOCID() = (%q, %v); want (%q, nil)", user, err, "ocid1.user.oc1..bbbb")
+	}
+
+	if fingerprint, err := cp.KeyFingerprint(); err != nil || fingerprint != "" {
+		t.Errorf("KeyFingerprint() = (%q, %v); want (\"\", nil)", fingerprint, err)
+	}
+
+	if region, err := cp.Region(); err != nil || region != "us-phoenix-1" {
+		t.Errorf("Region() = (%q, %v); want (%q, nil)", region, err, "us-phoenix-1")
+	}
+
+	if _, err := cp.PrivateRSAKey(); err != nil {
+		t.Errorf("PrivateRSAKey() unexpected error: %v", err)
+	}
+
+	// A refreshed token on disk should be picked up on the very next call,
+	// with nothing cached from construction time.
+	refreshed := fakeSessionToken(t, "ocid1.tenancy.oc1..cccc", "ocid1.user.oc1..dddd")
+	if err := ioutil.WriteFile(tokenPath, []byte(refreshed), 0600); err != nil {
+		t.Fatalf("failed to rewrite test token: %v", err)
+	}
+	if tenancy, err := cp.TenancyOCID(); err != nil || tenancy != "ocid1.tenancy.oc1..cccc" {
+		t.Errorf("TenancyOCID() after refresh = (%q, %v); want (%q, nil)", tenancy, err, "ocid1.tenancy.oc1..cccc")
+	}
+}
+
+func TestSessionTokenConfigurationProviderMalformedToken(t *testing.T) {
+	cp, _ := newTestSessionTokenProvider(t, "not-a-jwt")
+
+	if _, err := cp.TenancyOCID(); err == nil {
+		t.Error("TenancyOCID() => nil error for a malformed token; want one")
+	}
+}
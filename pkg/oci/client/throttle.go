@@ -0,0 +1,95 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+// maxThrottleRetries bounds how many times throttleTransport will honor a
+// 429's Retry-After and resend the request itself, so a server that keeps
+// throttling forever can't hang a callout indefinitely.
+const maxThrottleRetries = 3
+
+// throttleTransport wraps another http.RoundTripper, honoring HTTP 429
+// (TooManyRequests) responses by sleeping for the duration in the
+// Retry-After header and resending the request, instead of letting the 429
+// surface as an error straight away. The OCI SDK's servicefailure type
+// discards response headers by the time it constructs an error (see
+// vendor/github.com/oracle/oci-go-sdk/common/errors.go), so Retry-After has
+// to be read here, at the transport, or not at all. In a large cluster
+// where many nodes attach/detach volumes around the same time, 429s are
+// routine rather than exceptional, and failing the callout outright just
+// makes the kubelet retry sooner and pile on more throttling.
+type throttleTransport struct {
+	next http.RoundTripper
+}
+
+// wrapWithThrottleTransport returns a RoundTripper that retries a 429
+// response through next after sleeping for its Retry-After, up to
+// maxThrottleRetries times.
+func wrapWithThrottleTransport(next http.RoundTripper) http.RoundTripper {
+	return &throttleTransport{next: next}
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := drainAndRestore(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		resp, err = t.next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= maxThrottleRetries {
+			return resp, err
+		}
+
+		retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			return resp, err
+		}
+
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+
+		loglevel.Warnf("oci api request throttled: %s %s: retrying in %s (attempt %d/%d)",
+			req.Method, req.URL, retryAfter, attempt+1, maxThrottleRetries)
+		time.Sleep(retryAfter)
+	}
+}
+
+// parseRetryAfter parses a Retry-After header value in the delta-seconds
+// form OCI sends (e.g. "4"). It does not handle the HTTP-date form the RFC
+// also allows, since OCI has never been observed to send one; a value it
+// can't parse is treated as absent.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(v)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
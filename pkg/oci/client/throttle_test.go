@@ -0,0 +1,135 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestThrottleTransportRetriesOn429(t *testing.T) {
+	var attempts int
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		body, _ := ioutil.ReadAll(req.Body)
+		if string(body) != "payload" {
+			t.Errorf("request body = %q; want %q on attempt %d", body, "payload", attempts)
+		}
+		if attempts < 3 {
+			resp := &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": []string{"0"}},
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}
+			return resp, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	})
+
+	transport := wrapWithThrottleTransport(next)
+	req, _ := http.NewRequest("POST", "http://example.invalid", bytes.NewReader([]byte("payload")))
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() => %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("RoundTrip() status = %d; want %d", resp.StatusCode, http.StatusOK)
+	}
+	if attempts != 3 {
+		t.Errorf("RoundTrip() made %d attempts; want 3", attempts)
+	}
+}
+
+func TestThrottleTransportGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts int
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"0"}},
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	})
+
+	transport := wrapWithThrottleTransport(next)
+	req, _ := http.NewRequest("POST", "http://example.invalid", bytes.NewReader([]byte("payload")))
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() => %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("RoundTrip() status = %d; want %d after exhausting retries", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if attempts != maxThrottleRetries+1 {
+		t.Errorf("RoundTrip() made %d attempts; want %d", attempts, maxThrottleRetries+1)
+	}
+}
+
+func TestThrottleTransportStopsWithoutRetryAfter(t *testing.T) {
+	var attempts int
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	})
+
+	transport := wrapWithThrottleTransport(next)
+	req, _ := http.NewRequest("GET", "http://example.invalid", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() => %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("RoundTrip() made %d attempts; want 1 when Retry-After is absent", attempts)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"4", 4 * time.Second, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"not-a-number", 0, false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.value, func(t *testing.T) {
+			d, ok := parseRetryAfter(tt.value)
+			if ok != tt.ok || d != tt.expected {
+				t.Errorf("parseRetryAfter(%q) = (%v, %v); want (%v, %v)", tt.value, d, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "time"
+
+// Default timeouts applied when the corresponding Timeouts field is unset
+// (zero). These are this client's historical hardcoded values.
+const (
+	defaultRequestTimeout      = time.Minute
+	defaultDialTimeout         = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// Timeouts overrides the per-request context timeout client wraps every OCI
+// API call in, and the dialer/TLS handshake timeouts used when this
+// client's transport is built (see configureCustomTransport). A zero field
+// falls back to this client's historical hardcoded default, so existing
+// config.yaml files need no changes to keep behaving exactly as before.
+type Timeouts struct {
+	// RequestSeconds bounds each attempt at a single OCI API call (see
+	// client.timeout); every withRetry retry starts a fresh timeout. Too short a value on a slow network can make
+	// Attach/Detach fail calls that would otherwise have succeeded; too
+	// long a value delays this client noticing a genuinely hung request.
+	RequestSeconds int `yaml:"requestSeconds"`
+
+	// DialSeconds bounds establishing the TCP connection to the OCI API.
+	DialSeconds int `yaml:"dialSeconds"`
+
+	// TLSHandshakeSeconds bounds the TLS handshake once the TCP connection
+	// is established.
+	TLSHandshakeSeconds int `yaml:"tlsHandshakeSeconds"`
+}
+
+// request returns the configured per-request timeout, or
+// defaultRequestTimeout if unset.
+func (t Timeouts) request() time.Duration {
+	if t.RequestSeconds > 0 {
+		return time.Duration(t.RequestSeconds) * time.Second
+	}
+	return defaultRequestTimeout
+}
+
+// dial returns the configured dialer timeout, or defaultDialTimeout if
+// unset.
+func (t Timeouts) dial() time.Duration {
+	if t.DialSeconds > 0 {
+		return time.Duration(t.DialSeconds) * time.Second
+	}
+	return defaultDialTimeout
+}
+
+// tlsHandshake returns the configured TLS handshake timeout, or
+// defaultTLSHandshakeTimeout if unset.
+func (t Timeouts) tlsHandshake() time.Duration {
+	if t.TLSHandshakeSeconds > 0 {
+		return time.Duration(t.TLSHandshakeSeconds) * time.Second
+	}
+	return defaultTLSHandshakeTimeout
+}
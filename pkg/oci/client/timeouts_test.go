@@ -0,0 +1,57 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeoutsDefaulting(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   Timeouts
+		want Timeouts
+	}{
+		{
+			name: "unset falls back to defaults",
+			in:   Timeouts{},
+			want: Timeouts{
+				RequestSeconds:      int(defaultRequestTimeout / time.Second),
+				DialSeconds:         int(defaultDialTimeout / time.Second),
+				TLSHandshakeSeconds: int(defaultTLSHandshakeTimeout / time.Second),
+			},
+		},
+		{
+			name: "configured values override defaults",
+			in:   Timeouts{RequestSeconds: 5, DialSeconds: 2, TLSHandshakeSeconds: 1},
+			want: Timeouts{RequestSeconds: 5, DialSeconds: 2, TLSHandshakeSeconds: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.in.request(); got != time.Duration(tc.want.RequestSeconds)*time.Second {
+				t.Errorf("request() = %v, want %v", got, time.Duration(tc.want.RequestSeconds)*time.Second)
+			}
+			if got := tc.in.dial(); got != time.Duration(tc.want.DialSeconds)*time.Second {
+				t.Errorf("dial() = %v, want %v", got, time.Duration(tc.want.DialSeconds)*time.Second)
+			}
+			if got := tc.in.tlsHandshake(); got != time.Duration(tc.want.TLSHandshakeSeconds)*time.Second {
+				t.Errorf("tlsHandshake() = %v, want %v", got, time.Duration(tc.want.TLSHandshakeSeconds)*time.Second)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// TLSConfig exposes TLS parameters for the OCI transport, so security
+// baselines (e.g. TLS 1.2+ only, no CBC cipher suites) can be enforced
+// regardless of what the SDK or Go's own defaults would otherwise allow.
+type TLSConfig struct {
+	// MinVersion is the minimum TLS version to negotiate, e.g. "1.2". If
+	// unset, Go's default minimum applies.
+	MinVersion string `yaml:"minVersion"`
+
+	// CipherSuites restricts the TLS 1.2 cipher suites that may be
+	// negotiated, by name (e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"). If
+	// unset, Go's default cipher suite list applies. Has no effect on TLS
+	// 1.3, whose cipher suites Go does not allow configuring.
+	CipherSuites []string `yaml:"cipherSuites"`
+}
+
+var tlsVersionsByName = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var cipherSuitesByName = buildCipherSuitesByName()
+
+func buildCipherSuitesByName() map[string]uint16 {
+	m := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		m[cs.Name] = cs.ID
+	}
+	for _, cs := range tls.InsecureCipherSuites() {
+		m[cs.Name] = cs.ID
+	}
+	return m
+}
+
+// minVersion returns the tls.VersionTLSxx constant named by t.MinVersion, or
+// 0 if t.MinVersion is unset.
+func (t TLSConfig) minVersion() (uint16, error) {
+	if t.MinVersion == "" {
+		return 0, nil
+	}
+	v, ok := tlsVersionsByName[t.MinVersion]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS minimum version %q", t.MinVersion)
+	}
+	return v, nil
+}
+
+// cipherSuiteIDs returns the cipher suite IDs named by t.CipherSuites, or nil
+// if t.CipherSuites is unset.
+func (t TLSConfig) cipherSuiteIDs() ([]uint16, error) {
+	if len(t.CipherSuites) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint16, 0, len(t.CipherSuites))
+	for _, name := range t.CipherSuites {
+		id, ok := cipherSuitesByName[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestTLSConfigMinVersion(t *testing.T) {
+	testCases := []struct {
+		in        string
+		want      uint16
+		shouldErr bool
+	}{
+		{"", 0, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, false},
+		{"1.5", 0, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := TLSConfig{MinVersion: tt.in}.minVersion()
+			if tt.shouldErr && err == nil {
+				t.Fatal("expected an error, got none")
+			}
+			if !tt.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("minVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSConfigCipherSuiteIDs(t *testing.T) {
+	testCases := []struct {
+		name      string
+		in        []string
+		wantLen   int
+		shouldErr bool
+	}{
+		{"unset", nil, 0, false},
+		{"known", []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}, 1, false},
+		{"unknown", []string{"TLS_NOT_A_REAL_SUITE"}, 0, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TLSConfig{CipherSuites: tt.in}.cipherSuiteIDs()
+			if tt.shouldErr && err == nil {
+				t.Fatal("expected an error, got none")
+			}
+			if !tt.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("cipherSuiteIDs() len = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// WaitStrategyFixed polls the OCI API at a fixed interval. This is the
+	// default and matches the driver's historical behaviour.
+	WaitStrategyFixed = "fixed"
+
+	// WaitStrategyExponential polls the OCI API with an exponentially
+	// increasing interval between attempts, trading a little latency for
+	// fewer calls against a slow-moving operation.
+	WaitStrategyExponential = "exponential"
+
+	// WaitStrategyEvent would wait on an OCI work request instead of
+	// polling. The vendored oci-go-sdk client in this tree has no work
+	// request client, so this value is accepted by config parsing but
+	// rejected by validation until that support exists.
+	WaitStrategyEvent = "event"
+)
+
+// knownWaitStrategies are the AttachmentWaitStrategy values validate()
+// accepts syntactically. Not all of them are implementable yet; see
+// newWaitStrategy.
+var knownWaitStrategies = map[string]bool{
+	WaitStrategyFixed:       true,
+	WaitStrategyExponential: true,
+	WaitStrategyEvent:       true,
+}
+
+func knownWaitStrategyNames() []string {
+	names := make([]string, 0, len(knownWaitStrategies))
+	for name := range knownWaitStrategies {
+		names = append(names, name)
+	}
+	return names
+}
+
+// waitStrategy drives how the client polls the OCI API while waiting for an
+// asynchronous operation (volume attach/detach/availability) to reach its
+// target state.
+type waitStrategy interface {
+	// Wait blocks, repeatedly invoking condition, until it reports done,
+	// returns an error, or the strategy gives up and returns
+	// wait.ErrWaitTimeout.
+	Wait(condition wait.ConditionFunc) error
+}
+
+// fixedIntervalWaitStrategy polls at a constant interval up to timeout.
+type fixedIntervalWaitStrategy struct {
+	interval time.Duration
+	timeout  time.Duration
+}
+
+func (s fixedIntervalWaitStrategy) Wait(condition wait.ConditionFunc) error {
+	return wait.PollImmediate(s.interval, s.timeout, condition)
+}
+
+// exponentialWaitStrategy polls with an exponentially increasing interval,
+// starting at initialInterval and growing by factor on each attempt for up
+// to steps attempts.
+type exponentialWaitStrategy struct {
+	initialInterval time.Duration
+	factor          float64
+	steps           int
+}
+
+func (s exponentialWaitStrategy) Wait(condition wait.ConditionFunc) error {
+	return wait.ExponentialBackoff(wait.Backoff{
+		Duration: s.initialInterval,
+		Factor:   s.factor,
+		Steps:    s.steps,
+	}, condition)
+}
+
+// newWaitStrategy builds the waitStrategy described by name, using
+// pollInterval/pollTimeout as the basis for its pacing.
+func newWaitStrategy(name string, pollInterval, pollTimeout time.Duration) (waitStrategy, error) {
+	switch name {
+	case "", WaitStrategyFixed:
+		return fixedIntervalWaitStrategy{interval: pollInterval, timeout: pollTimeout}, nil
+	case WaitStrategyExponential:
+		steps := int(pollTimeout / pollInterval)
+		if steps < 1 {
+			steps = 1
+		}
+		return exponentialWaitStrategy{initialInterval: pollInterval, factor: 2.0, steps: steps}, nil
+	case WaitStrategyEvent:
+		return nil, fmt.Errorf("attachmentWaitStrategy %q is not supported by this build: the vendored OCI SDK has no work request client", name)
+	default:
+		return nil, fmt.Errorf("unknown attachmentWaitStrategy %q; want one of %v", name, knownWaitStrategyNames())
+	}
+}
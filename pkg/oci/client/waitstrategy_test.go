@@ -0,0 +1,99 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestNewWaitStrategy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		strategy  string
+		shouldErr bool
+	}{
+		{"default", "", false},
+		{"fixed", WaitStrategyFixed, false},
+		{"exponential", WaitStrategyExponential, false},
+		{"event", WaitStrategyEvent, true},
+		{"unknown", "not-a-strategy", true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newWaitStrategy(tt.strategy, time.Millisecond, 10*time.Millisecond)
+			if tt.shouldErr {
+				if err == nil {
+					t.Fatalf("newWaitStrategy(%q) => no error; expected one", tt.strategy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newWaitStrategy(%q) => %v; expected no error", tt.strategy, err)
+			}
+			attempts := 0
+			err = s.Wait(func() (bool, error) {
+				attempts++
+				return attempts >= 3, nil
+			})
+			if err != nil {
+				t.Errorf("Wait() => %v; expected no error", err)
+			}
+			if attempts != 3 {
+				t.Errorf("Wait() made %d attempts; expected 3", attempts)
+			}
+		})
+	}
+}
+
+func TestWaitStrategyTimeout(t *testing.T) {
+	s, err := newWaitStrategy(WaitStrategyFixed, time.Millisecond, 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("newWaitStrategy() => %v", err)
+	}
+
+	err = s.Wait(func() (bool, error) { return false, nil })
+	if err != wait.ErrWaitTimeout {
+		t.Errorf("Wait() => %v; expected wait.ErrWaitTimeout", err)
+	}
+}
+
+func TestValidateAttachmentWaitStrategy(t *testing.T) {
+	testCases := []struct {
+		strategy  string
+		shouldErr bool
+	}{
+		{"", false},
+		{WaitStrategyFixed, false},
+		{WaitStrategyExponential, false},
+		{WaitStrategyEvent, true},
+		{"not-a-strategy", true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.strategy, func(t *testing.T) {
+			errs := validateAttachmentWaitStrategy(&Config{AttachmentWaitStrategy: tt.strategy})
+			if tt.shouldErr && len(errs) == 0 {
+				t.Errorf("validateAttachmentWaitStrategy(%q) => no error; expected one", tt.strategy)
+			}
+			if !tt.shouldErr && len(errs) != 0 {
+				t.Errorf("validateAttachmentWaitStrategy(%q) => %v; expected no error", tt.strategy, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	defaultAsyncDetachConcurrency     = 2
+	defaultAsyncDetachIntervalSeconds = 1
+)
+
+// AsyncDetachEnabled reports whether Detach should enqueue its requests
+// (see pkg/detachqueue) instead of performing them synchronously,
+// overridable via OCI_FLEXD_ASYNC_DETACH. Meant to be enabled alongside a
+// node shutdown hook or systemd timer that periodically runs this binary's
+// "drain-detach-queue" admin command, so a mass eviction's worth of Detach
+// callouts doesn't turn into that many simultaneous OCI API calls.
+func AsyncDetachEnabled() bool {
+	return os.Getenv("OCI_FLEXD_ASYNC_DETACH") != ""
+}
+
+// AsyncDetachDir returns the directory queued detach requests are persisted
+// to and read back from, overridable via OCI_FLEXD_ASYNC_DETACH_DIR.
+func AsyncDetachDir() string {
+	dir := os.Getenv("OCI_FLEXD_ASYNC_DETACH_DIR")
+	if dir != "" {
+		return dir
+	}
+	return filepath.Join(GetConfigDirectory(), "detach-queue")
+}
+
+// AsyncDetachConcurrency returns the maximum number of queued detaches
+// "drain-detach-queue" will process at once, overridable via
+// OCI_FLEXD_ASYNC_DETACH_CONCURRENCY.
+func AsyncDetachConcurrency() int {
+	return intFromEnv("OCI_FLEXD_ASYNC_DETACH_CONCURRENCY", defaultAsyncDetachConcurrency)
+}
+
+// AsyncDetachIntervalSeconds returns the minimum gap, in seconds, between
+// "drain-detach-queue" starting one queued detach and the next, overridable
+// via OCI_FLEXD_ASYNC_DETACH_INTERVAL_SECONDS.
+func AsyncDetachIntervalSeconds() int {
+	return intFromEnv("OCI_FLEXD_ASYNC_DETACH_INTERVAL_SECONDS", defaultAsyncDetachIntervalSeconds)
+}
@@ -0,0 +1,31 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import "os"
+
+// AttachDisabled reports whether this driver should advertise the "attach"
+// capability as false on init, overridable via OCI_FLEXD_DISABLE_ATTACH.
+// A false "attach" capability tells the controller-manager this plugin
+// doesn't need Attach/Detach calling out to it at all, so every node runs
+// the kubelet's own per-pod Mount/Unmount bind-mount flow (see
+// OCIFlexvolumeDriver.Mount) instead - the same fallback a kubelet started
+// without --enable-controller-attach-detach already takes, but opted into
+// per-driver rather than cluster-wide. Meant for clusters where the
+// controller-manager's attach/detach controller shouldn't be trusted with
+// this driver's volumes at all, e.g. during a staged migration off it.
+func AttachDisabled() bool {
+	return os.Getenv("OCI_FLEXD_DISABLE_ATTACH") != ""
+}
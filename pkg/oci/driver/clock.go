@@ -0,0 +1,40 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import "time"
+
+// Clock abstracts time so that wait loops and the IsAttached cache can be
+// driven deterministically in tests, instead of reaching for the package-level
+// time.Now/time.Sleep directly.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock is the Clock used in production.
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// getClock returns d.Clock, falling back to realClock{} when the driver was
+// constructed without one (e.g. via a struct literal in existing tests).
+func (d OCIFlexvolumeDriver) getClock() Clock {
+	if d.Clock != nil {
+		return d.Clock
+	}
+	return realClock{}
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+// getVolumeNameMinVersion is the earliest kubelet/apiserver version on which
+// the getvolumename callout is known to work. Below it, the callout was
+// broken (see https://github.com/kubernetes/kubernetes/issues/44737) and
+// this driver has always needed to respond StatusNotSupported regardless of
+// the options it was passed.
+var getVolumeNameMinVersion = kubeVersion{major: 1, minor: 6, patch: 5}
+
+// kubeVersion is a parsed major.minor.patch kubelet/apiserver version. It's
+// deliberately narrower than a full semver parser since all this package
+// needs is to compare against the handful of known-broken callouts below.
+type kubeVersion struct {
+	major, minor, patch int
+}
+
+var kubeVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)`)
+
+// parseKubeVersion parses the gitVersion string reported by
+// Discovery().ServerVersion() (e.g. "v1.6.4"), ignoring any
+// pre-release/build metadata suffix.
+func parseKubeVersion(gitVersion string) (kubeVersion, error) {
+	m := kubeVersionPattern.FindStringSubmatch(gitVersion)
+	if m == nil {
+		return kubeVersion{}, fmt.Errorf("could not parse kube version %q", gitVersion)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return kubeVersion{major: major, minor: minor, patch: patch}, nil
+}
+
+// atLeast reports whether v is greater than or equal to other.
+func (v kubeVersion) atLeast(other kubeVersion) bool {
+	if v.major != other.major {
+		return v.major > other.major
+	}
+	if v.minor != other.minor {
+		return v.minor > other.minor
+	}
+	return v.patch >= other.patch
+}
+
+// capabilities inspects the apiserver version (used as a proxy for the
+// kubelet version, since they're usually deployed in lockstep, and the
+// kubelet itself never tells a flexvolume driver its version) and returns
+// which version-sensitive callouts this driver can answer honestly rather
+// than via a hardcoded StatusNotSupported. It replaces what used to be a
+// comment next to the getvolumename case explaining why it was disabled.
+//
+// The "attach" entry isn't version-sensitive - it's a static operator
+// choice, see AttachDisabled - but lives here too since both are reported
+// on the same init response.
+//
+// On worker nodes (d.master == false) there's no apiserver connection to
+// query, so the version-sensitive decisions default to whatever the last
+// known-broken version range says is safe.
+func (d OCIFlexvolumeDriver) capabilities() map[string]bool {
+	caps := map[string]bool{
+		"attach":        !AttachDisabled(),
+		"getVolumeName": false,
+	}
+
+	if !d.master || d.K == nil {
+		loglevel.Debugf("capabilities: worker node; assuming getvolumename is unsupported")
+		return caps
+	}
+
+	info, err := d.K.Discovery().ServerVersion()
+	if err != nil {
+		loglevel.Warnf("capabilities: could not determine apiserver version: %v", err)
+		return caps
+	}
+
+	v, err := parseKubeVersion(info.GitVersion)
+	if err != nil {
+		loglevel.Warnf("capabilities: %v", err)
+		return caps
+	}
+
+	caps["getVolumeName"] = v.atLeast(getVolumeNameMinVersion)
+	return caps
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"os"
+	"testing"
+
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseKubeVersion(t *testing.T) {
+	testCases := []struct {
+		gitVersion string
+		want       kubeVersion
+		wantErr    bool
+	}{
+		{"v1.6.4", kubeVersion{1, 6, 4}, false},
+		{"v1.10.11-gke.1", kubeVersion{1, 10, 11}, false},
+		{"garbage", kubeVersion{}, true},
+	}
+
+	for _, tt := range testCases {
+		got, err := parseKubeVersion(tt.gitVersion)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseKubeVersion(%q) => nil error; want one", tt.gitVersion)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseKubeVersion(%q) => %v; want nil error", tt.gitVersion, err)
+		}
+		if got != tt.want {
+			t.Errorf("parseKubeVersion(%q) = %+v; want %+v", tt.gitVersion, got, tt.want)
+		}
+	}
+}
+
+func TestKubeVersionAtLeast(t *testing.T) {
+	if !(kubeVersion{1, 6, 5}).atLeast(kubeVersion{1, 6, 5}) {
+		t.Error("1.6.5.atLeast(1.6.5) => false; want true")
+	}
+	if !(kubeVersion{1, 7, 0}).atLeast(kubeVersion{1, 6, 5}) {
+		t.Error("1.7.0.atLeast(1.6.5) => false; want true")
+	}
+	if (kubeVersion{1, 6, 4}).atLeast(kubeVersion{1, 6, 5}) {
+		t.Error("1.6.4.atLeast(1.6.5) => true; want false")
+	}
+}
+
+func TestCapabilitiesWorkerNode(t *testing.T) {
+	d := OCIFlexvolumeDriver{master: false}
+	caps := d.capabilities()
+	if caps["getVolumeName"] {
+		t.Errorf("capabilities()[\"getVolumeName\"] = true on a worker node; want false")
+	}
+}
+
+func TestCapabilitiesAttach(t *testing.T) {
+	original := os.Getenv("OCI_FLEXD_DISABLE_ATTACH")
+	defer os.Setenv("OCI_FLEXD_DISABLE_ATTACH", original)
+
+	os.Unsetenv("OCI_FLEXD_DISABLE_ATTACH")
+	d := OCIFlexvolumeDriver{master: false}
+	if caps := d.capabilities(); !caps["attach"] {
+		t.Errorf(`capabilities()["attach"] = false with OCI_FLEXD_DISABLE_ATTACH unset; want true`)
+	}
+
+	os.Setenv("OCI_FLEXD_DISABLE_ATTACH", "1")
+	if caps := d.capabilities(); caps["attach"] {
+		t.Errorf(`capabilities()["attach"] = true with OCI_FLEXD_DISABLE_ATTACH set; want false`)
+	}
+}
+
+func TestCapabilitiesMasterNode(t *testing.T) {
+	testCases := []struct {
+		name       string
+		gitVersion string
+		want       bool
+	}{
+		{"broken version", "v1.6.4", false},
+		{"fixed version", "v1.6.5", true},
+		{"newer version", "v1.10.0", true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			k := fake.NewSimpleClientset()
+			k.Discovery().(*discoveryfake.FakeDiscovery).FakedServerVersion = &apimachineryversion.Info{GitVersion: tt.gitVersion}
+
+			d := OCIFlexvolumeDriver{master: true, K: k}
+			if got := d.capabilities()["getVolumeName"]; got != tt.want {
+				t.Errorf("capabilities()[\"getVolumeName\"] = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
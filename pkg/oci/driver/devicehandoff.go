@@ -0,0 +1,89 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"net/url"
+	"strings"
+)
+
+// attachmentHandoff is what Attach learns about a completed volume
+// attachment that WaitForAttach and MountDevice, running later on the
+// worker node, need as well. flexvolume.DriverStatus.Device is the only
+// channel the Flexvolume exec protocol gives Attach to pass anything
+// through to those calls, so it's encoded into that string rather than
+// looked up again - the worker node has no OCI API credentials to do that
+// lookup itself (see instancemeta.Interface.GetVolumeAttachments' doc
+// comment).
+type attachmentHandoff struct {
+	// Device is the kernel device path for the attachment, e.g.
+	// /dev/disk/by-path/ip-<ip>:<port>-iscsi-<iqn>-lun-1 (see
+	// diskIDByPathTemplate).
+	Device string
+
+	// AttachmentID is the OCID of the volume attachment itself, as returned
+	// by AttachVolume/FindVolumeAttachment.
+	AttachmentID string
+
+	// AttachmentType is the OCI attachment type, e.g. "iscsi" - mirrors
+	// instancemeta.VolumeAttachment.AttachmentType. MountDevice only
+	// handles "iscsi" today (see its doc comment on FSS support), but
+	// having this on hand lets a worker-side call branch on attachment
+	// type explicitly instead of assuming every Device string it's ever
+	// handed is an iSCSI by-path device.
+	AttachmentType string
+}
+
+const attachmentTypeISCSI = "iscsi"
+
+// encodeAttachmentHandoff formats h as "<device>?attachmentId=<id>&attachmentType=<type>"
+// for flexvolume.DriverStatus.Device. AttachmentID and AttachmentType are
+// omitted from the query string when empty; a "?" is a character that
+// never appears in a /dev path, so a device with no metadata to carry
+// round-trips as exactly the bare path it always used to be.
+func encodeAttachmentHandoff(h attachmentHandoff) string {
+	v := url.Values{}
+	if h.AttachmentID != "" {
+		v.Set("attachmentId", h.AttachmentID)
+	}
+	if h.AttachmentType != "" {
+		v.Set("attachmentType", h.AttachmentType)
+	}
+	if len(v) == 0 {
+		return h.Device
+	}
+	return h.Device + "?" + v.Encode()
+}
+
+// decodeAttachmentHandoff parses a flexvolume.DriverStatus.Device string
+// produced by encodeAttachmentHandoff. It also accepts a bare device path
+// with no "?" suffix, so it can be used on a Device string handed off by an
+// older build of this driver without a migration step.
+func decodeAttachmentHandoff(device string) attachmentHandoff {
+	i := strings.IndexByte(device, '?')
+	if i < 0 {
+		return attachmentHandoff{Device: device}
+	}
+
+	v, err := url.ParseQuery(device[i+1:])
+	if err != nil {
+		return attachmentHandoff{Device: device}
+	}
+	return attachmentHandoff{
+		Device:         device[:i],
+		AttachmentID:   v.Get("attachmentId"),
+		AttachmentType: v.Get("attachmentType"),
+	}
+}
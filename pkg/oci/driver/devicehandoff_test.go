@@ -0,0 +1,95 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import "testing"
+
+func TestEncodeAttachmentHandoff(t *testing.T) {
+	testCases := []struct {
+		name string
+		h    attachmentHandoff
+		want string
+	}{
+		{
+			name: "bare device path round-trips unchanged",
+			h:    attachmentHandoff{Device: "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1"},
+			want: "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1",
+		},
+		{
+			name: "attachment id and type",
+			h: attachmentHandoff{
+				Device:         "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1",
+				AttachmentID:   "ocid1.volumeattachment.oc1.phx.aaaaaa",
+				AttachmentType: attachmentTypeISCSI,
+			},
+			want: "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1?attachmentId=ocid1.volumeattachment.oc1.phx.aaaaaa&attachmentType=iscsi",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeAttachmentHandoff(tt.h); got != tt.want {
+				t.Errorf("encodeAttachmentHandoff(%+v) = %q; want %q", tt.h, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeAttachmentHandoff(t *testing.T) {
+	testCases := []struct {
+		name   string
+		device string
+		want   attachmentHandoff
+	}{
+		{
+			name:   "bare device path from an older build",
+			device: "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1",
+			want:   attachmentHandoff{Device: "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1"},
+		},
+		{
+			name:   "encoded attachment id and type",
+			device: "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1?attachmentId=ocid1.volumeattachment.oc1.phx.aaaaaa&attachmentType=iscsi",
+			want: attachmentHandoff{
+				Device:         "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1",
+				AttachmentID:   "ocid1.volumeattachment.oc1.phx.aaaaaa",
+				AttachmentType: attachmentTypeISCSI,
+			},
+		},
+		{
+			name:   "malformed query string falls back to the whole string as the device",
+			device: "/dev/oci/ocid1.volume.oc1.phx.aaaaaa?%zz",
+			want:   attachmentHandoff{Device: "/dev/oci/ocid1.volume.oc1.phx.aaaaaa?%zz"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeAttachmentHandoff(tt.device); got != tt.want {
+				t.Errorf("decodeAttachmentHandoff(%q) = %+v; want %+v", tt.device, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentHandoffRoundTrip(t *testing.T) {
+	want := attachmentHandoff{
+		Device:         "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.foo-lun-1",
+		AttachmentID:   "ocid1.volumeattachment.oc1.phx.aaaaaa",
+		AttachmentType: attachmentTypeISCSI,
+	}
+	if got := decodeAttachmentHandoff(encodeAttachmentHandoff(want)); got != want {
+		t.Errorf("round trip = %+v; want %+v", got, want)
+	}
+}
@@ -15,22 +15,31 @@
 package driver
 
 import (
-	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/oracle/oci-go-sdk/common"
 	"github.com/oracle/oci-go-sdk/core"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/oracle/oci-flexvolume-driver/pkg/detachqueue"
+	ocierrors "github.com/oracle/oci-flexvolume-driver/pkg/errors"
 	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
 	"github.com/oracle/oci-flexvolume-driver/pkg/iscsi"
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/mount"
 	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/instancemeta"
+	"github.com/oracle/oci-flexvolume-driver/pkg/udev"
+	"github.com/oracle/oci-flexvolume-driver/pkg/zfs"
 )
 
 const (
@@ -40,10 +49,54 @@ const (
 	ocidPrefix           = "ocid1."
 )
 
+// regionKeyToOCIDSegment maps a short region key (as set in config.yaml's
+// auth.regionKey) to the region segment OCI actually uses in that region's
+// volume OCIDs, for the handful of regions where that isn't just the key
+// itself - e.g. "fra" volumes are ocid1.volume.oc1.eu-frankfurt-1.<name>,
+// not oc1.fra.<name>. Sourced from the region constants the vendored SDK
+// knows about (common.RegionFRA etc.) rather than a bare string, so this
+// stays in sync with whatever region names that SDK vendor snapshot uses.
+//
+// This vendored SDK predates OCI's government realms (oc2/oc3) and any
+// commercial regions added since, so it has no way to tell us whether a
+// newer region also diverges like fra does; a regionKey not in this map
+// falls through to being used verbatim, which remains correct for phx,
+// iad, sea and every realm whose OCID region segment is just the key.
+var regionKeyToOCIDSegment = map[string]string{
+	"fra": string(common.RegionFRA),
+}
+
+// failAPIError builds a Fail DriverStatus for an error returned by the
+// oci/client package, classifying it via pkg/errors so the message tells
+// whoever reads the kubelet's logs whether the kubelet retrying the same
+// callout is actually likely to help, without them having to go look up
+// what the underlying OCI API error was themselves.
+func failAPIError(err error) flexvolume.DriverStatus {
+	return flexvolume.Fail(classifiedMessage(err, ocierrors.ClassifyAPIError(err)))
+}
+
+// failMountError is failAPIError's counterpart for errors returned by the
+// iscsi package.
+func failMountError(err error) flexvolume.DriverStatus {
+	return flexvolume.Fail(classifiedMessage(err, ocierrors.ClassifyMountError(err)))
+}
+
+func classifiedMessage(err error, kind ocierrors.Kind) string {
+	if !ocierrors.Retryable(kind) {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v (%s, the kubelet will retry)", err, kind)
+}
+
 // OCIFlexvolumeDriver implements the flexvolume.Driver interface for OCI.
 type OCIFlexvolumeDriver struct {
 	K      kubernetes.Interface
 	master bool
+
+	// Clock is used for the IsAttached cache and wait loops. It defaults to
+	// the real wall clock (see getClock()) so that existing callers which
+	// construct an OCIFlexvolumeDriver directly need not set it.
+	Clock Clock
 }
 
 // NewOCIFlexvolumeDriver creates a new driver
@@ -63,7 +116,7 @@ func NewOCIFlexvolumeDriver() (fvd *OCIFlexvolumeDriver, err error) {
 		}
 		return &OCIFlexvolumeDriver{K: k, master: true}, nil
 	} else if os.IsNotExist(err) {
-		log.Printf("Config file %q does not exist. Assuming worker node.", path)
+		loglevel.Infof("Config file %q does not exist. Assuming worker node.", path)
 		return &OCIFlexvolumeDriver{}, nil
 	}
 	return nil, err
@@ -108,8 +161,19 @@ func GetKubeconfigPath() string {
 	return kcp
 }
 
-// Init checks that we have the appropriate credentials and metadata API access
-// on driver initialisation.
+// newInstanceMetadata constructs the instance metadata client Init probes to
+// tell a worker node on OCI apart from a host that isn't on OCI at all.
+// Overridable in tests.
+var newInstanceMetadata = instancemeta.New
+
+// Init checks that we have the appropriate credentials and metadata API
+// access on driver initialisation. On a worker node - one with no
+// config.yaml, see NewOCIFlexvolumeDriver - that means confirming the
+// instance metadata endpoint actually answers, rather than assuming so:
+// without this check, a host that's neither a configured master nor
+// actually running on OCI sails through Init and only discovers the problem
+// once Attach/WaitForAttach/MountDevice each independently time out talking
+// to an unreachable 169.254.169.254.
 func (d OCIFlexvolumeDriver) Init() flexvolume.DriverStatus {
 	path := GetConfigPath()
 	if d.master {
@@ -123,29 +187,77 @@ func (d OCIFlexvolumeDriver) Init() flexvolume.DriverStatus {
 			return flexvolume.Fail(err)
 		}
 	} else {
-		log.Printf("Assuming worker node.")
+		loglevel.Infof("Assuming worker node.")
+		if _, err := newInstanceMetadata().Get(); err != nil {
+			return flexvolume.Fail("not running on OCI (instance metadata unreachable) and no static config present at ", path, ": ", err)
+		}
 	}
 
-	return flexvolume.Succeed()
+	status := flexvolume.Succeed()
+	status.Capabilities = d.capabilities()
+	return status
+}
+
+// GetVolumeName returns the unique name of the volume referred to by opts.
+// Whether it's safe to answer at all is version-sensitive: getvolumename was
+// broken on kubelet/apiserver versions prior to getVolumeNameMinVersion (see
+// capabilities in compat.go), so this driver must keep responding
+// StatusNotSupported there rather than the tribal-knowledge comment this
+// method replaces.
+//
+// The name is returned as the volume's OCID, not the bare PV/volume name
+// opts carries, so that two PVs naming the same underlying volume
+// differently - one by its short name, one by its full OCID, as happens
+// after DeriveVolumeOCID's region-segment handling changes - still
+// deduplicate to the same string instead of being treated as distinct
+// volumes.
+//
+// Deriving the OCID only needs config.Auth.RegionKey, so this reads the
+// config file directly via client.ConfigFromFile rather than going through
+// client.New: the latter builds a fully authenticated client, which for a
+// UseInstancePrincipals config means a round trip to the instance metadata
+// service - unnecessary latency and a new failure mode for a callout that
+// otherwise never touches the network.
+func (d OCIFlexvolumeDriver) GetVolumeName(opts flexvolume.Options) flexvolume.DriverStatus {
+	if !d.capabilities()["getVolumeName"] {
+		return flexvolume.NotSupported("getvolumename is not supported on this kubelet/apiserver version")
+	}
+
+	volumeName := opts["kubernetes.io/pvOrVolumeName"]
+	if volumeName == "" {
+		return flexvolume.Fail("getvolumename: missing kubernetes.io/pvOrVolumeName option")
+	}
+
+	cfg, err := client.ConfigFromFile(GetConfigPath())
+	if err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	return flexvolume.DriverStatus{
+		Status:     flexvolume.StatusSuccess,
+		VolumeName: DeriveVolumeOCID(cfg.Auth.RegionKey, volumeName),
+	}
 }
 
-// deriveVolumeOCID will figure out the correct OCID for a volume
+// DeriveVolumeOCID will figure out the correct OCID for a volume
 // based solely on the region key and volumeName. Because of differences
 // across regions we need to impose some awkward logic here to get the correct
 // OCID or if it is already an OCID then return the OCID.
-func deriveVolumeOCID(regionKey string, volumeName string) string {
+//
+// Exported so that tools outside the driver's Attach/Detach path (e.g.
+// cmd/oci's migrate-to-csi) which only have a PV's short volume name and
+// region key can reconstruct the same OCID this driver would.
+func DeriveVolumeOCID(regionKey string, volumeName string) string {
 	if strings.HasPrefix(volumeName, ocidPrefix) {
 		return volumeName
 	}
 
-	var volumeOCID string
-	if regionKey == "fra" {
-		volumeOCID = fmt.Sprintf(volumeOCIDTemplate, "eu-frankfurt-1", volumeName)
-	} else {
-		volumeOCID = fmt.Sprintf(volumeOCIDTemplate, regionKey, volumeName)
+	regionSegment, ok := regionKeyToOCIDSegment[regionKey]
+	if !ok {
+		regionSegment = regionKey
 	}
 
-	return volumeOCID
+	return fmt.Sprintf(volumeOCIDTemplate, regionSegment, volumeName)
 }
 
 // constructKubeClient uses a kubeconfig layed down by a secret via deploy.sh to return
@@ -163,64 +275,108 @@ func constructKubeClient() (*kubernetes.Clientset, error) {
 	return k, err
 }
 
-// lookupNodeID returns the OCID for the given nodeName.
-func lookupNodeID(k kubernetes.Interface, nodeName string) (string, error) {
-	n, err := k.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+// lookupNodeID returns the OCID for the given nodeName, retrying up to
+// NodeResolutionRetries times (bounded by the callout's overall
+// CalloutBudget) so that a transient lookup failure doesn't immediately fail
+// the callout.
+//
+// Which strategies are tried, and in what order, comes from c.GetConfig().
+// NodeResolutionStrategies (see resolveNodeID); a node that exists but
+// matches none of them fails immediately rather than retrying - there's
+// nothing a retry could change about which fields are set on it.
+func (d OCIFlexvolumeDriver) lookupNodeID(c client.Interface, k kubernetes.Interface, nodeName string) (string, error) {
+	strategies := c.GetConfig().NodeResolutionStrategies
+
+	var id string
+	attempts := 0
+	err := wait.PollImmediate(time.Second, CalloutBudget(), func() (bool, error) {
+		attempts++
+		n, err := k.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if err != nil {
+			if attempts >= NodeResolutionRetries() {
+				return false, err
+			}
+			return false, nil
+		}
+		resolved, err := resolveNodeID(c, strategies, n)
+		if err != nil {
+			return false, err
+		}
+		id = resolved
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return "", fmt.Errorf("timed out resolving node %q to a provider ID", nodeName)
+	}
 	if err != nil {
 		return "", err
 	}
-	if n.Spec.ProviderID == "" {
-		return "", errors.New("node is missing provider id")
-	}
-	return n.Spec.ProviderID, nil
+	return id, nil
 }
 
 // Attach initiates the attachment of the given OCI volume to the k8s worker
 // node.
+//
+// This only ever calls AttachVolume against the Block Volume API - there's
+// no equivalent here for an FSS export's access control (source CIDRs,
+// access=READ_ONLY, identity squash), since, per MountDevice's doc comment,
+// there's no FSS mount path in this driver for export options to flow
+// through to in the first place.
 func (d OCIFlexvolumeDriver) Attach(opts flexvolume.Options, nodeName string) flexvolume.DriverStatus {
-	c, err := client.New(GetConfigPath())
+	c, err := client.NewForServiceAccount(GetConfigPath(), opts[flexvolume.OptionKeyServiceAccountName])
 	if err != nil {
-		return flexvolume.Fail(err)
+		return failAPIError(err)
 	}
 
-	id, err := lookupNodeID(d.K, nodeName)
+	id, err := d.lookupNodeID(c, d.K, nodeName)
 	if err != nil {
 		return flexvolume.Fail(err)
 	}
 
 	instance, err := c.GetInstance(id)
 	if err != nil {
-		return flexvolume.Fail(err)
+		return failAPIError(err)
 	}
 
-	volumeOCID := deriveVolumeOCID(c.GetConfig().Auth.RegionKey, opts["kubernetes.io/pvOrVolumeName"])
+	pvOrVolumeName := opts["kubernetes.io/pvOrVolumeName"]
+	volumeOCID := DeriveVolumeOCID(c.GetConfig().Auth.RegionKey, pvOrVolumeName)
+	recordVolumePriority(pvOrVolumeName, opts[flexvolume.OptionCriticalVolume] == "true")
+
+	encryptInTransit := c.GetConfig().EncryptionInTransitEnabled
+	if v := opts[flexvolume.OptionEncryptInTransit]; v != "" {
+		encryptInTransit = v == "true"
+	}
+	readOnly := opts[flexvolume.OptionReadWrite] == "ro"
+	shareable := opts[flexvolume.OptionAttachmentAccessMode] == "shareable"
 
-	log.Printf("Attaching volume %s -> instance %s", volumeOCID, *instance.Id)
+	loglevel.Infof("Attaching volume %s -> instance %s", volumeOCID, *instance.Id)
 
-	attachment, statusCode, err := c.AttachVolume(*instance.Id, volumeOCID)
+	if DryRunEnabled() {
+		loglevel.Infof("dry-run: would call AttachVolume(instance=%s, volume=%s, encryptInTransit=%t, readOnly=%t, shareable=%t)", *instance.Id, volumeOCID, encryptInTransit, readOnly, shareable)
+		return flexvolume.Succeed("dry-run: no attachment performed")
+	}
+
+	attachment, statusCode, err := c.AttachVolume(*instance.Id, volumeOCID, encryptInTransit, readOnly, shareable)
 	if err != nil {
 		if statusCode != 409 {
-			log.Printf("AttachVolume: %+v", err)
-			return flexvolume.Fail(err)
+			loglevel.Errorf("AttachVolume: %+v", err)
+			return failAPIError(err)
 		}
 		// If we get a 409 conflict response when attaching we
 		// presume that the device is already attached.
-		log.Printf("Attach(): Volume %q already attached.", volumeOCID)
-		attachment, err = c.FindVolumeAttachment(volumeOCID)
+		loglevel.Infof("Attach(): Volume %q already attached.", volumeOCID)
+		attachment, err = c.FindVolumeAttachment(volumeOCID, *instance.Id)
 		if err != nil {
-			return flexvolume.Fail(err)
-		}
-		if *attachment.GetInstanceId() != *instance.Id {
-			return flexvolume.Fail("Already attached to instance: ", *instance.Id)
+			return failAPIError(err)
 		}
 	}
 
 	attachment, err = c.WaitForVolumeAttached(*attachment.GetId())
 	if err != nil {
-		return flexvolume.Fail(err)
+		return failAPIError(err)
 	}
 
-	log.Printf("attach: %s attached", *attachment.GetId())
+	loglevel.Infof("attach: %s attached", *attachment.GetId())
 	iscsiAttachment, ok := attachment.(core.IScsiVolumeAttachment)
 	if !ok {
 		return flexvolume.Fail("Only ISCSI volume attachments are currently supported")
@@ -228,45 +384,181 @@ func (d OCIFlexvolumeDriver) Attach(opts flexvolume.Options, nodeName string) fl
 
 	return flexvolume.DriverStatus{
 		Status: flexvolume.StatusSuccess,
-		Device: fmt.Sprintf(diskIDByPathTemplate, *iscsiAttachment.Ipv4, *iscsiAttachment.Port, *iscsiAttachment.Iqn),
+		Device: encodeAttachmentHandoff(attachmentHandoff{
+			Device:         fmt.Sprintf(diskIDByPathTemplate, *iscsiAttachment.Ipv4, *iscsiAttachment.Port, *iscsiAttachment.Iqn),
+			AttachmentID:   *attachment.GetId(),
+			AttachmentType: attachmentTypeISCSI,
+		}),
 	}
 }
 
 // Detach detaches the volume from the worker node.
+// Detach detaches the volume identified by pvOrVolumeName. If asynchronous
+// detach is enabled (see AsyncDetachEnabled), it instead enqueues the
+// request for a later "drain-detach-queue" admin command run to process,
+// returning success immediately - this is what keeps a mass eviction from
+// turning into a detach storm against the OCI API, at the cost of the
+// kubelet no longer being told when the detach itself actually finishes.
 func (d OCIFlexvolumeDriver) Detach(pvOrVolumeName, nodeName string) flexvolume.DriverStatus {
+	if AsyncDetachEnabled() {
+		req := detachqueue.Request{
+			PVOrVolumeName: pvOrVolumeName,
+			NodeName:       nodeName,
+			Critical:       isCriticalVolume(pvOrVolumeName),
+		}
+		if _, err := detachqueue.Enqueue(AsyncDetachDir(), req); err != nil {
+			return flexvolume.Fail(err)
+		}
+		return flexvolume.Succeed("detach queued for asynchronous processing")
+	}
+
+	return d.DetachNow(pvOrVolumeName, nodeName)
+}
+
+// DetachNow performs the detach synchronously, blocking until the OCI API
+// reports the volume detached. It's exported so the "drain-detach-queue"
+// admin command (see cmd/oci) can reuse it to process requests Detach
+// queued rather than performed itself.
+//
+// DetachNow is idempotent: a retry that finds no ATTACHING/ATTACHED
+// attachment left to detach succeeds rather than failing, so the KCM's
+// own retries don't spin forever against a volume that's already gone.
+func (d OCIFlexvolumeDriver) DetachNow(pvOrVolumeName, nodeName string) flexvolume.DriverStatus {
 	c, err := client.New(GetConfigPath())
 	if err != nil {
-		return flexvolume.Fail(err)
+		return failAPIError(err)
 	}
 
-	volumeOCID := deriveVolumeOCID(c.GetConfig().Auth.RegionKey, pvOrVolumeName)
-	attachment, err := c.FindVolumeAttachment(volumeOCID)
+	id, err := d.lookupNodeID(c, d.K, nodeName)
 	if err != nil {
 		return flexvolume.Fail(err)
 	}
 
+	volumeOCID := DeriveVolumeOCID(c.GetConfig().Auth.RegionKey, pvOrVolumeName)
+	attachment, err := c.FindVolumeAttachment(volumeOCID, id)
+	if err == client.ErrVolumeAttachmentNotFound {
+		// Already detached - e.g. DetachNow retried after a previous run
+		// got far enough to detach the volume but not to report success.
+		// The controller-manager expects Detach to be idempotent, and
+		// failing here would wedge teardown retrying a detach that's
+		// already done.
+		loglevel.Infof("DetachNow(): volume %q already detached.", volumeOCID)
+		clearVolumePriority(pvOrVolumeName)
+		return flexvolume.Succeed()
+	} else if err != nil {
+		return failAPIError(err)
+	}
+
+	if DryRunEnabled() {
+		loglevel.Infof("dry-run: would call DetachVolume(attachment=%s)", *attachment.GetId())
+		return flexvolume.Succeed("dry-run: no detachment performed")
+	}
+
 	err = c.DetachVolume(*attachment.GetId())
 	if err != nil {
-		return flexvolume.Fail(err)
+		return failAPIError(err)
 	}
 
 	err = c.WaitForVolumeDetached(*attachment.GetId())
 	if err != nil {
-		return flexvolume.Fail(err)
+		return failAPIError(err)
 	}
+
+	clearVolumePriority(pvOrVolumeName)
 	return flexvolume.Succeed()
 }
 
+// findAttachmentViaMetadata looks up the attachment details for the given
+// volume OCID using the node-local, credential-free "volume attachments"
+// instance metadata endpoint. This lets a worker node discover the
+// attachment itself rather than relying on the Device string handed off by
+// Attach(), which runs on the master and does have API credentials. The
+// metadata endpoint has no attachment OCID, only the volume's, so the
+// returned attachmentHandoff's AttachmentID is always empty.
+func findAttachmentViaMetadata(meta instancemeta.Interface, volumeOCID string) (attachmentHandoff, error) {
+	attachments, err := meta.GetVolumeAttachments()
+	if err != nil {
+		return attachmentHandoff{}, err
+	}
+
+	for _, a := range attachments {
+		if a.VolumeOCID != volumeOCID {
+			continue
+		}
+		if a.LifecycleState != "ATTACHED" {
+			return attachmentHandoff{}, fmt.Errorf("volume %q attachment found but in state %q", volumeOCID, a.LifecycleState)
+		}
+		return attachmentHandoff{
+			Device:         fmt.Sprintf(diskIDByPathTemplate, a.Ipv4, a.Port, a.Iqn),
+			AttachmentType: a.AttachmentType,
+		}, nil
+	}
+
+	return attachmentHandoff{}, fmt.Errorf("no volume attachment found for %q in instance metadata", volumeOCID)
+}
+
+// ErrStaleAttachment is returned by verifyAttachmentLive when a volume's
+// attachment is no longer live on this node, so MountDevice can refuse with
+// a specific, recognizable error instead of logging in to an iSCSI target
+// for a volume that's already been (or is being) detached.
+type ErrStaleAttachment struct {
+	VolumeOCID string
+	Err        error
+}
+
+func (e *ErrStaleAttachment) Error() string {
+	return fmt.Sprintf("refusing to mount %q: attachment is no longer live: %v", e.VolumeOCID, e.Err)
+}
+
+// verifyAttachmentLive confirms, via the node-local instance metadata
+// endpoint, that volumeOCID still has a live ATTACHED attachment on this
+// node, returning *ErrStaleAttachment if not. The kubelet replays the same
+// mountdevice call-out (with the same device path MountDevice was
+// originally handed off) on every subsequent mount attempt, including
+// after a Detach that failed partway through - without this check that
+// replay would log back in to a target this node no longer has a real
+// attachment for. A non-OCID volumeOCID is skipped rather than failed:
+// older PVs keyed by volume name alone predate this check having anything
+// to look up (see the ocidPrefix branches elsewhere in this file).
+func verifyAttachmentLive(meta instancemeta.Interface, volumeOCID string) error {
+	if !strings.HasPrefix(volumeOCID, ocidPrefix) {
+		return nil
+	}
+	if _, err := findAttachmentViaMetadata(meta, volumeOCID); err != nil {
+		return &ErrStaleAttachment{VolumeOCID: volumeOCID, Err: err}
+	}
+	return nil
+}
+
 // WaitForAttach searches for the the volume attachment created by Attach() and
 // waits for its life cycle state to reach ATTACHED.
-func (d OCIFlexvolumeDriver) WaitForAttach(mountDevice string, _ flexvolume.Options) flexvolume.DriverStatus {
+func (d OCIFlexvolumeDriver) WaitForAttach(mountDevice string, opts flexvolume.Options) flexvolume.DriverStatus {
+	volumeName := opts["kubernetes.io/pvOrVolumeName"]
+	if strings.HasPrefix(volumeName, ocidPrefix) {
+		if h, err := findAttachmentViaMetadata(instancemeta.New(), volumeName); err == nil {
+			return flexvolume.DriverStatus{
+				Status: flexvolume.StatusSuccess,
+				Device: encodeAttachmentHandoff(h),
+			}
+		}
+		// Fall through to the device string handed off by Attach() -- the
+		// metadata endpoint may not yet reflect a very recent attachment.
+	}
+
 	return flexvolume.DriverStatus{
 		Status: flexvolume.StatusSuccess,
 		Device: mountDevice,
 	}
 }
 
-// IsAttached checks whether the volume is attached to the host.
+// IsAttached checks whether the volume is attached to the host identified by
+// nodeName, not merely attached to some instance - it resolves nodeName to
+// an instance OCID and passes that to FindVolumeAttachment, which only
+// matches an attachment to that instance, so a volume that failed over to a
+// different node reports Attached=false here rather than a stale true.
+// The exception is a result cached within the last isAttachedCacheTTL (plus
+// jitter): the cache is keyed by volume OCID alone (see getCachedIsAttached),
+// so a cached result is returned without that lookup, whichever nodeName asks.
 // TODO(apryde): The documentation states that this is called from the Kubelet
 // and KCM. Implementation requries credentials which won't be present on nodes
 // but I've only ever seen it called by the KCM.
@@ -276,9 +568,34 @@ func (d OCIFlexvolumeDriver) IsAttached(opts flexvolume.Options, nodeName string
 		return flexvolume.Fail(err)
 	}
 
-	volumeOCID := deriveVolumeOCID(c.GetConfig().Auth.RegionKey, opts["kubernetes.io/pvOrVolumeName"])
-	attachment, err := c.FindVolumeAttachment(volumeOCID)
+	id, err := d.lookupNodeID(c, d.K, nodeName)
 	if err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	volumeOCID := DeriveVolumeOCID(c.GetConfig().Auth.RegionKey, opts["kubernetes.io/pvOrVolumeName"])
+	clock := d.getClock()
+
+	if attached, ok := getCachedIsAttached(clock, c.GetConfig(), volumeOCID); ok {
+		loglevel.Debugf("isattached: using cached result for %s: attached=%t", volumeOCID, attached)
+		return flexvolume.DriverStatus{
+			Status:   flexvolume.StatusSuccess,
+			Attached: attached,
+		}
+	}
+
+	attachment, err := c.FindVolumeAttachment(volumeOCID, id)
+	if err != nil {
+		// Only memoize a confirmed "not attached" - i.e. FindVolumeAttachment
+		// positively established the volume has no attachment. Any other
+		// error (a network blip, an exhausted withRetry budget on a 5xx, ...)
+		// is transient or unknown, and caching it as Attached=false would
+		// have the attach/detach controller act on stale state for up to
+		// isAttachedCacheTTL+jitter; skip the cache write so the next
+		// callout hits the real API again instead.
+		if err == client.ErrVolumeAttachmentNotFound {
+			setCachedIsAttached(clock, c.GetConfig(), volumeOCID, false)
+		}
 		return flexvolume.DriverStatus{
 			Status:   flexvolume.StatusSuccess,
 			Message:  err.Error(),
@@ -286,86 +603,393 @@ func (d OCIFlexvolumeDriver) IsAttached(opts flexvolume.Options, nodeName string
 		}
 	}
 
-	log.Printf("attach: found volume attachment %s", *attachment.GetId())
+	loglevel.Infof("attach: found volume attachment %s", *attachment.GetId())
 
+	setCachedIsAttached(clock, c.GetConfig(), volumeOCID, true)
 	return flexvolume.DriverStatus{
 		Status:   flexvolume.StatusSuccess,
 		Attached: true,
 	}
 }
 
+// loginWithRetries attempts to log in to the iSCSI target up to
+// IscsiLoginRetries times, or until the callout's retry budget is exhausted,
+// so that a single transient login failure doesn't fail the whole
+// MountDevice callout.
+func (d OCIFlexvolumeDriver) loginWithRetries(m iscsi.Interface) error {
+	clock := d.getClock()
+	deadline := d.calloutDeadline()
+	var lastErr error
+	for i := 0; i < IscsiLoginRetries(); i++ {
+		if lastErr = m.Login(); lastErr == nil {
+			return nil
+		}
+		if i == IscsiLoginRetries()-1 || !clock.Now().Before(deadline) {
+			break
+		}
+		clock.Sleep(time.Second)
+	}
+	if lastErr != nil {
+		if version, err := m.Version(); err == nil {
+			loglevel.Errorf("loginWithRetries: giving up after %d attempt(s) on iscsiadm version %s", IscsiLoginRetries(), version)
+		}
+	}
+	return lastErr
+}
+
+// ExpandVolume rescans the iSCSI session backing devicePath so the kernel
+// picks up a block volume resized at the OCI end, ahead of the kubelet
+// growing its filesystem to match with ExpandFS.
+func (d OCIFlexvolumeDriver) ExpandVolume(devicePath string, opts flexvolume.Options, newSize, oldSize string) flexvolume.DriverStatus {
+	iSCSIMounter, err := iscsi.NewFromDevicePath(devicePath)
+	if err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	loglevel.Infof("ExpandVolume: rescanning %q from %s to %s", devicePath, oldSize, newSize)
+	if err := iSCSIMounter.Rescan(); err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	return flexvolume.Succeed()
+}
+
+// ExpandFS grows the filesystem mounted from devicePath at deviceMountPath
+// to fill the underlying block device, which must already have been
+// rescanned to its new size by ExpandVolume.
+func (d OCIFlexvolumeDriver) ExpandFS(devicePath, deviceMountPath string, opts flexvolume.Options, newSize, oldSize string) flexvolume.DriverStatus {
+	iSCSIMounter, err := iscsi.NewFromDevicePath(devicePath)
+	if err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	loglevel.Infof("ExpandFS: resizing filesystem at %q from %s to %s", deviceMountPath, oldSize, newSize)
+	if err := iSCSIMounter.ResizeFS(devicePath, deviceMountPath); err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	return flexvolume.Succeed()
+}
+
 // MountDevice connects the iSCSI target on the k8s worker node before mounting
 // and (if necessary) formatting the disk.
+//
+// A PV that sets kubernetes.io/fsType: zfs gets a single-disk zpool (see
+// pkg/zfs) instead of a pkg/mount-formatted filesystem, for workloads that
+// want ZFS's snapshots and compression at the node level; every other
+// fsType still goes through the ordinary mkfs.<fstype>-based path below.
+//
+// This driver and its mount device path parsing (see iscsi.ParseDevicePath)
+// are otherwise iSCSI/block-volume specific throughout; there's no NFS
+// mount path for FSS file systems, the vendored oci-go-sdk has no
+// filestorage client package to create them with, and there's no
+// provisioner in this repo to create the export in the first place. FSS
+// support would need all three.
+//
+// That also rules out a userspace NFS client fallback for hosts missing
+// nfs-utils: there's no NFS mount attempt here for a missing client to be a
+// fallback for, and a preflight check or install hook for a package this
+// driver never shells out to would have nothing to guard. nfs-utils only
+// matters once an actual FSS mount path exists.
+//
+// It likewise rules out a per-PV NFS protocol version option (e.g. to pass
+// vers=4.1 for a tenancy that mandates NFSv4): there's no "vers=" mount
+// option to default or override here, since there's no NFS mount call here
+// at all. That option belongs next to whatever does eventually build the
+// FSS mount options, once the three gaps above are filled.
+//
+// Same for a PV option to pick among several FSS mount targets in an AD:
+// there's no filestorage client here to list mount targets with in the
+// first place, let alone a GetMountTargetForAD-style picker for such an
+// option to override.
+//
+// And for wiring kubernetes.io/readwrite: ro into an NFS "ro" mount option
+// or a READ_ONLY export: there's no read-only handling to uncomment here,
+// commented out or otherwise - mountOptionsFromOpts below already does the
+// read-only handling for every fsType that goes through FormatAndMount. The
+// zfs path creates or imports its pool without a read-only option.
+//
+// And for auto-creating a missing export from a path template: there's
+// nothing in this driver that looks an export up, so there's nothing that
+// could currently fail when one doesn't exist for it to create instead.
+//
+// And for failing over between a mount target's private IPs: there's no
+// privateIps[0] (or any other index) read anywhere in this driver for a
+// retry-the-others loop to wrap.
+//
+// And for a composite <filesystem-ocid>:<export-path> volume identifier:
+// DeriveVolumeOCID and every ocidPrefix check in this file assume a volume
+// name is a single block volume OCID (or a name to build one from) end to
+// end; there's no Claim() or export-path-aware parsing anywhere for a
+// composite ID to be split by.
 func (d OCIFlexvolumeDriver) MountDevice(mountDir, mountDevice string, opts flexvolume.Options) flexvolume.DriverStatus {
-	iSCSIMounter, err := iscsi.NewFromDevicePath(mountDevice)
+	handoff := decodeAttachmentHandoff(mountDevice)
+	if handoff.AttachmentType != "" && handoff.AttachmentType != attachmentTypeISCSI {
+		return flexvolume.Fail(fmt.Sprintf("unsupported attachment type %q: only %q attachments are currently supported", handoff.AttachmentType, attachmentTypeISCSI))
+	}
+	if handoff.AttachmentID != "" {
+		loglevel.Debugf("MountDevice: mounting attachment %s", handoff.AttachmentID)
+	}
+	mountDevice = handoff.Device
+
+	volumeOCID := opts["kubernetes.io/pvOrVolumeName"]
+	if err := verifyAttachmentLive(instancemeta.New(), volumeOCID); err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	iqn, ipv4, port, err := iscsi.ParseDevicePath(mountDevice)
 	if err != nil {
 		return flexvolume.Fail(err)
 	}
+	iSCSIMounter := iscsi.New(iqn, ipv4, port)
+
+	// If the volume name handed off to us is already the volume's OCID (see
+	// the ocidPrefix branch in WaitForAttach and Attach) we can key a udev
+	// rule off it, giving this mount a stable /dev/oci/<volume-ocid> device
+	// path that survives the kind of kernel device renaming that otherwise
+	// invalidates the by-path assumptions baked into diskIDByPathTemplate
+	// (e.g. its hardcoded "lun-1"). This is best effort: if it fails we fall
+	// back to mounting the raw by-path device exactly as before.
+	if strings.HasPrefix(volumeOCID, ocidPrefix) {
+		if stableDevice, uErr := udev.New().EnsureSymlink(volumeOCID, ipv4, port, iqn); uErr == nil {
+			mountDevice = stableDevice
+		} else {
+			loglevel.Warnf("MountDevice: could not set up stable device symlink for %q, falling back to %q: %v", volumeOCID, mountDevice, uErr)
+		}
+	}
 
 	if isMounted, oErr := iSCSIMounter.DeviceOpened(mountDevice); oErr != nil {
-		return flexvolume.Fail(oErr)
+		return failMountError(oErr)
 	} else if isMounted {
 		return flexvolume.Succeed("Device already mounted. Nothing to do.")
 	}
 
 	if err = iSCSIMounter.AddToDB(); err != nil {
-		return flexvolume.Fail(err)
+		return failMountError(err)
 	}
 	if err = iSCSIMounter.SetAutomaticLogin(); err != nil {
-		return flexvolume.Fail(err)
+		return failMountError(err)
 	}
-	if err = iSCSIMounter.Login(); err != nil {
-		return flexvolume.Fail(err)
+	if err = d.loginWithRetries(iSCSIMounter); err != nil {
+		return failMountError(err)
 	}
 
-	if !waitForPathToExist(mountDevice, 20) {
+	if !d.waitForPathToExist(mountDevice, AttachWaitRetries()) {
 		return flexvolume.Fail("Failed waiting for device to exist: ", mountDevice)
 	}
 
-	options := []string{}
-	if opts[flexvolume.OptionReadWrite] == "ro" {
-		options = []string{"ro"}
+	if opts[flexvolume.OptionFSType] == zfs.FSType {
+		poolName := zfsPoolName(iqn, ipv4, port)
+		if err := zfs.New().CreateOrImportPool(mountDevice, poolName, mountDir); err != nil {
+			return failMountError(err)
+		}
+
+		recordMountTracking(d.getClock(), mountDir, opts)
+
+		return flexvolume.Succeed()
 	}
-	err = iSCSIMounter.FormatAndMount(mountDevice, mountDir, opts[flexvolume.OptionFSType], options)
+
+	if opts[flexvolume.OptionVolumeMode] == volumeModeBlock {
+		if err = ensureRawBlockMountTarget(mountDir); err != nil {
+			return flexvolume.Fail(err)
+		}
+		if err = iSCSIMounter.MountRawBlock(mountDevice, mountDir); err != nil {
+			return failMountError(err)
+		}
+
+		recordMountTracking(d.getClock(), mountDir, opts)
+
+		return flexvolume.Succeed()
+	}
+
+	options := mountOptionsFromOpts(opts)
+	if opts[flexvolume.OptionDiscard] == "true" {
+		if supported, dErr := mount.DeviceSupportsDiscard(mountDevice); dErr != nil {
+			loglevel.Warnf("MountDevice: could not determine whether %q supports discard: %v", mountDevice, dErr)
+		} else if supported {
+			options = append(options, "discard")
+		} else {
+			loglevel.Warnf("MountDevice: discard requested for %q but the attached device doesn't support it", mountDevice)
+		}
+	}
+	fsckBeforeMount := opts[flexvolume.OptionFsckBeforeMount] == "true"
+	err = iSCSIMounter.FormatAndMount(mountDevice, mountDir, opts[flexvolume.OptionFSType], options, fsckBeforeMount)
 	if err != nil {
-		return flexvolume.Fail(err)
+		return failMountError(err)
 	}
 
+	recordMountTracking(d.getClock(), mountDir, opts)
+
 	return flexvolume.Succeed()
 }
 
+// mountOptionsFromOpts builds the mount option list FormatAndMount is
+// called with: "ro" when the PV is read-only, plus whatever the PV's
+// spec.mountOptions contributed via OptionMountOptions. There's no FSS/NFS
+// mount path in this driver for these to also flow into (see MountDevice's
+// doc comment) and raw block volumes (see volumeModeBlock above) have no
+// filesystem to mount options against, so FormatAndMount is the only
+// consumer.
+func mountOptionsFromOpts(opts flexvolume.Options) []string {
+	options := []string{}
+	if opts[flexvolume.OptionReadWrite] == "ro" {
+		options = append(options, "ro")
+	}
+	if mountOptions := opts[flexvolume.OptionMountOptions]; mountOptions != "" {
+		options = append(options, strings.Split(mountOptions, ",")...)
+	}
+	return options
+}
+
+// volumeModeBlock is the kubernetes.io/volumeMode value used for raw block
+// volumes (PersistentVolumeSpec.VolumeMode == corev1.PersistentVolumeBlock),
+// consumed without a filesystem.
+const volumeModeBlock = "Block"
+
+// ensureRawBlockMountTarget makes sure mountDir exists as an empty regular
+// file rather than a directory, since bind-mounting a block device node
+// requires the target to be the same kind of node as the source.
+func ensureRawBlockMountTarget(mountDir string) error {
+	if _, err := os.Stat(mountDir); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("checking raw block mount target %q: %v", mountDir, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(mountDir), 0750); err != nil {
+		return fmt.Errorf("creating parent directory of raw block mount target %q: %v", mountDir, err)
+	}
+
+	f, err := os.OpenFile(mountDir, os.O_CREATE|os.O_EXCL, 0660)
+	if err != nil {
+		return fmt.Errorf("creating raw block mount target %q: %v", mountDir, err)
+	}
+	return f.Close()
+}
+
 // UnmountDevice unmounts the disk, logs out the iscsi target, and deletes the
 // iscsi node record.
+//
+// A mount path holding a zpool MountDevice created (see zfs.FSType) is
+// recognised by PoolForMountpoint rather than by the iSCSI by-path lookup
+// below, since /proc/mounts records a zfs mount's source as the pool name,
+// not the underlying block device; zfsPoolName/parseZFSPoolName recover the
+// iSCSI target that pool name was built from so the rest of the teardown -
+// logging out and removing the node record - is identical either way.
 func (d OCIFlexvolumeDriver) UnmountDevice(mountPath string) flexvolume.DriverStatus {
-	iSCSIMounter, err := iscsi.NewFromMountPointPath(mountPath)
+	diskByPath, poolName, err := diskByPathForMountPoint(mountPath)
 	if err != nil {
 		if err == iscsi.ErrMountPointNotFound {
 			return flexvolume.Succeed("Mount point not found. Nothing to do.")
 		}
-		return flexvolume.Fail(err)
+		return failMountError(err)
+	}
+	iSCSIMounter, err := iscsi.NewFromDevicePath(diskByPath)
+	if err != nil {
+		return failMountError(err)
 	}
 
-	if err = iSCSIMounter.UnmountPath(mountPath); err != nil {
-		return flexvolume.Fail(err)
+	if err := udev.New().RemoveSymlinkForDevice(diskByPath); err != nil {
+		loglevel.Warnf("UnmountDevice: could not remove stable device symlink for %q: %v", diskByPath, err)
 	}
-	if err = iSCSIMounter.Logout(); err != nil {
-		return flexvolume.Fail(err)
+
+	gracePeriod := unmountGracePeriod()
+
+	// Each step below is attempted independently of whether an earlier one
+	// failed, so e.g. an unmount failure doesn't also leave the iSCSI session
+	// logged in: a retried UnmountDevice call has as little left to redo as
+	// possible. Their errors are aggregated rather than returned on first
+	// failure so the caller sees everything that went wrong, not just the
+	// first symptom.
+	var errs []error
+	if poolName != "" {
+		if err := zfs.New().ExportPool(poolName); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	if err = iSCSIMounter.RemoveFromDB(); err != nil {
-		return flexvolume.Fail(err)
+	if err := iSCSIMounter.UnmountPath(mountPath, gracePeriod); err != nil {
+		errs = append(errs, err)
+	}
+	if err := iSCSIMounter.Logout(); err != nil {
+		errs = append(errs, err)
 	}
+	if err := iSCSIMounter.RemoveFromDB(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return failMountError(err)
+	}
+
+	removeRawBlockMountTarget(mountPath)
+	removeMountTracking(mountPath)
 
 	return flexvolume.Succeed()
 }
 
-// Mount is unimplemented as we use the --enable-controller-attach-detach flow
-// and as such mount the drive in MountDevice().
+// removeRawBlockMountTarget removes the placeholder regular file
+// ensureRawBlockMountTarget created for a raw block mount, now that it has
+// been unmounted. A filesystem mount's mountPath is a directory the kubelet
+// created and still owns, so this only ever touches what MountDevice itself
+// put there: a directory mountPath (the filesystem case) is left alone, and
+// any other mountPath is removed.
+// Best effort: a failure here doesn't affect whether the volume counts as
+// unmounted.
+func removeRawBlockMountTarget(mountPath string) {
+	info, err := os.Stat(mountPath)
+	if err != nil || info.IsDir() {
+		return
+	}
+	if err := os.Remove(mountPath); err != nil {
+		loglevel.Warnf("UnmountDevice: could not remove raw block mount target %q: %v", mountPath, err)
+	}
+}
+
+// Mount implements the per-pod bind-mount flow used by a kubelet started
+// without --enable-controller-attach-detach: that kubelet calls Mount/Unmount
+// directly rather than driving the controller-manager's Attach/Detach
+// followed by its own WaitForAttach/MountDevice/UnmountDevice, so this just
+// runs all three of those steps itself, one after another, using the local
+// hostname in place of the node name a controller-manager callout would
+// otherwise have supplied.
 func (d OCIFlexvolumeDriver) Mount(mountDir string, opts flexvolume.Options) flexvolume.DriverStatus {
-	return flexvolume.NotSupported()
+	nodeName, err := os.Hostname()
+	if err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	attachStatus := d.Attach(opts, nodeName)
+	if attachStatus.Status != flexvolume.StatusSuccess {
+		return attachStatus
+	}
+
+	waitStatus := d.WaitForAttach(attachStatus.Device, opts)
+	if waitStatus.Status != flexvolume.StatusSuccess {
+		return waitStatus
+	}
+
+	mountStatus := d.MountDevice(mountDir, waitStatus.Device, opts)
+	if mountStatus.Status == flexvolume.StatusSuccess {
+		recordBindMountInfo(d.getClock(), mountDir, opts["kubernetes.io/pvOrVolumeName"], nodeName)
+	}
+	return mountStatus
 }
 
-// Unmount is unimplemented as we use the --enable-controller-attach-detach flow
-// and as such unmount the drive in UnmountDevice().
+// Unmount is Mount's mirror image: it runs UnmountDevice followed by Detach,
+// recovering the volume and node identity Mount recorded in mount tracking
+// (see recordBindMountInfo), since unlike Detach's normal
+// controller-manager caller, Unmount is given nothing but mountDir.
 func (d OCIFlexvolumeDriver) Unmount(mountDir string) flexvolume.DriverStatus {
-	return flexvolume.NotSupported()
+	pvOrVolumeName, nodeName, ok := bindMountInfo(mountDir)
+
+	unmountStatus := d.UnmountDevice(mountDir)
+	if unmountStatus.Status != flexvolume.StatusSuccess {
+		return unmountStatus
+	}
+
+	if !ok {
+		loglevel.Warnf("Unmount: no recorded volume/node identity for %q, skipping detach", mountDir)
+		return unmountStatus
+	}
+
+	return d.Detach(pvOrVolumeName, nodeName)
 }
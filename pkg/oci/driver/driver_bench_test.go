@@ -0,0 +1,55 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/instancemeta"
+)
+
+// BenchmarkDeriveVolumeOCID tracks the cost of the hot-path OCID derivation
+// performed on every Attach/Detach/IsAttached call.
+func BenchmarkDeriveVolumeOCID(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		DeriveVolumeOCID("phx", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	}
+}
+
+// BenchmarkFindAttachmentViaMetadata tracks the cost of the node-side
+// attachment lookup, including a worst-case linear scan of the returned
+// attachment list.
+func BenchmarkFindAttachmentViaMetadata(b *testing.B) {
+	attachments := make([]instancemeta.VolumeAttachment, 500)
+	for i := range attachments {
+		attachments[i] = instancemeta.VolumeAttachment{
+			VolumeOCID:     "ocid1.volume.oc1.phx.bbbbbb",
+			LifecycleState: "ATTACHED",
+			Iqn:            "iqn.2015-12.com.oracleiaas:bbbbbb",
+			Ipv4:           "169.254.2.2",
+			Port:           3260,
+		}
+	}
+	target := "ocid1.volume.oc1.phx.aaaaaa"
+	attachments[len(attachments)-1].VolumeOCID = target
+	meta := instancemeta.NewMockWithVolumeAttachments(&instancemeta.InstanceMetadata{}, attachments)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := findAttachmentViaMetadata(meta, target); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import "os"
+
+// DryRunEnabled reports whether Attach/Detach should resolve the target
+// instance and volume and log the OCI API calls they would make without
+// actually making them, overridable via OCI_FLEXD_DRY_RUN (and, in turn,
+// by this binary's -dry-run flag). Meant for validating a new cluster's
+// wiring - node lookup, volume OCID derivation, credentials - without
+// risking a real attach/detach against it.
+func DryRunEnabled() bool {
+	return os.Getenv("OCI_FLEXD_DRY_RUN") != ""
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+// withFileLock runs fn while holding an exclusive flock on path, creating it
+// first if it doesn't exist. It serializes the read-modify-write cycles the
+// isattached and volume-priority on-disk caches do around it, so that two
+// flexvolume callout processes racing to update different entries during a
+// KCM reconcile storm - the exact scenario those caches exist to absorb -
+// don't clobber each other's write instead of merging. A lock failure is
+// logged and treated as a no-op rather than an error: these caches are all
+// best-effort, so running fn unlocked just reopens the race it was meant to
+// close, not a correctness problem on its own.
+func withFileLock(path string, fn func()) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		loglevel.Warnf("filelock: failed to open %s: %v", path, err)
+		fn()
+		return
+	}
+	defer f.Close()
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		loglevel.Warnf("filelock: failed to lock %s: %v", path, err)
+		fn()
+		return
+	}
+	defer unix.Flock(int(f.Fd()), unix.LOCK_UN)
+
+	fn()
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+)
+
+// isAttachedCacheTTL is the base lifetime of a cached IsAttached() result.
+// Because each Flexvolume callout is a fresh process, the cache is persisted
+// to disk so that it is effective across the many IsAttached() invocations
+// a KCM reconcile loop makes for the same volume in quick succession.
+const isAttachedCacheTTL = 10 * time.Second
+
+// isAttachedCacheJitter is added on top of isAttachedCacheTTL so that cache
+// entries for many volumes written at roughly the same time don't all expire
+// in the same instant and cause a thundering herd of OCI calls.
+const isAttachedCacheJitter = 5 * time.Second
+
+// isAttachedCacheEntry is the cached result of a single IsAttached() lookup.
+type isAttachedCacheEntry struct {
+	Attached  bool      `json:"attached"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// isAttachedCacheFile is the on-disk shape of the cache. ConfigFingerprint
+// records the client.ConfigFingerprint the Entries were populated under, so
+// a later load under a different config (different tenancy, user or VCN)
+// can tell its entries don't apply rather than trust them anyway.
+type isAttachedCacheFile struct {
+	ConfigFingerprint string                          `json:"configFingerprint"`
+	Entries           map[string]isAttachedCacheEntry `json:"entries"`
+}
+
+// isAttachedCachePath returns the path of the on-disk IsAttached() result
+// cache, stored alongside the driver's other node-local state.
+func isAttachedCachePath() string {
+	return filepath.Join(GetConfigDirectory(), "isattached-cache.json")
+}
+
+// loadIsAttachedCacheFile reads the on-disk cache file as-is, with no
+// regard for which config fingerprint it was written under. A missing or
+// corrupt cache file is treated as an empty cache rather than an error,
+// since the cache is purely an optimisation.
+func loadIsAttachedCacheFile() isAttachedCacheFile {
+	empty := isAttachedCacheFile{Entries: make(map[string]isAttachedCacheEntry)}
+
+	b, err := ioutil.ReadFile(isAttachedCachePath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			loglevel.Warnf("isattached cache: failed to read cache: %v", err)
+		}
+		return empty
+	}
+
+	file := empty
+	if err := json.Unmarshal(b, &file); err != nil {
+		loglevel.Warnf("isattached cache: failed to unmarshal cache: %v", err)
+		return empty
+	}
+	if file.Entries == nil {
+		file.Entries = make(map[string]isAttachedCacheEntry)
+	}
+
+	return file
+}
+
+// loadIsAttachedCache reads the on-disk cache's entries, ignoring which
+// config fingerprint they were written under. Used by callers that only
+// ever inspect entries, not trust their Attached value for a particular
+// config - operator tooling's read-only snapshot view (IsAttachedSnapshot).
+// The janitor's pruning pass reads the whole file via loadIsAttachedCacheFile
+// instead, so that it can save it back.
+func loadIsAttachedCache() map[string]isAttachedCacheEntry {
+	return loadIsAttachedCacheFile().Entries
+}
+
+// IsAttachedSnapshot returns the current on-disk IsAttached() result cache,
+// keyed by volume OCID, as a watch.Source-compatible map. It's exported for
+// operator tooling (see cmd/oci's "watch" admin command) that wants to
+// observe attachment state changes without duplicating the on-disk format.
+func IsAttachedSnapshot() (map[string]interface{}, error) {
+	cache := loadIsAttachedCache()
+	snapshot := make(map[string]interface{}, len(cache))
+	for volumeOCID, entry := range cache {
+		snapshot[volumeOCID] = entry
+	}
+	return snapshot, nil
+}
+
+// saveIsAttachedCacheFile persists file to disk, best-effort.
+func saveIsAttachedCacheFile(file isAttachedCacheFile) {
+	b, err := json.Marshal(file)
+	if err != nil {
+		loglevel.Warnf("isattached cache: failed to marshal cache: %v", err)
+		return
+	}
+
+	if err := ioutil.WriteFile(isAttachedCachePath(), b, 0600); err != nil {
+		loglevel.Warnf("isattached cache: failed to write cache: %v", err)
+	}
+}
+
+// getCachedIsAttached returns the cached IsAttached() result for volumeOCID,
+// if one exists, hasn't yet expired according to clock, and was cached
+// under the same config fingerprint as config - see isAttachedCacheFile.
+func getCachedIsAttached(clock Clock, config *client.Config, volumeOCID string) (bool, bool) {
+	file := loadIsAttachedCacheFile()
+	if file.ConfigFingerprint != client.ConfigFingerprint(config) {
+		return false, false
+	}
+
+	entry, ok := file.Entries[volumeOCID]
+	if !ok || clock.Now().After(entry.ExpiresAt) {
+		return false, false
+	}
+	return entry.Attached, true
+}
+
+// setCachedIsAttached records attached as the result of looking up
+// volumeOCID under config, with a jittered expiry so that many entries
+// written together don't expire in lockstep. A cache written under a
+// different config fingerprint than config's is discarded first, rather
+// than mixed with entries from whatever tenancy/VCN populated it. The
+// load-modify-save cycle runs under withFileLock so that two callout
+// processes updating different volumes' entries at once - as happens during
+// a KCM reconcile storm - don't race and drop each other's write.
+func setCachedIsAttached(clock Clock, config *client.Config, volumeOCID string, attached bool) {
+	fingerprint := client.ConfigFingerprint(config)
+
+	withFileLock(isAttachedCachePath(), func() {
+		file := loadIsAttachedCacheFile()
+		if file.ConfigFingerprint != fingerprint {
+			loglevel.Infof("isattached cache: config fingerprint changed since the cache was last written; discarding %d cached entries", len(file.Entries))
+			file = isAttachedCacheFile{Entries: make(map[string]isAttachedCacheEntry)}
+		}
+
+		jitter := time.Duration(rand.Int63n(int64(isAttachedCacheJitter)))
+		file.ConfigFingerprint = fingerprint
+		file.Entries[volumeOCID] = isAttachedCacheEntry{
+			Attached:  attached,
+			ExpiresAt: clock.Now().Add(isAttachedCacheTTL + jitter),
+		}
+		saveIsAttachedCacheFile(file)
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+)
+
+func TestIsAttachedCacheRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "isattached-cache")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	clock := newFakeClock(time.Now())
+	config := &client.Config{Auth: client.AuthConfig{VcnOCID: "ocid1.vcn.oc1.phx.aaaaaa"}}
+
+	if _, ok := getCachedIsAttached(clock, config, "ocid1.volume.oc1.phx.aaaaaa"); ok {
+		t.Fatal("expected no cached result before any writes")
+	}
+
+	setCachedIsAttached(clock, config, "ocid1.volume.oc1.phx.aaaaaa", true)
+
+	attached, ok := getCachedIsAttached(clock, config, "ocid1.volume.oc1.phx.aaaaaa")
+	if !ok {
+		t.Fatal("expected cached result after write")
+	}
+	if !attached {
+		t.Error("expected cached attached=true")
+	}
+
+	if _, ok := getCachedIsAttached(clock, config, "ocid1.volume.oc1.phx.bbbbbb"); ok {
+		t.Error("expected no cached result for a different volume")
+	}
+}
+
+func TestIsAttachedCacheExpiry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "isattached-cache")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	config := &client.Config{Auth: client.AuthConfig{VcnOCID: "ocid1.vcn.oc1.phx.aaaaaa"}}
+
+	saveIsAttachedCacheFile(isAttachedCacheFile{
+		ConfigFingerprint: client.ConfigFingerprint(config),
+		Entries: map[string]isAttachedCacheEntry{
+			"ocid1.volume.oc1.phx.aaaaaa": {
+				Attached:  true,
+				ExpiresAt: time.Now().Add(-time.Minute),
+			},
+		},
+	})
+
+	if _, ok := getCachedIsAttached(newFakeClock(time.Now()), config, "ocid1.volume.oc1.phx.aaaaaa"); ok {
+		t.Error("expected expired cache entry to be treated as a miss")
+	}
+}
+
+func TestIsAttachedCacheInvalidatedByConfigChange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "isattached-cache")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	clock := newFakeClock(time.Now())
+	oldConfig := &client.Config{Auth: client.AuthConfig{VcnOCID: "ocid1.vcn.oc1.phx.aaaaaa"}}
+	newConfig := &client.Config{Auth: client.AuthConfig{VcnOCID: "ocid1.vcn.oc1.phx.bbbbbb"}}
+
+	setCachedIsAttached(clock, oldConfig, "ocid1.volume.oc1.phx.aaaaaa", true)
+
+	if _, ok := getCachedIsAttached(clock, newConfig, "ocid1.volume.oc1.phx.aaaaaa"); ok {
+		t.Error("expected a config/VCN change to invalidate the old config's cached entries")
+	}
+
+	setCachedIsAttached(clock, newConfig, "ocid1.volume.oc1.phx.cccccc", true)
+
+	if _, ok := getCachedIsAttached(clock, oldConfig, "ocid1.volume.oc1.phx.aaaaaa"); ok {
+		t.Error("expected the old config's entry to have been discarded once the cache was rewritten under the new config")
+	}
+}
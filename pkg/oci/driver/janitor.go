@@ -0,0 +1,148 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"os"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/detachqueue"
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/udev"
+)
+
+const defaultJanitorMaxAgeHours = 24
+
+// JanitorMaxAge returns the age past which the "janitor" admin command
+// considers a node-local state record abandoned, overridable via
+// OCI_FLEXD_JANITOR_MAX_AGE_HOURS.
+func JanitorMaxAge() time.Duration {
+	hours := intFromEnv("OCI_FLEXD_JANITOR_MAX_AGE_HOURS", defaultJanitorMaxAgeHours)
+	return time.Duration(hours) * time.Hour
+}
+
+// JanitorReport summarises what a single RunJanitor pass cleaned up.
+type JanitorReport struct {
+	ExpiredIsAttachedEntries  int
+	StaleMountTrackingEntries int
+	StaleDetachQueueEntries   int
+	StaleUdevSymlinks         int
+}
+
+// RunJanitor removes node-local state this driver has no other way of
+// cleaning up itself: IsAttached() cache entries past their expiry, mount
+// tracking entries whose mount directory no longer exists, detach queue
+// entries older than maxAge that a "drain-detach-queue" run has repeatedly
+// failed to process, and udev rules left behind by a node reboot or crash
+// between Detach() and the UnmountDevice() call that would otherwise have
+// removed them. now is passed in rather than using time.Now directly so the
+// admin command that calls this can be tested deterministically. It's meant
+// to be run periodically by a systemd timer (see drainDetachQueue for the
+// same convention), not left running as a daemon.
+func RunJanitor(now func() time.Time, maxAge time.Duration) JanitorReport {
+	var report JanitorReport
+
+	report.ExpiredIsAttachedEntries = cleanIsAttachedCache(now)
+	report.StaleMountTrackingEntries = cleanMountTracking(now, maxAge)
+	report.StaleDetachQueueEntries = cleanDetachQueue(now, maxAge)
+	report.StaleUdevSymlinks = cleanStaleUdevSymlinks()
+
+	return report
+}
+
+// cleanStaleUdevSymlinks removes udev rules whose /dev/oci/<volume-ocid>
+// symlink no longer resolves to a device - by-path scan residue a crashed
+// or rebooted node's UnmountDevice() never got to clean up itself. A
+// failure here is logged rather than returned: it shouldn't stop the rest
+// of a janitor pass from running.
+func cleanStaleUdevSymlinks() int {
+	removed, err := udev.New().PruneStaleSymlinks()
+	if err != nil {
+		loglevel.Warnf("janitor: pruning stale udev symlinks: %v", err)
+	}
+	return removed
+}
+
+// cleanIsAttachedCache removes every cache entry that has already expired,
+// so that a node with many volumes doesn't accumulate an ever-growing file
+// of cache entries nothing ever prunes (getCachedIsAttached only ignores
+// expired entries, it doesn't remove them).
+func cleanIsAttachedCache(now func() time.Time) int {
+	file := loadIsAttachedCacheFile()
+
+	removed := 0
+	for volumeOCID, entry := range file.Entries {
+		if now().After(entry.ExpiresAt) {
+			delete(file.Entries, volumeOCID)
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		saveIsAttachedCacheFile(file)
+	}
+	return removed
+}
+
+// cleanMountTracking removes mount tracking entries older than maxAge whose
+// mount directory no longer exists, which is how an entry becomes abandoned:
+// UnmountDevice() removes its own entry on the normal path, so a surviving
+// entry for a gone mount point means the node was rebooted, or crashed,
+// between the unmount and the matching removeMountTracking call.
+func cleanMountTracking(now func() time.Time, maxAge time.Duration) int {
+	entries := loadMountTracking()
+
+	removed := 0
+	for mountDir, entry := range entries {
+		if now().Sub(entry.MountedAt) < maxAge {
+			continue
+		}
+		if _, err := os.Stat(mountDir); err == nil {
+			continue
+		}
+		delete(entries, mountDir)
+		removed++
+	}
+
+	if removed > 0 {
+		saveMountTracking(entries)
+	}
+	return removed
+}
+
+// cleanDetachQueue removes detach queue entries whose on-disk file is older
+// than maxAge. A queue entry only survives this long if "drain-detach-queue"
+// has repeatedly failed to process it (see detachqueue.Drain), so by this
+// age it's either already detached out-of-band or stuck on a volume that no
+// longer exists; either way it's safe to drop rather than retry it forever.
+func cleanDetachQueue(now func() time.Time, maxAge time.Duration) int {
+	reqs, err := detachqueue.List(AsyncDetachDir())
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	for path := range reqs {
+		info, err := os.Stat(path)
+		if err != nil || now().Sub(info.ModTime()) < maxAge {
+			continue
+		}
+		if err := detachqueue.Remove(path); err != nil {
+			continue
+		}
+		removed++
+	}
+	return removed
+}
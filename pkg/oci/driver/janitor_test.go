@@ -0,0 +1,84 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/detachqueue"
+)
+
+func TestRunJanitor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janitor")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, env := range []string{"OCI_FLEXD_CONFIG_DIRECTORY", "OCI_FLEXD_ASYNC_DETACH_DIR"} {
+		original := os.Getenv(env)
+		defer os.Setenv(env, original)
+	}
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+	os.Setenv("OCI_FLEXD_ASYNC_DETACH_DIR", filepath.Join(dir, "detach-queue"))
+
+	now := time.Now()
+
+	// An expired isattached cache entry, which should be pruned regardless
+	// of maxAge.
+	saveIsAttachedCacheFile(isAttachedCacheFile{
+		Entries: map[string]isAttachedCacheEntry{
+			"ocid1.volume.oc1.phx.expired": {Attached: true, ExpiresAt: now.Add(-time.Minute)},
+		},
+	})
+
+	// A stale mount tracking entry whose mount directory no longer exists,
+	// and a fresh one that should be left alone.
+	saveMountTracking(map[string]mountTrackingEntry{
+		filepath.Join(dir, "gone"): {PodUID: "stale-pod", MountedAt: now.Add(-2 * time.Hour)},
+		filepath.Join(dir, "here"): {PodUID: "fresh-pod", MountedAt: now},
+	})
+	if err := os.MkdirAll(filepath.Join(dir, "here"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := detachqueue.Enqueue(AsyncDetachDir(), detachqueue.Request{PVOrVolumeName: "stale-volume", NodeName: "node-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := RunJanitor(func() time.Time { return now.Add(2 * time.Hour) }, time.Hour)
+
+	if report.ExpiredIsAttachedEntries != 1 {
+		t.Errorf("ExpiredIsAttachedEntries = %d, want 1", report.ExpiredIsAttachedEntries)
+	}
+	if report.StaleMountTrackingEntries != 1 {
+		t.Errorf("StaleMountTrackingEntries = %d, want 1", report.StaleMountTrackingEntries)
+	}
+	if report.StaleDetachQueueEntries != 1 {
+		t.Errorf("StaleDetachQueueEntries = %d, want 1", report.StaleDetachQueueEntries)
+	}
+
+	entries := loadMountTracking()
+	if _, ok := entries[filepath.Join(dir, "gone")]; ok {
+		t.Error("expected stale mount tracking entry to be removed")
+	}
+	if _, ok := entries[filepath.Join(dir, "here")]; !ok {
+		t.Error("expected fresh mount tracking entry to survive")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+// mountTrackingEntry records which pod a MountDevice() call was made on
+// behalf of, so that an incident responder can later answer "which pod is
+// using this volume" without having to correlate kubelet logs.
+type mountTrackingEntry struct {
+	PodUID       string    `json:"podUID"`
+	PodNamespace string    `json:"podNamespace"`
+	PodName      string    `json:"podName"`
+	MountedAt    time.Time `json:"mountedAt"`
+
+	// PVOrVolumeName and NodeName are set only for mounts made through the
+	// per-pod bind-mount flow (see OCIFlexvolumeDriver.Mount), which, unlike
+	// MountDevice, has no separate Detach call supplying them - so Unmount
+	// has to recover them from here instead. See recordBindMountInfo.
+	PVOrVolumeName string `json:"pvOrVolumeName,omitempty"`
+	NodeName       string `json:"nodeName,omitempty"`
+}
+
+// mountTrackingPath returns the path of the on-disk mount tracking file,
+// stored alongside the driver's other node-local state.
+func mountTrackingPath() string {
+	return filepath.Join(GetConfigDirectory(), "mount-tracking.json")
+}
+
+// loadMountTracking reads the on-disk mount tracking state. A missing or
+// corrupt file is treated as empty rather than an error, since this state is
+// purely informational.
+func loadMountTracking() map[string]mountTrackingEntry {
+	entries := make(map[string]mountTrackingEntry)
+
+	b, err := ioutil.ReadFile(mountTrackingPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			loglevel.Warnf("mount tracking: failed to read state: %v", err)
+		}
+		return entries
+	}
+
+	if err := json.Unmarshal(b, &entries); err != nil {
+		loglevel.Warnf("mount tracking: failed to unmarshal state: %v", err)
+		return make(map[string]mountTrackingEntry)
+	}
+
+	return entries
+}
+
+// MountSnapshot returns the current on-disk mount tracking state, keyed by
+// mount directory, as a watch.Source-compatible map. It's exported for
+// operator tooling (see cmd/oci's "watch" admin command) that wants to
+// observe mount state changes without duplicating the on-disk format.
+func MountSnapshot() (map[string]interface{}, error) {
+	entries := loadMountTracking()
+	snapshot := make(map[string]interface{}, len(entries))
+	for mountDir, entry := range entries {
+		snapshot[mountDir] = entry
+	}
+	return snapshot, nil
+}
+
+// saveMountTracking persists the mount tracking state to disk, best-effort.
+func saveMountTracking(entries map[string]mountTrackingEntry) {
+	b, err := json.Marshal(entries)
+	if err != nil {
+		loglevel.Warnf("mount tracking: failed to marshal state: %v", err)
+		return
+	}
+
+	if err := ioutil.WriteFile(mountTrackingPath(), b, 0600); err != nil {
+		loglevel.Warnf("mount tracking: failed to write state: %v", err)
+	}
+}
+
+// recordMountTracking records that mountDir is mounted on behalf of the pod
+// described by opts, keyed by mountDir so that UnmountDevice() can later
+// remove the entry using the same path the kubelet gives it. Pod identity is
+// best-effort: if the kubelet didn't supply OptionKeyPodUID (e.g. an older
+// kubelet), no entry is recorded.
+func recordMountTracking(clock Clock, mountDir string, opts flexvolume.Options) {
+	podUID := opts[flexvolume.OptionKeyPodUID]
+	if podUID == "" {
+		return
+	}
+
+	entries := loadMountTracking()
+	entries[mountDir] = mountTrackingEntry{
+		PodUID:       podUID,
+		PodNamespace: opts[flexvolume.OptionKeyPodNamespace],
+		PodName:      opts[flexvolume.OptionKeyPodName],
+		MountedAt:    clock.Now(),
+	}
+	saveMountTracking(entries)
+}
+
+// recordBindMountInfo augments the mount tracking entry for mountDir -
+// creating one if Mount's own call to MountDevice didn't record one, e.g.
+// because the kubelet omitted OptionKeyPodUID - with the volume and node
+// identity Mount() used to attach it, so a later Unmount() call can detach
+// the same volume from the same node.
+func recordBindMountInfo(clock Clock, mountDir, pvOrVolumeName, nodeName string) {
+	entries := loadMountTracking()
+	entry := entries[mountDir]
+	entry.PVOrVolumeName = pvOrVolumeName
+	entry.NodeName = nodeName
+	if entry.MountedAt.IsZero() {
+		entry.MountedAt = clock.Now()
+	}
+	entries[mountDir] = entry
+	saveMountTracking(entries)
+}
+
+// bindMountInfo returns the volume and node identity recordBindMountInfo
+// stored for mountDir, so Unmount() can detach what Mount() attached. ok is
+// false if Mount() was never called for mountDir, or predates this tracking.
+func bindMountInfo(mountDir string) (pvOrVolumeName, nodeName string, ok bool) {
+	entry, found := loadMountTracking()[mountDir]
+	if !found || entry.PVOrVolumeName == "" {
+		return "", "", false
+	}
+	return entry.PVOrVolumeName, entry.NodeName, true
+}
+
+// removeMountTracking removes any mount tracking entry for mountDir.
+func removeMountTracking(mountDir string) {
+	entries := loadMountTracking()
+	if _, ok := entries[mountDir]; !ok {
+		return
+	}
+	delete(entries, mountDir)
+	saveMountTracking(entries)
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+)
+
+func TestMountTrackingRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mount-tracking")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	clock := newFakeClock(time.Now())
+	opts := flexvolume.Options{
+		flexvolume.OptionKeyPodUID:       "pod-uid",
+		flexvolume.OptionKeyPodNamespace: "default",
+		flexvolume.OptionKeyPodName:      "my-pod",
+	}
+
+	recordMountTracking(clock, "/var/lib/kubelet/pods/pod-uid/volumes/vol1", opts)
+
+	entries := loadMountTracking()
+	entry, ok := entries["/var/lib/kubelet/pods/pod-uid/volumes/vol1"]
+	if !ok {
+		t.Fatal("expected a mount tracking entry after record")
+	}
+	if entry.PodUID != "pod-uid" || entry.PodNamespace != "default" || entry.PodName != "my-pod" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	removeMountTracking("/var/lib/kubelet/pods/pod-uid/volumes/vol1")
+
+	if _, ok := loadMountTracking()["/var/lib/kubelet/pods/pod-uid/volumes/vol1"]; ok {
+		t.Error("expected entry to be removed")
+	}
+}
+
+func TestBindMountInfoRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mount-tracking")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	if _, _, ok := bindMountInfo("/var/lib/kubelet/pods/pod-uid/volumes/vol1"); ok {
+		t.Fatal("expected no bind mount info before it's recorded")
+	}
+
+	recordBindMountInfo(newFakeClock(time.Now()), "/var/lib/kubelet/pods/pod-uid/volumes/vol1", "vol1", "node1")
+
+	pvOrVolumeName, nodeName, ok := bindMountInfo("/var/lib/kubelet/pods/pod-uid/volumes/vol1")
+	if !ok {
+		t.Fatal("expected bind mount info after record")
+	}
+	if pvOrVolumeName != "vol1" || nodeName != "node1" {
+		t.Errorf("got pvOrVolumeName=%q nodeName=%q, want vol1/node1", pvOrVolumeName, nodeName)
+	}
+
+	removeMountTracking("/var/lib/kubelet/pods/pod-uid/volumes/vol1")
+
+	if _, _, ok := bindMountInfo("/var/lib/kubelet/pods/pod-uid/volumes/vol1"); ok {
+		t.Error("expected bind mount info to be gone after removeMountTracking")
+	}
+}
+
+func TestRecordMountTrackingNoPodUID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mount-tracking")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	recordMountTracking(newFakeClock(time.Now()), "/mnt/vol", flexvolume.Options{})
+
+	if len(loadMountTracking()) != 0 {
+		t.Error("expected no entry to be recorded without a pod UID")
+	}
+}
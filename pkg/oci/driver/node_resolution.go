@@ -0,0 +1,109 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+)
+
+// resolveNodeID tries each of strategies against n in order, returning the
+// OCID from the first one that matches. A strategy "misses" (ok=false, err
+// nil) when the data it needs isn't present on n; resolveNodeID tries the
+// next strategy rather than failing outright. An error is a hard stop - the
+// lookup that strategy attempted itself failed, not merely come up empty.
+func resolveNodeID(c client.Interface, strategies []string, n *corev1.Node) (string, error) {
+	if len(strategies) == 0 {
+		strategies = client.DefaultNodeResolutionStrategies
+	}
+
+	for _, strategy := range strategies {
+		id, ok, err := resolveNodeIDByStrategy(c, strategy, n)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return id, nil
+		}
+	}
+
+	return "", fmt.Errorf("node %q matched none of the configured node resolution strategies (%v)", n.Name, strategies)
+}
+
+// resolveNodeIDByStrategy applies a single named strategy. See
+// client.NodeResolutionProviderID and its siblings for what each name
+// means.
+func resolveNodeIDByStrategy(c client.Interface, strategy string, n *corev1.Node) (id string, ok bool, err error) {
+	switch strategy {
+	case client.NodeResolutionProviderID:
+		if n.Spec.ProviderID == "" {
+			return "", false, nil
+		}
+		return n.Spec.ProviderID, true, nil
+
+	case client.NodeResolutionDisplayName:
+		instance, err := c.FindInstanceByDisplayName(n.Name)
+		if err != nil {
+			return "", false, err
+		}
+		if instance == nil {
+			return "", false, nil
+		}
+		return *instance.Id, true, nil
+
+	case client.NodeResolutionHostnameLabel, client.NodeResolutionPublicIP:
+		// client.ValidateConfig already rejects these two at config load
+		// time (see notImplementedNodeResolutionStrategies); getting here
+		// means a caller built a strategy list that skipped validation.
+		return "", false, fmt.Errorf("node resolution strategy %q is not implemented by this build", strategy)
+
+	case client.NodeResolutionPrivateIP:
+		return resolveByPrivateIP(c, n.Name)
+
+	default:
+		return "", false, fmt.Errorf("unknown node resolution strategy %q", strategy)
+	}
+}
+
+// resolveByPrivateIP resolves nodeName to an IP via DNS and matches it
+// against the private IP of an attached VNIC (see
+// client.FindInstanceByPrivateIP), for a cluster where the Kubernetes node
+// name is itself a resolvable hostname rather than the instance's display
+// name or providerID. A node name that doesn't resolve at all is a miss,
+// not an error - the node name could simply be something DNS has never
+// heard of, which a different configured strategy is then free to match
+// on instead.
+func resolveByPrivateIP(c client.Interface, nodeName string) (string, bool, error) {
+	ips, err := net.LookupHost(nodeName)
+	if err != nil {
+		return "", false, nil
+	}
+
+	for _, ip := range ips {
+		instance, err := c.FindInstanceByPrivateIP(ip)
+		if err != nil {
+			return "", false, err
+		}
+		if instance != nil {
+			return *instance.Id, true, nil
+		}
+	}
+
+	return "", false, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+)
+
+// displayNameOCIClient resolves FindInstanceByDisplayName against a fixed
+// in-memory set, for exercising NodeResolutionDisplayName without a real
+// OCI API call.
+type displayNameOCIClient struct {
+	client.Interface
+	instances map[string]string // display name -> OCID
+}
+
+func (f displayNameOCIClient) FindInstanceByDisplayName(displayName string) (*core.Instance, error) {
+	id, ok := f.instances[displayName]
+	if !ok {
+		return nil, nil
+	}
+	return &core.Instance{Id: &id}, nil
+}
+
+func TestResolveNodeIDProviderIDThenDisplayName(t *testing.T) {
+	c := displayNameOCIClient{instances: map[string]string{"node-1": "ocid1.instance.oc1.phx.bbbb"}}
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	id, err := resolveNodeID(c, []string{client.NodeResolutionProviderID, client.NodeResolutionDisplayName}, n)
+	if err != nil {
+		t.Fatalf("resolveNodeID() => %v; want nil", err)
+	}
+	if id != "ocid1.instance.oc1.phx.bbbb" {
+		t.Errorf("resolveNodeID() = %q; want %q", id, "ocid1.instance.oc1.phx.bbbb")
+	}
+}
+
+func TestResolveNodeIDNoStrategyMatches(t *testing.T) {
+	c := displayNameOCIClient{instances: map[string]string{}}
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	_, err := resolveNodeID(c, []string{client.NodeResolutionProviderID, client.NodeResolutionDisplayName}, n)
+	if err == nil {
+		t.Fatal("resolveNodeID() => nil error; want one when no strategy matches")
+	}
+	if !strings.Contains(err.Error(), "matched none") {
+		t.Errorf("resolveNodeID() error = %q; want it to say no strategy matched", err)
+	}
+}
+
+func TestResolveNodeIDByStrategyNotImplemented(t *testing.T) {
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	for _, strategy := range []string{client.NodeResolutionHostnameLabel, client.NodeResolutionPublicIP} {
+		_, ok, err := resolveNodeIDByStrategy(displayNameOCIClient{}, strategy, n)
+		if ok {
+			t.Errorf("resolveNodeIDByStrategy(%q) => ok=true; want false", strategy)
+		}
+		if err == nil {
+			t.Errorf("resolveNodeIDByStrategy(%q) => nil error; want one", strategy)
+		}
+	}
+}
+
+// privateIPOCIClient resolves FindInstanceByPrivateIP against a fixed
+// in-memory set, for exercising NodeResolutionPrivateIP without a real DNS
+// lookup or OCI API call.
+type privateIPOCIClient struct {
+	client.Interface
+	instances map[string]string // private IP -> OCID
+}
+
+func (f privateIPOCIClient) FindInstanceByPrivateIP(privateIP string) (*core.Instance, error) {
+	id, ok := f.instances[privateIP]
+	if !ok {
+		return nil, nil
+	}
+	return &core.Instance{Id: &id}, nil
+}
+
+func TestResolveByPrivateIPMatch(t *testing.T) {
+	c := privateIPOCIClient{instances: map[string]string{"127.0.0.1": "ocid1.instance.oc1.phx.cccc"}}
+
+	id, ok, err := resolveByPrivateIP(c, "localhost")
+	if err != nil {
+		t.Fatalf("resolveByPrivateIP() => %v; want nil", err)
+	}
+	if !ok {
+		t.Fatal("resolveByPrivateIP() => ok=false; want true")
+	}
+	if id != "ocid1.instance.oc1.phx.cccc" {
+		t.Errorf("resolveByPrivateIP() = %q; want %q", id, "ocid1.instance.oc1.phx.cccc")
+	}
+}
+
+func TestResolveByPrivateIPUnresolvableHostIsMiss(t *testing.T) {
+	c := privateIPOCIClient{instances: map[string]string{}}
+
+	_, ok, err := resolveByPrivateIP(c, "this-host-does-not-exist.invalid")
+	if err != nil {
+		t.Fatalf("resolveByPrivateIP() => %v; want nil error for an unresolvable name", err)
+	}
+	if ok {
+		t.Error("resolveByPrivateIP() => ok=true; want false for an unresolvable name")
+	}
+}
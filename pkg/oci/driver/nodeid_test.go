@@ -0,0 +1,73 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+)
+
+// fakeOCIClient satisfies client.Interface for the node resolution
+// strategies exercised here, which only ever call GetConfig - leaving
+// every other method to the nil embedded Interface's zero value is fine
+// as long as a test doesn't reach one that calls it.
+type fakeOCIClient struct {
+	client.Interface
+	config client.Config
+}
+
+func (f fakeOCIClient) GetConfig() *client.Config {
+	return &f.config
+}
+
+// TestLookupNodeIDUsesProviderID confirms lookupNodeID resolves a node to
+// its OCI instance from spec.providerID when that's the configured (or,
+// as here, default) strategy.
+func TestLookupNodeIDUsesProviderID(t *testing.T) {
+	k := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec:       corev1.NodeSpec{ProviderID: "ocid1.instance.oc1.phx.aaaa"},
+	})
+
+	d := OCIFlexvolumeDriver{}
+	id, err := d.lookupNodeID(fakeOCIClient{}, k, "node-1")
+	if err != nil {
+		t.Fatalf("lookupNodeID() => %v; want nil", err)
+	}
+	if id != "ocid1.instance.oc1.phx.aaaa" {
+		t.Errorf("lookupNodeID() = %q; want %q", id, "ocid1.instance.oc1.phx.aaaa")
+	}
+}
+
+func TestLookupNodeIDFailsFastWithoutProviderID(t *testing.T) {
+	k := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+	})
+
+	d := OCIFlexvolumeDriver{}
+	_, err := d.lookupNodeID(fakeOCIClient{}, k, "node-1")
+	if err == nil {
+		t.Fatal("lookupNodeID() => nil error; want one for a node matching no configured strategy")
+	}
+	if !strings.Contains(err.Error(), "matched none of the configured node resolution strategies") {
+		t.Errorf("lookupNodeID() error = %q; want it to explain no strategy matched", err)
+	}
+}
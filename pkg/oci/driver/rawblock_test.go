@@ -0,0 +1,59 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnsureRawBlockMountTargetCreatesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rawblock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "pods", "uid", "volumes", "dev")
+	if err := ensureRawBlockMountTarget(target); err != nil {
+		t.Fatalf("ensureRawBlockMountTarget() returned error: %v", err)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatalf("expected mount target to exist: %v", err)
+	}
+	if info.IsDir() {
+		t.Error("expected mount target to be a regular file, got a directory")
+	}
+}
+
+func TestEnsureRawBlockMountTargetIdempotent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rawblock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "dev")
+	if err := ensureRawBlockMountTarget(target); err != nil {
+		t.Fatalf("first call returned error: %v", err)
+	}
+	if err := ensureRawBlockMountTarget(target); err != nil {
+		t.Fatalf("second call returned error: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
+)
+
+// ReconcileReport summarises what a single Reconcile pass found and (unless
+// dryRun) detached.
+type ReconcileReport struct {
+	Checked  int
+	Orphaned int
+	Detached int
+	Errors   []error
+}
+
+// Reconcile lists every cluster node's OCI volume attachments and detaches
+// any with no corresponding entry in that node's status.volumesAttached,
+// cleaning up attachments left behind by a crashed node or a Detach call
+// that updated OCI but never made it back to the apiserver. It's meant to
+// be run periodically (see cmd/oci's "reconcile" admin command, suited to a
+// CronJob) rather than on every volume operation, since listing every
+// node's attachments is comparatively expensive.
+//
+// The correlation between an OCI attachment and a node's reported volumes
+// is a heuristic: a flexvolume's status.volumesAttached entry is a
+// kubelet-assigned unique name ending in "/<pvOrVolumeName>" - the same
+// name DeriveVolumeOCID builds a volume's OCID from - so an attachment is
+// treated as orphaned unless some reported name's final path segment
+// derives, via that same function, the attachment's own volume OCID. This
+// can't be made exact without either parsing that unique name format
+// (which isn't part of this driver's or even the flexvolume API's
+// contract) or a stored OCID-to-PV mapping this driver doesn't keep, but
+// it's conservative in the direction that matters: a false negative just
+// leaves an orphan for the next pass, while a false positive would detach
+// a volume still in use.
+func Reconcile(d *OCIFlexvolumeDriver, dryRun bool) (ReconcileReport, error) {
+	c, err := client.New(GetConfigPath())
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+
+	nodes, err := d.K.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return ReconcileReport{}, fmt.Errorf("listing nodes: %v", err)
+	}
+
+	regionKey := c.GetConfig().Auth.RegionKey
+
+	var report ReconcileReport
+	for _, node := range nodes.Items {
+		if err := reconcileNode(d, c, regionKey, node, dryRun, &report); err != nil {
+			report.Errors = append(report.Errors, fmt.Errorf("node %q: %v", node.Name, err))
+		}
+	}
+
+	return report, nil
+}
+
+// reconcileNode reconciles the attachments of a single node, accumulating
+// its results into report.
+func reconcileNode(d *OCIFlexvolumeDriver, c client.Interface, regionKey string, node corev1.Node, dryRun bool, report *ReconcileReport) error {
+	id, err := d.lookupNodeID(c, d.K, node.Name)
+	if err != nil {
+		return fmt.Errorf("resolving node: %v", err)
+	}
+
+	attachments, err := c.ListInstanceVolumeAttachments(c.GetConfig().Auth.CompartmentOCID, id)
+	if err != nil {
+		return fmt.Errorf("listing attachments: %v", err)
+	}
+
+	for _, a := range attachments {
+		volumeID := a.GetVolumeId()
+		attachmentID := a.GetId()
+		if volumeID == nil || attachmentID == nil {
+			continue
+		}
+
+		report.Checked++
+		if nodeKnowsVolume(node, regionKey, *volumeID) {
+			continue
+		}
+
+		report.Orphaned++
+		loglevel.Warnf("reconcile: %s attached to instance %s (node %q) has no corresponding entry in status.volumesAttached", *volumeID, id, node.Name)
+		if dryRun {
+			continue
+		}
+
+		if err := c.DetachVolume(*attachmentID); err != nil {
+			report.Errors = append(report.Errors, fmt.Errorf("detaching orphaned attachment %s (volume %s): %v", *attachmentID, *volumeID, err))
+			continue
+		}
+		report.Detached++
+	}
+
+	return nil
+}
+
+// nodeKnowsVolume reports whether node's status.volumesAttached has an
+// entry whose final path segment names the PV or volume that volumeID was
+// derived from - see Reconcile's doc comment for the matching heuristic.
+func nodeKnowsVolume(node corev1.Node, regionKey, volumeID string) bool {
+	for _, v := range node.Status.VolumesAttached {
+		name := string(v.Name)
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		if name != "" && DeriveVolumeOCID(regionKey, name) == volumeID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNodeKnowsVolume(t *testing.T) {
+	node := corev1.Node{
+		Status: corev1.NodeStatus{
+			VolumesAttached: []corev1.AttachedVolume{
+				{Name: "oracle/oci#/var/oracle~oci/pvc-abc123", DevicePath: "/dev/sdb"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		volumeID string
+		want     bool
+	}{
+		{"known volume", DeriveVolumeOCID("phx", "pvc-abc123"), true},
+		{"different region", DeriveVolumeOCID("iad", "pvc-abc123"), false},
+		{"unrelated volume", DeriveVolumeOCID("phx", "pvc-xyz789"), false},
+	}
+
+	for _, tt := range testCases {
+		if got := nodeKnowsVolume(node, "phx", tt.volumeID); got != tt.want {
+			t.Errorf("%s: nodeKnowsVolume() = %v; wanted %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNodeKnowsVolumeNoAttachments(t *testing.T) {
+	node := corev1.Node{}
+	if nodeKnowsVolume(node, "phx", DeriveVolumeOCID("phx", "pvc-abc123")) {
+		t.Error("nodeKnowsVolume() = true for a node with no reported attachments")
+	}
+}
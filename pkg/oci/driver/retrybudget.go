@@ -0,0 +1,92 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Default retry caps for the operation classes a flexvolume callout goes
+// through. Each can be overridden per-deployment via the environment
+// variables read by the accessor functions below, following this package's
+// existing OCI_FLEXD_* env var conventions (see GetConfigDirectory,
+// GetKubeconfigPath).
+const (
+	defaultNodeResolutionRetries     = 3
+	defaultAttachWaitRetries         = 20
+	defaultIscsiLoginRetries         = 3
+	defaultCalloutBudgetSeconds      = 120
+	defaultUnmountGracePeriodSeconds = 0
+)
+
+// NodeResolutionRetries returns the maximum number of attempts used to
+// resolve a node name to an OCID, overridable via
+// OCI_FLEXD_NODE_RESOLUTION_RETRIES.
+func NodeResolutionRetries() int {
+	return intFromEnv("OCI_FLEXD_NODE_RESOLUTION_RETRIES", defaultNodeResolutionRetries)
+}
+
+// AttachWaitRetries returns the maximum number of attempts used to wait for
+// an attached device to appear on disk, overridable via
+// OCI_FLEXD_ATTACH_WAIT_RETRIES.
+func AttachWaitRetries() int {
+	return intFromEnv("OCI_FLEXD_ATTACH_WAIT_RETRIES", defaultAttachWaitRetries)
+}
+
+// IscsiLoginRetries returns the maximum number of attempts used to log in to
+// an iSCSI target, overridable via OCI_FLEXD_ISCSI_LOGIN_RETRIES.
+func IscsiLoginRetries() int {
+	return intFromEnv("OCI_FLEXD_ISCSI_LOGIN_RETRIES", defaultIscsiLoginRetries)
+}
+
+// CalloutBudget returns the maximum wall-clock time a single flexvolume
+// callout may spend across all of its retryable operations, so that one slow
+// phase (e.g. node resolution) cannot consume the entire kubelet exec timeout
+// and starve the others. Overridable via OCI_FLEXD_CALLOUT_BUDGET_SECONDS.
+func CalloutBudget() time.Duration {
+	seconds := intFromEnv("OCI_FLEXD_CALLOUT_BUDGET_SECONDS", defaultCalloutBudgetSeconds)
+	return time.Duration(seconds) * time.Second
+}
+
+// unmountGracePeriod returns how long UnmountDevice retries a busy unmount
+// (logging which processes are holding it open, see mount.diagnoseBusyPath)
+// before escalating to a lazy unmount, overridable via
+// OCI_FLEXD_UNMOUNT_GRACE_PERIOD_SECONDS. Zero, the default, means no
+// escalation: a busy unmount fails on the first attempt, this driver's
+// long-standing behaviour.
+func unmountGracePeriod() time.Duration {
+	seconds := intFromEnv("OCI_FLEXD_UNMOUNT_GRACE_PERIOD_SECONDS", defaultUnmountGracePeriodSeconds)
+	return time.Duration(seconds) * time.Second
+}
+
+// calloutDeadline returns the point in time, relative to d's clock, at which
+// the current callout's retry budget is exhausted.
+func (d OCIFlexvolumeDriver) calloutDeadline() time.Time {
+	return d.getClock().Now().Add(CalloutBudget())
+}
+
+func intFromEnv(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i <= 0 {
+		return def
+	}
+	return i
+}
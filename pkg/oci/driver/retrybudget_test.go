@@ -0,0 +1,44 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIntFromEnvDefaultsAndOverrides(t *testing.T) {
+	const name = "OCI_FLEXD_TEST_RETRIES"
+	defer os.Unsetenv(name)
+
+	if got := intFromEnv(name, 5); got != 5 {
+		t.Errorf("expected default of 5 when unset, got %d", got)
+	}
+
+	os.Setenv(name, "9")
+	if got := intFromEnv(name, 5); got != 9 {
+		t.Errorf("expected override of 9, got %d", got)
+	}
+
+	os.Setenv(name, "not-a-number")
+	if got := intFromEnv(name, 5); got != 5 {
+		t.Errorf("expected fall back to default on invalid value, got %d", got)
+	}
+
+	os.Setenv(name, "-1")
+	if got := intFromEnv(name, 5); got != 5 {
+		t.Errorf("expected fall back to default on non-positive value, got %d", got)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+const defaultUsageAlertThresholdPercent = 85
+
+// UsageAlertThresholdPercent returns the filesystem utilization, as a
+// percentage, at or past which the "check-volume-usage" admin command
+// raises a high-water alert, overridable via
+// OCI_FLEXD_USAGE_ALERT_THRESHOLD_PERCENT.
+func UsageAlertThresholdPercent() int {
+	return intFromEnv("OCI_FLEXD_USAGE_ALERT_THRESHOLD_PERCENT", defaultUsageAlertThresholdPercent)
+}
+
+// VolumeUsageAlertReason is the Event reason used for every Event
+// EmitVolumeUsageEvents creates, so alerting rules can match on it.
+const VolumeUsageAlertReason = "VolumeUsageHighWaterMark"
+
+// VolumeUsage reports a mount directory's filesystem utilization and the pod
+// it was mounted on behalf of, from this node's mount tracking state (see
+// mount_tracking.go).
+type VolumeUsage struct {
+	MountDir     string
+	PodUID       string
+	PodNamespace string
+	PodName      string
+	UsedPercent  int
+}
+
+// CheckVolumeUsage statfs's every mount directory this node's mount tracking
+// state knows about and returns the ones at or past thresholdPercent
+// utilization, sorted by mount directory for deterministic output. A mount
+// directory that's disappeared or can't be statfs'd (e.g. a stale entry left
+// behind by a crash - see RunJanitor, which is what actually cleans these
+// up) is skipped rather than failing the whole check.
+//
+// This only covers driver-managed mounts: flexvolume has no CSI-style
+// NodeGetVolumeStats call the kubelet polls on our behalf, so this driver
+// has to measure usage itself, from whatever node-local state it already
+// keeps about its own mounts, rather than being handed it.
+func CheckVolumeUsage(thresholdPercent int) []VolumeUsage {
+	entries := loadMountTracking()
+
+	mountDirs := make([]string, 0, len(entries))
+	for mountDir := range entries {
+		mountDirs = append(mountDirs, mountDir)
+	}
+	sort.Strings(mountDirs)
+
+	var highWater []VolumeUsage
+	for _, mountDir := range mountDirs {
+		percent, err := diskUsagePercent(mountDir)
+		if err != nil {
+			loglevel.Warnf("usage alert: failed to check usage of %q: %v", mountDir, err)
+			continue
+		}
+		if percent < thresholdPercent {
+			continue
+		}
+
+		entry := entries[mountDir]
+		highWater = append(highWater, VolumeUsage{
+			MountDir:     mountDir,
+			PodUID:       entry.PodUID,
+			PodNamespace: entry.PodNamespace,
+			PodName:      entry.PodName,
+			UsedPercent:  percent,
+		})
+	}
+	return highWater
+}
+
+// EmitVolumeUsageEvents creates a Warning Event, reason
+// VolumeUsageAlertReason, against each usage's pod, so that a high-water
+// mount shows up in "kubectl describe pod" and to any event-driven
+// alerting a cluster already has, the same way a CSI volume's
+// NodeGetVolumeStats-derived condition would. A usage with no PodUID (the
+// kubelet didn't supply one on mount, see recordMountTracking) is skipped,
+// since there is then no object to attach the Event to. It returns the
+// number of Events it successfully created.
+func EmitVolumeUsageEvents(k kubernetes.Interface, usages []VolumeUsage, now time.Time) int {
+	emitted := 0
+	for _, usage := range usages {
+		if usage.PodUID == "" {
+			loglevel.Warnf("usage alert: %q is at %d%% but has no tracked pod; skipping event", usage.MountDir, usage.UsedPercent)
+			continue
+		}
+
+		event := &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "oci-volume-usage-",
+				Namespace:    usage.PodNamespace,
+			},
+			InvolvedObject: corev1.ObjectReference{
+				Kind:      "Pod",
+				Namespace: usage.PodNamespace,
+				Name:      usage.PodName,
+				UID:       types.UID(usage.PodUID),
+			},
+			Reason:         VolumeUsageAlertReason,
+			Message:        fmt.Sprintf("volume mounted at %s is %d%% full", usage.MountDir, usage.UsedPercent),
+			Type:           corev1.EventTypeWarning,
+			Source:         corev1.EventSource{Component: "oci-flexvolume-driver"},
+			FirstTimestamp: metav1.NewTime(now),
+			LastTimestamp:  metav1.NewTime(now),
+			Count:          1,
+		}
+
+		if _, err := k.CoreV1().Events(usage.PodNamespace).Create(event); err != nil {
+			loglevel.Warnf("usage alert: failed to create event for pod %s/%s: %v", usage.PodNamespace, usage.PodName, err)
+			continue
+		}
+		emitted++
+	}
+	return emitted
+}
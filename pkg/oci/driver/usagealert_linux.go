@@ -0,0 +1,40 @@
+// +build linux
+
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// diskUsagePercent returns the percentage of path's filesystem currently in
+// use, rounded down, via statfs(2). Blocks reserved for the root user
+// (Bfree - Bavail) count as used: a driver-managed volume filling up is what
+// this is meant to catch, and those blocks aren't available to the workload
+// writing to it regardless of who could free them.
+func diskUsagePercent(path string) (int, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, fmt.Errorf("statfs %q: %v", path, err)
+	}
+	if stat.Blocks == 0 {
+		return 0, fmt.Errorf("statfs %q: reported zero total blocks", path)
+	}
+
+	used := stat.Blocks - stat.Bavail
+	return int(used * 100 / stat.Blocks), nil
+}
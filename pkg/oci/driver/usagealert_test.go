@@ -0,0 +1,98 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckVolumeUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "usagealert")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	// dir itself is always going to be well under 100% full in a test
+	// environment, so this only exercises that a mount directory with no
+	// tracking entry (never recorded) or whose entry's directory doesn't
+	// exist is skipped, rather than asserting on a real usage percentage.
+	saveMountTracking(map[string]mountTrackingEntry{
+		dir: {PodUID: "pod-1", PodNamespace: "default", PodName: "my-pod"},
+		os.TempDir() + "/usagealert-does-not-exist": {PodUID: "pod-2"},
+	})
+
+	// With an unreachable threshold, nothing should ever be reported,
+	// including for the tracked-but-missing mount directory above.
+	if got := CheckVolumeUsage(101); len(got) != 0 {
+		t.Errorf("CheckVolumeUsage(101) = %+v; want none", got)
+	}
+
+	// With threshold 0, every mount directory that still exists and can be
+	// statfs'd is reported, regardless of how full it actually is.
+	got := CheckVolumeUsage(0)
+	if len(got) != 1 {
+		t.Fatalf("CheckVolumeUsage(0) = %+v; want exactly one entry for %q", got, dir)
+	}
+	if got[0].MountDir != dir {
+		t.Errorf("CheckVolumeUsage(0)[0].MountDir = %q; want %q", got[0].MountDir, dir)
+	}
+	if got[0].PodUID != "pod-1" || got[0].PodNamespace != "default" || got[0].PodName != "my-pod" {
+		t.Errorf("CheckVolumeUsage(0)[0] = %+v; want pod identity carried over from mount tracking", got[0])
+	}
+}
+
+func TestEmitVolumeUsageEvents(t *testing.T) {
+	now := time.Now()
+	usages := []VolumeUsage{
+		{MountDir: "/var/lib/kubelet/.../mount-a", PodUID: "pod-1", PodNamespace: "default", PodName: "my-pod", UsedPercent: 92},
+		// No PodUID: there's nothing to attach an event to, so this should
+		// be skipped rather than erroring the whole batch out.
+		{MountDir: "/var/lib/kubelet/.../mount-b", UsedPercent: 99},
+	}
+
+	k := fake.NewSimpleClientset()
+	emitted := EmitVolumeUsageEvents(k, usages, now)
+
+	if emitted != 1 {
+		t.Fatalf("EmitVolumeUsageEvents() = %d; want 1", emitted)
+	}
+
+	events, err := k.CoreV1().Events("default").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List(events) => %v", err)
+	}
+	if len(events.Items) != 1 {
+		t.Fatalf("len(events.Items) = %d; want 1", len(events.Items))
+	}
+
+	event := events.Items[0]
+	if event.Reason != VolumeUsageAlertReason {
+		t.Errorf("event.Reason = %q; want %q", event.Reason, VolumeUsageAlertReason)
+	}
+	if event.InvolvedObject.Name != "my-pod" || event.InvolvedObject.UID != "pod-1" {
+		t.Errorf("event.InvolvedObject = %+v; want pod-1/my-pod", event.InvolvedObject)
+	}
+}
@@ -0,0 +1,26 @@
+// +build !linux
+
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import "fmt"
+
+// diskUsagePercent is unsupported outside Linux: this driver only ever runs
+// on Linux nodes, same as pkg/mount's mount_unsupported.go, but the build
+// still needs to type-check on other platforms.
+func diskUsagePercent(path string) (int, error) {
+	return 0, fmt.Errorf("diskUsagePercent: not supported on this platform")
+}
@@ -21,9 +21,12 @@ import (
 
 const waitForPathDelay = 1 * time.Second
 
-// waitForPathToExist waits for for a given filesystem path to exist.
-func waitForPathToExist(path string, maxRetries int) bool {
+// waitForPathToExist waits for for a given filesystem path to exist, using
+// d's injected Clock rather than reaching for time.Sleep directly so that
+// callers can drive it deterministically in tests.
+func (d OCIFlexvolumeDriver) waitForPathToExist(path string, maxRetries int) bool {
 	// TODO: Replace with "k8s.io/apimachinery/pkg/util/wait".
+	clock := d.getClock()
 	for i := 0; i < maxRetries; i++ {
 		var err error
 		_, err = os.Stat(path)
@@ -36,7 +39,7 @@ func waitForPathToExist(path string, maxRetries int) bool {
 		if i == maxRetries-1 {
 			break
 		}
-		time.Sleep(waitForPathDelay)
+		clock.Sleep(waitForPathDelay)
 	}
 	return false
 }
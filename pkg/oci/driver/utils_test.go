@@ -0,0 +1,36 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitForPathToExistWithFakeClock(t *testing.T) {
+	d := OCIFlexvolumeDriver{Clock: newFakeClock(time.Now())}
+
+	if d.waitForPathToExist("/path/that/does/not/exist", 3) {
+		t.Error("expected waitForPathToExist() to return false for a nonexistent path")
+	}
+}
+
+func TestWaitForPathToExistExisting(t *testing.T) {
+	d := OCIFlexvolumeDriver{Clock: newFakeClock(time.Now())}
+
+	if !d.waitForPathToExist("/", 3) {
+		t.Error("expected waitForPathToExist() to return true immediately for an existing path")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+)
+
+// volumePriorityPath returns the path of the on-disk record of which
+// volumes were attached with OptionCriticalVolume set, stored alongside the
+// driver's other node-local state.
+func volumePriorityPath() string {
+	return filepath.Join(GetConfigDirectory(), "volume-priority.json")
+}
+
+// loadVolumePriority reads the on-disk critical-volume record. A missing or
+// corrupt file is treated as empty rather than an error, since this state is
+// purely an optimisation: losing it just means a volume detaches without
+// priority, not that the detach fails.
+func loadVolumePriority() map[string]bool {
+	entries := make(map[string]bool)
+
+	b, err := ioutil.ReadFile(volumePriorityPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			loglevel.Warnf("volume priority: failed to read state: %v", err)
+		}
+		return entries
+	}
+
+	if err := json.Unmarshal(b, &entries); err != nil {
+		loglevel.Warnf("volume priority: failed to unmarshal state: %v", err)
+		return make(map[string]bool)
+	}
+
+	return entries
+}
+
+// saveVolumePriority persists the critical-volume record to disk, best-effort.
+func saveVolumePriority(entries map[string]bool) {
+	b, err := json.Marshal(entries)
+	if err != nil {
+		loglevel.Warnf("volume priority: failed to marshal state: %v", err)
+		return
+	}
+
+	if err := ioutil.WriteFile(volumePriorityPath(), b, 0600); err != nil {
+		loglevel.Warnf("volume priority: failed to write state: %v", err)
+	}
+}
+
+// recordVolumePriority records whether pvOrVolumeName was attached with
+// OptionCriticalVolume set, so that Detach() - which the Flexvolume protocol
+// gives no options to - can still tell the async detach queue to prioritize
+// it. Non-critical volumes aren't recorded at all, keeping the file limited
+// to the volumes that actually need the lookup. The load-modify-save cycle
+// runs under withFileLock so that concurrent callouts recording or clearing
+// different volumes' priority don't race and drop each other's write.
+func recordVolumePriority(pvOrVolumeName string, critical bool) {
+	if !critical {
+		return
+	}
+
+	withFileLock(volumePriorityPath(), func() {
+		entries := loadVolumePriority()
+		entries[pvOrVolumeName] = true
+		saveVolumePriority(entries)
+	})
+}
+
+// isCriticalVolume reports whether pvOrVolumeName was last attached with
+// OptionCriticalVolume set.
+func isCriticalVolume(pvOrVolumeName string) bool {
+	return loadVolumePriority()[pvOrVolumeName]
+}
+
+// clearVolumePriority removes any critical-volume record for pvOrVolumeName,
+// once its detach has actually been processed.
+func clearVolumePriority(pvOrVolumeName string) {
+	withFileLock(volumePriorityPath(), func() {
+		entries := loadVolumePriority()
+		if _, ok := entries[pvOrVolumeName]; !ok {
+			return
+		}
+		delete(entries, pvOrVolumeName)
+		saveVolumePriority(entries)
+	})
+}
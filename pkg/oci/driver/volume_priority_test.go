@@ -0,0 +1,63 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestVolumePriorityRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "volume-priority")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	recordVolumePriority("critical-pv", true)
+
+	if !isCriticalVolume("critical-pv") {
+		t.Error("expected critical-pv to be recorded as critical")
+	}
+
+	clearVolumePriority("critical-pv")
+
+	if isCriticalVolume("critical-pv") {
+		t.Error("expected entry to be removed")
+	}
+}
+
+func TestRecordVolumePriorityNotCritical(t *testing.T) {
+	dir, err := ioutil.TempDir("", "volume-priority")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_FLEXD_CONFIG_DIRECTORY")
+	defer os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", original)
+	os.Setenv("OCI_FLEXD_CONFIG_DIRECTORY", dir)
+
+	recordVolumePriority("normal-pv", false)
+
+	if len(loadVolumePriority()) != 0 {
+		t.Error("expected no entry to be recorded for a non-critical volume")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/iscsi"
+	"github.com/oracle/oci-flexvolume-driver/pkg/zfs"
+)
+
+// zfsPoolNamePrefix starts every pool name this driver creates, so a zpool
+// list showing pools from something else on the node is obviously not ours.
+const zfsPoolNamePrefix = "flexd-"
+
+// zfsTargetPattern matches the IPv4, port and IQN zfsPoolName hex-encodes
+// into a pool name, mirroring iscsi.ParseDevicePath's by-path pattern.
+var zfsTargetPattern = regexp.MustCompile(`^(?P<IPv4>[\w\.]+):(?P<Port>\d+)-(?P<IQN>.+)$`)
+
+// zfsPoolName deterministically names the zpool MountDevice creates or
+// imports on an iSCSI device, from that device's target IQN/IPv4/port
+// rather than a random or counter-based name, so that UnmountDevice -
+// which the kubelet only ever calls with a mount path, never the original
+// MountDevice opts - can recover which iSCSI session to log out of once it
+// has looked the pool name back up via zfs.Interface.PoolForMountpoint.
+//
+// zpool names must start with a letter and may otherwise only contain
+// alphanumerics and "_.:-", which an IQN isn't guaranteed to stick to (it
+// allows arbitrary UTF-8 after the first few fields), so the target is hex
+// encoded rather than embedded verbatim.
+func zfsPoolName(iqn, ipv4 string, port int) string {
+	return zfsPoolNamePrefix + hex.EncodeToString([]byte(fmt.Sprintf("%s:%d-%s", ipv4, port, iqn)))
+}
+
+// parseZFSPoolName reverses zfsPoolName, extracting the iSCSI target it was
+// built from. It returns an error for any pool name this driver didn't
+// create, e.g. one left over from a manual zpool create on the node.
+func parseZFSPoolName(poolName string) (iqn, ipv4 string, port int, err error) {
+	hexPart := poolName
+	if len(hexPart) < len(zfsPoolNamePrefix) || hexPart[:len(zfsPoolNamePrefix)] != zfsPoolNamePrefix {
+		return "", "", 0, fmt.Errorf("zfs pool name %q was not created by this driver", poolName)
+	}
+	hexPart = hexPart[len(zfsPoolNamePrefix):]
+
+	decoded, err := hex.DecodeString(hexPart)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("zfs pool name %q did not decode as hex: %v", poolName, err)
+	}
+
+	m := zfsTargetPattern.FindStringSubmatch(string(decoded))
+	if m == nil {
+		return "", "", 0, fmt.Errorf("zfs pool name %q decoded to an unrecognised target %q", poolName, decoded)
+	}
+
+	var portNum int
+	if _, err := fmt.Sscanf(m[2], "%d", &portNum); err != nil {
+		return "", "", 0, fmt.Errorf("zfs pool name %q had an unparseable port %q: %v", poolName, m[2], err)
+	}
+
+	return m[3], m[1], portNum, nil
+}
+
+// diskByPathForMountPoint returns the iSCSI by-path device mounted at
+// mountPath, for UnmountDevice - trying a zfs pool first (see UnmountDevice's
+// doc comment for why /proc/mounts alone can't tell the two cases apart)
+// and falling back to the ordinary by-path lookup otherwise. When mountPath
+// holds a zpool, poolName is also returned so the caller can export it;
+// it's empty for an ordinary filesystem mount.
+func diskByPathForMountPoint(mountPath string) (diskByPath, poolName string, err error) {
+	if pool, zErr := zfs.New().PoolForMountpoint(mountPath); zErr == nil {
+		iqn, ipv4, port, pErr := parseZFSPoolName(pool)
+		if pErr != nil {
+			return "", "", pErr
+		}
+		return fmt.Sprintf(diskIDByPathTemplate, ipv4, port, iqn), pool, nil
+	}
+
+	diskByPath, err = iscsi.DiskByPathForMountPointPath(mountPath)
+	return diskByPath, "", err
+}
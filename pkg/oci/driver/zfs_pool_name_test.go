@@ -0,0 +1,39 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import "testing"
+
+func TestZFSPoolNameRoundTrip(t *testing.T) {
+	wantIQN := "iqn.2015-12.com.oracleiaas:aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
+	wantIPv4 := "169.254.2.2"
+	wantPort := 3260
+
+	poolName := zfsPoolName(wantIQN, wantIPv4, wantPort)
+
+	iqn, ipv4, port, err := parseZFSPoolName(poolName)
+	if err != nil {
+		t.Fatalf("parseZFSPoolName(%q) = %v", poolName, err)
+	}
+	if iqn != wantIQN || ipv4 != wantIPv4 || port != wantPort {
+		t.Errorf("parseZFSPoolName(%q) = (%q, %q, %d); want (%q, %q, %d)", poolName, iqn, ipv4, port, wantIQN, wantIPv4, wantPort)
+	}
+}
+
+func TestParseZFSPoolNameRejectsForeignPool(t *testing.T) {
+	if _, _, _, err := parseZFSPoolName("rpool"); err == nil {
+		t.Error("parseZFSPoolName(\"rpool\") succeeded; want an error for a pool this driver didn't create")
+	}
+}
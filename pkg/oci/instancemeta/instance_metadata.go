@@ -18,24 +18,56 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 const (
-	baseURL          = "http://169.254.169.254"
-	metadataEndpoint = "/opc/v1/instance/"
+	baseURL                   = "http://169.254.169.254"
+	metadataEndpoint          = "/opc/v1/instance/"
+	volumeAttachmentsEndpoint = "/opc/v1/volumeAttachments/"
+
+	// requestTimeout bounds how long a single metadata request can take.
+	// 169.254.169.254 is only routable at all on an actual OCI instance;
+	// off one, the connection attempt doesn't get a quick "connection
+	// refused" back, it just hangs until the OS's own TCP timeout (minutes,
+	// depending on platform) - http.DefaultClient has no timeout of its
+	// own, so without this every caller on a non-OCI host would wait that
+	// long for each call instead of a few seconds.
+	requestTimeout = 5 * time.Second
 )
 
 // InstanceMetadata holds the subset of the instance metadata retrieved from the
 // local OCI instance metadata API endpoint.
 // https://docs.us-phoenix-1.oraclecloud.com/Content/Compute/Tasks/gettingmetadata.htm
 type InstanceMetadata struct {
-	CompartmentOCID string `json:"compartmentId"`
-	Region          string `json:"region"`
+	CompartmentOCID    string `json:"compartmentId"`
+	Region             string `json:"region"`
+	AvailabilityDomain string `json:"availabilityDomain"`
+}
+
+// VolumeAttachment holds the subset of a volume attachment's fields exposed
+// via the node-local "volume attachments" instance metadata endpoint. Unlike
+// the OCI API, this requires no credentials and only ever reflects
+// attachments belonging to the local instance.
+// https://docs.us-phoenix-1.oraclecloud.com/Content/Compute/Tasks/gettingmetadata.htm
+type VolumeAttachment struct {
+	AttachmentType string `json:"attachmentType"`
+	VolumeOCID     string `json:"volumeId"`
+	LifecycleState string `json:"lifecycleState"`
+	Iqn            string `json:"iqn"`
+	Ipv4           string `json:"ipv4"`
+	Port           int    `json:"port"`
 }
 
 // Interface defines how consumers access OCI instance metadata.
 type Interface interface {
 	Get() (*InstanceMetadata, error)
+
+	// GetVolumeAttachments returns the set of volume attachments known to the
+	// local instance, as reported by the node-local metadata endpoint. It
+	// requires no OCI API credentials and so is safe to call from worker
+	// nodes.
+	GetVolumeAttachments() ([]VolumeAttachment, error)
 }
 
 type metadataGetter struct {
@@ -46,7 +78,7 @@ type metadataGetter struct {
 // New returns the instance metadata for the host on which the code is being
 // executed.
 func New() Interface {
-	return &metadataGetter{client: http.DefaultClient, baseURL: baseURL}
+	return &metadataGetter{client: &http.Client{Timeout: requestTimeout}, baseURL: baseURL}
 }
 
 // Get either returns the cached metadata for the current instance or queries
@@ -75,3 +107,28 @@ func (m *metadataGetter) Get() (*InstanceMetadata, error) {
 
 	return md, nil
 }
+
+// GetVolumeAttachments queries the node-local "volume attachments" metadata
+// endpoint and returns the result.
+func (m *metadataGetter) GetVolumeAttachments() ([]VolumeAttachment, error) {
+	req, err := http.NewRequest("GET", m.baseURL+volumeAttachmentsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume attachments: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("volume attachments endpoint returned status %d; expected 200 OK", resp.StatusCode)
+	}
+
+	var attachments []VolumeAttachment
+	if err := json.NewDecoder(resp.Body).Decode(&attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
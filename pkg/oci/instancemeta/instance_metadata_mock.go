@@ -15,7 +15,9 @@
 package instancemeta
 
 type mockMetadataGetter struct {
-	metadata *InstanceMetadata
+	metadata    *InstanceMetadata
+	attachments []VolumeAttachment
+	err         error
 }
 
 // NewMock returns a new mock OCI instance metadata getter.
@@ -23,6 +25,29 @@ func NewMock(metadata *InstanceMetadata) Interface {
 	return &mockMetadataGetter{metadata: metadata}
 }
 
+// NewMockWithVolumeAttachments returns a new mock OCI instance metadata
+// getter that also serves the given volume attachments.
+func NewMockWithVolumeAttachments(metadata *InstanceMetadata, attachments []VolumeAttachment) Interface {
+	return &mockMetadataGetter{metadata: metadata, attachments: attachments}
+}
+
+// NewMockWithError returns a mock OCI instance metadata getter whose Get and
+// GetVolumeAttachments both fail with err, simulating a host with no
+// reachable instance metadata endpoint (i.e. not running on OCI at all).
+func NewMockWithError(err error) Interface {
+	return &mockMetadataGetter{err: err}
+}
+
 func (m *mockMetadataGetter) Get() (*InstanceMetadata, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
 	return m.metadata, nil
 }
+
+func (m *mockMetadataGetter) GetVolumeAttachments() ([]VolumeAttachment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.attachments, nil
+}
@@ -49,8 +49,9 @@ func TestGetMetadata(t *testing.T) {
 	}
 
 	expected := &InstanceMetadata{
-		CompartmentOCID: "ocid1.compartment.oc1..aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
-		Region:          "phx",
+		CompartmentOCID:    "ocid1.compartment.oc1..aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		Region:             "phx",
+		AvailabilityDomain: "NWuj:PHX-AD-1",
 	}
 	if !reflect.DeepEqual(meta, expected) {
 		t.Errorf("Get() => %+v, want %+v", meta, expected)
@@ -0,0 +1,313 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nulldriver implements a Flexvolume driver that simulates volume
+// attachment with a loop device backed by a sparse image file, instead of
+// talking to the OCI API and iSCSI. It exists to exercise the kubelet<->
+// driver JSON contract, locking, and plumbing changes end-to-end on a
+// developer laptop with no OCI access.
+package nulldriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/utils/exec"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/mount"
+)
+
+const (
+	mountCommand = "/bin/mount"
+	loopbackSize = 64 * 1024 * 1024 // 64MB is plenty for plumbing tests.
+)
+
+// NullFlexvolumeDriver implements the flexvolume.Driver interface using a
+// loop device in place of an OCI iSCSI volume attachment.
+type NullFlexvolumeDriver struct {
+	runner  exec.Interface
+	mounter mount.Interface
+}
+
+// New creates a new NullFlexvolumeDriver.
+func New() *NullFlexvolumeDriver {
+	return &NullFlexvolumeDriver{
+		runner:  exec.New(),
+		mounter: mount.New(mountCommand),
+	}
+}
+
+// GetStateDirectory gets the path under which backing image files for
+// simulated volumes are stored.
+func GetStateDirectory() string {
+	path := os.Getenv("OCI_NULLD_STATE_DIRECTORY")
+	if path == "" {
+		path = "/tmp/oci-null-flexvolume-driver"
+	}
+	return path
+}
+
+func imagePath(volumeName string) string {
+	return filepath.Join(GetStateDirectory(), volumeName+".img")
+}
+
+// attachDisabled reports whether this driver should advertise the "attach"
+// capability as false on init, overridable via OCI_NULLD_DISABLE_ATTACH -
+// the nulldriver's equivalent of the OCI driver's AttachDisabled, kept
+// separate since nulldriver doesn't share any config with it.
+func attachDisabled() bool {
+	return os.Getenv("OCI_NULLD_DISABLE_ATTACH") != ""
+}
+
+// Init creates the directory used to store backing image files.
+func (d *NullFlexvolumeDriver) Init() flexvolume.DriverStatus {
+	if err := os.MkdirAll(GetStateDirectory(), 0755); err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	status := flexvolume.Succeed()
+	status.Capabilities = map[string]bool{
+		"attach": !attachDisabled(),
+	}
+	return status
+}
+
+// Attach creates (if necessary) a backing image file for the volume and
+// attaches it to a loop device, returning the loop device path.
+func (d *NullFlexvolumeDriver) Attach(opts flexvolume.Options, nodeName string) flexvolume.DriverStatus {
+	img := imagePath(opts["kubernetes.io/pvOrVolumeName"])
+
+	if _, err := os.Stat(img); os.IsNotExist(err) {
+		if err := createBackingImage(img); err != nil {
+			return flexvolume.Fail(err)
+		}
+	}
+
+	device, err := d.attachLoopDevice(img)
+	if err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	return flexvolume.DriverStatus{Status: flexvolume.StatusSuccess, Device: device}
+}
+
+// Detach tears down the loop device associated with the volume, if any. The
+// underlying backing image file is left in place so that re-attaching
+// produces the same (possibly already formatted/mounted) volume.
+func (d *NullFlexvolumeDriver) Detach(pvOrVolumeName, nodeName string) flexvolume.DriverStatus {
+	device, err := d.findLoopDevice(imagePath(pvOrVolumeName))
+	if err != nil {
+		// Already detached.
+		return flexvolume.Succeed()
+	}
+	if out, err := d.runner.Command("losetup", "-d", device).CombinedOutput(); err != nil {
+		return flexvolume.Fail(fmt.Errorf("null: losetup -d failed: %v: %s", err, out))
+	}
+	return flexvolume.Succeed()
+}
+
+// WaitForAttach is a no-op; the loop device returned by Attach() is
+// immediately usable.
+func (d *NullFlexvolumeDriver) WaitForAttach(mountDevice string, _ flexvolume.Options) flexvolume.DriverStatus {
+	return flexvolume.DriverStatus{Status: flexvolume.StatusSuccess, Device: mountDevice}
+}
+
+// IsAttached reports whether a loop device currently exists for the volume.
+func (d *NullFlexvolumeDriver) IsAttached(opts flexvolume.Options, nodeName string) flexvolume.DriverStatus {
+	_, err := d.findLoopDevice(imagePath(opts["kubernetes.io/pvOrVolumeName"]))
+	return flexvolume.DriverStatus{
+		Status:   flexvolume.StatusSuccess,
+		Attached: err == nil,
+	}
+}
+
+// GetVolumeName returns the volume name this driver was given, unguarded by
+// any version check since there's no real kubelet/apiserver skew to worry
+// about when simulating attachment locally.
+func (d *NullFlexvolumeDriver) GetVolumeName(opts flexvolume.Options) flexvolume.DriverStatus {
+	return flexvolume.DriverStatus{
+		Status:     flexvolume.StatusSuccess,
+		VolumeName: opts["kubernetes.io/pvOrVolumeName"],
+	}
+}
+
+// ExpandVolume is a no-op; loop devices back onto a file that's already
+// however big the test asked it to be, with nothing to rescan.
+func (d *NullFlexvolumeDriver) ExpandVolume(devicePath string, opts flexvolume.Options, newSize, oldSize string) flexvolume.DriverStatus {
+	return flexvolume.Succeed()
+}
+
+// ExpandFS is a no-op for the same reason as ExpandVolume.
+func (d *NullFlexvolumeDriver) ExpandFS(devicePath, deviceMountPath string, opts flexvolume.Options, newSize, oldSize string) flexvolume.DriverStatus {
+	return flexvolume.Succeed()
+}
+
+// MountDevice formats (if necessary) and mounts the loop device.
+func (d *NullFlexvolumeDriver) MountDevice(mountDir, mountDevice string, opts flexvolume.Options) flexvolume.DriverStatus {
+	if isMounted, err := d.mounter.DeviceOpened(mountDevice); err != nil {
+		return flexvolume.Fail(err)
+	} else if isMounted {
+		return flexvolume.Succeed("Device already mounted. Nothing to do.")
+	}
+
+	options := []string{}
+	if opts[flexvolume.OptionReadWrite] == "ro" {
+		options = []string{"ro"}
+	}
+
+	formatAndMounter := &mount.SafeFormatAndMount{Interface: d.mounter, Runner: d.runner}
+	if err := formatAndMounter.FormatAndMount(mountDevice, mountDir, opts[flexvolume.OptionFSType], options, opts[flexvolume.OptionFsckBeforeMount] == "true"); err != nil {
+		return flexvolume.Fail(err)
+	}
+
+	return flexvolume.Succeed()
+}
+
+// UnmountDevice unmounts the mount path.
+func (d *NullFlexvolumeDriver) UnmountDevice(mountPath string) flexvolume.DriverStatus {
+	if err := mount.UnmountPath(mountPath, d.mounter); err != nil {
+		return flexvolume.Fail(err)
+	}
+	return flexvolume.Succeed()
+}
+
+// Mount implements the per-pod bind-mount flow used by a kubelet started
+// without --enable-controller-attach-detach, mirroring
+// OCIFlexvolumeDriver.Mount: it runs Attach, WaitForAttach and MountDevice
+// in turn, then records which volume it attached so a later Unmount() call
+// - which gets nothing but mountDir - can detach it again.
+func (d *NullFlexvolumeDriver) Mount(mountDir string, opts flexvolume.Options) flexvolume.DriverStatus {
+	attachStatus := d.Attach(opts, "")
+	if attachStatus.Status != flexvolume.StatusSuccess {
+		return attachStatus
+	}
+
+	waitStatus := d.WaitForAttach(attachStatus.Device, opts)
+	if waitStatus.Status != flexvolume.StatusSuccess {
+		return waitStatus
+	}
+
+	mountStatus := d.MountDevice(mountDir, waitStatus.Device, opts)
+	if mountStatus.Status == flexvolume.StatusSuccess {
+		entries := loadMountVolumeTracking()
+		entries[mountDir] = opts["kubernetes.io/pvOrVolumeName"]
+		saveMountVolumeTracking(entries)
+	}
+	return mountStatus
+}
+
+// Unmount is Mount's mirror image: it runs UnmountDevice followed by
+// Detach, recovering the volume Mount recorded for mountDir.
+func (d *NullFlexvolumeDriver) Unmount(mountDir string) flexvolume.DriverStatus {
+	unmountStatus := d.UnmountDevice(mountDir)
+	if unmountStatus.Status != flexvolume.StatusSuccess {
+		return unmountStatus
+	}
+
+	entries := loadMountVolumeTracking()
+	pvOrVolumeName, ok := entries[mountDir]
+	if !ok {
+		return unmountStatus
+	}
+	delete(entries, mountDir)
+	saveMountVolumeTracking(entries)
+
+	return d.Detach(pvOrVolumeName, "")
+}
+
+// mountVolumeTrackingPath returns the path of the on-disk map from mount
+// directory to volume name, recorded by Mount() so Unmount() - which only
+// gets mountDir - knows which volume to detach.
+func mountVolumeTrackingPath() string {
+	return filepath.Join(GetStateDirectory(), "mount-volumes.json")
+}
+
+// loadMountVolumeTracking reads the on-disk mount-to-volume map. A missing
+// or corrupt file is treated as empty rather than an error, matching the
+// best-effort tracking pattern this is modelled on (see
+// pkg/oci/driver/mount_tracking.go).
+func loadMountVolumeTracking() map[string]string {
+	entries := make(map[string]string)
+
+	b, err := ioutil.ReadFile(mountVolumeTrackingPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			loglevel.Warnf("null: failed to read mount volume tracking: %v", err)
+		}
+		return entries
+	}
+
+	if err := json.Unmarshal(b, &entries); err != nil {
+		loglevel.Warnf("null: failed to unmarshal mount volume tracking: %v", err)
+		return make(map[string]string)
+	}
+
+	return entries
+}
+
+// saveMountVolumeTracking persists the mount-to-volume map, best-effort.
+func saveMountVolumeTracking(entries map[string]string) {
+	b, err := json.Marshal(entries)
+	if err != nil {
+		loglevel.Warnf("null: failed to marshal mount volume tracking: %v", err)
+		return
+	}
+
+	if err := ioutil.WriteFile(mountVolumeTrackingPath(), b, 0600); err != nil {
+		loglevel.Warnf("null: failed to write mount volume tracking: %v", err)
+	}
+}
+
+func createBackingImage(path string) error {
+	loglevel.Infof("null: creating backing image %q", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Truncate(loopbackSize)
+}
+
+func (d *NullFlexvolumeDriver) attachLoopDevice(img string) (string, error) {
+	if device, err := d.findLoopDevice(img); err == nil {
+		return device, nil
+	}
+
+	out, err := d.runner.Command("losetup", "-f", "--show", img).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("null: losetup -f failed: %v: %s", err, out)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// findLoopDevice returns the loop device currently associated with img, if
+// any.
+func (d *NullFlexvolumeDriver) findLoopDevice(img string) (string, error) {
+	out, err := d.runner.Command("losetup", "-j", img).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("null: losetup -j failed: %v: %s", err, out)
+	}
+
+	line := strings.TrimSpace(string(out))
+	if line == "" {
+		return "", fmt.Errorf("null: no loop device found for %q", img)
+	}
+	return strings.SplitN(line, ":", 2)[0], nil
+}
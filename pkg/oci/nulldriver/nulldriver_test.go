@@ -0,0 +1,98 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nulldriver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestGetStateDirectory(t *testing.T) {
+	original := os.Getenv("OCI_NULLD_STATE_DIRECTORY")
+	defer os.Setenv("OCI_NULLD_STATE_DIRECTORY", original)
+
+	os.Unsetenv("OCI_NULLD_STATE_DIRECTORY")
+	if got := GetStateDirectory(); got != "/tmp/oci-null-flexvolume-driver" {
+		t.Errorf("GetStateDirectory() = %q; wanted default", got)
+	}
+
+	os.Setenv("OCI_NULLD_STATE_DIRECTORY", "/foo/bar")
+	if got := GetStateDirectory(); got != "/foo/bar" {
+		t.Errorf("GetStateDirectory() = %q; wanted %q", got, "/foo/bar")
+	}
+}
+
+func TestMountVolumeTrackingRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "null-mount-tracking")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv("OCI_NULLD_STATE_DIRECTORY")
+	defer os.Setenv("OCI_NULLD_STATE_DIRECTORY", original)
+	os.Setenv("OCI_NULLD_STATE_DIRECTORY", dir)
+
+	entries := loadMountVolumeTracking()
+	entries["/mnt/vol1"] = "my-volume"
+	saveMountVolumeTracking(entries)
+
+	reloaded := loadMountVolumeTracking()
+	if got := reloaded["/mnt/vol1"]; got != "my-volume" {
+		t.Errorf("loadMountVolumeTracking()[\"/mnt/vol1\"] = %q; wanted %q", got, "my-volume")
+	}
+
+	delete(reloaded, "/mnt/vol1")
+	saveMountVolumeTracking(reloaded)
+
+	if _, ok := loadMountVolumeTracking()["/mnt/vol1"]; ok {
+		t.Error("expected entry to be removed")
+	}
+}
+
+func TestInitCapabilitiesAttach(t *testing.T) {
+	original := os.Getenv("OCI_NULLD_DISABLE_ATTACH")
+	defer os.Setenv("OCI_NULLD_DISABLE_ATTACH", original)
+
+	dir, err := ioutil.TempDir("", "null-init")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("OCI_NULLD_STATE_DIRECTORY", dir)
+	defer os.Unsetenv("OCI_NULLD_STATE_DIRECTORY")
+
+	os.Unsetenv("OCI_NULLD_DISABLE_ATTACH")
+	d := New()
+	if caps := d.Init().Capabilities; !caps["attach"] {
+		t.Errorf(`Init().Capabilities["attach"] = false with OCI_NULLD_DISABLE_ATTACH unset; want true`)
+	}
+
+	os.Setenv("OCI_NULLD_DISABLE_ATTACH", "1")
+	if caps := d.Init().Capabilities; caps["attach"] {
+		t.Errorf(`Init().Capabilities["attach"] = true with OCI_NULLD_DISABLE_ATTACH set; want false`)
+	}
+}
+
+func TestImagePath(t *testing.T) {
+	os.Setenv("OCI_NULLD_STATE_DIRECTORY", "/foo/bar")
+	defer os.Unsetenv("OCI_NULLD_STATE_DIRECTORY")
+
+	expected := "/foo/bar/my-volume.img"
+	if got := imagePath("my-volume"); got != expected {
+		t.Errorf("imagePath() = %q; wanted %q", got, expected)
+	}
+}
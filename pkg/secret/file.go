@@ -0,0 +1,35 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// FileProvider resolves a ref to the trimmed contents of the local file at
+// that path, for a secret an operator has placed on the node directly (e.g.
+// distributed out-of-band by a config management tool) rather than passed
+// through a PV spec.
+type FileProvider struct{}
+
+// Get returns the trimmed contents of the file at ref.
+func (FileProvider) Get(ref string) (string, error) {
+	b, err := ioutil.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import "fmt"
+
+// OptionsProvider resolves a ref to a key already present in the call-out's
+// Options, i.e. a Kubernetes Secret the kubelet passed through the PV spec.
+// The kubelet base64-encodes these before exec'ing the driver; callers are
+// expected to have already run opts through flexvolume.DecodeKubeSecrets
+// before constructing an OptionsProvider, the same as every other consumer
+// of a "kubernetes.io/secret..." option.
+type OptionsProvider map[string]string
+
+// Get returns opts[ref], or an error if ref isn't present.
+func (opts OptionsProvider) Get(ref string) (string, error) {
+	v, ok := opts[ref]
+	if !ok {
+		return "", fmt.Errorf("secret: no option %q present", ref)
+	}
+	return v, nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secret abstracts where a key (a CHAP secret, a LUKS passphrase,
+// or anything else this driver needs at mount time but shouldn't have to
+// carry in a PV spec) actually comes from, so a feature that needs one can
+// depend on the Provider interface instead of picking a storage location
+// itself.
+package secret
+
+// Provider returns the plaintext secret referenced by ref, the meaning of
+// which is provider-specific (an Options key, a file path, a Vault secret
+// OCID).
+type Provider interface {
+	Get(ref string) (string, error)
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOptionsProvider(t *testing.T) {
+	opts := OptionsProvider{"kubernetes.io/secret/chap": "hunter2"}
+
+	got, err := opts.Get("kubernetes.io/secret/chap")
+	if err != nil {
+		t.Fatalf("Get() => %v, want nil", err)
+	}
+	if got != "hunter2" {
+		t.Errorf("Get() = %q, want %q", got, "hunter2")
+	}
+
+	if _, err := opts.Get("kubernetes.io/secret/missing"); err == nil {
+		t.Error("Get() on a missing key => nil error, want one")
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "chap-secret")
+	if err := ioutil.WriteFile(path, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FileProvider{}.Get(path)
+	if err != nil {
+		t.Fatalf("Get() => %v, want nil", err)
+	}
+	if got != "hunter2" {
+		t.Errorf("Get() = %q, want %q", got, "hunter2")
+	}
+
+	if _, err := (FileProvider{}).Get(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Get() on a missing file => nil error, want one")
+	}
+}
+
+func TestVaultProviderIsUnimplemented(t *testing.T) {
+	if _, err := (VaultProvider{}).Get("ocid1.vaultsecret.oc1.phx.aaaaaa"); err == nil {
+		t.Error("Get() => nil error, want one (no vendored secrets service client)")
+	}
+}
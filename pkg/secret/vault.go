@@ -0,0 +1,33 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import "fmt"
+
+// VaultProvider resolves a ref interpreted as an OCI Vault secret OCID, so a
+// key can live in Vault instead of a PV spec or a node-local file. It is
+// currently unimplemented: the oci-go-sdk version vendored into this tree
+// doesn't include a secrets (Vault) service client, the way pkg/oci/client
+// already has to degrade AttachVolume's encryptInTransit/readOnly/shareable
+// options for the same reason (see its doc comment). Get always fails until
+// that client is vendored; it exists now so the Provider interface has
+// somewhere for a Vault-backed implementation to land without every caller
+// changing again.
+type VaultProvider struct{}
+
+// Get always returns an error: see the type doc comment.
+func (VaultProvider) Get(ref string) (string, error) {
+	return "", fmt.Errorf("secret: Vault-backed secrets are not supported by this build (OCID %q); the vendored OCI SDK has no secrets service client", ref)
+}
@@ -0,0 +1,202 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package udev manages udev rules that give attached OCI volumes a stable
+// /dev/oci/<volume-ocid> symlink, so that kernel-assigned device names (which
+// can be renumbered or otherwise change across attach/detach cycles) don't
+// break the driver's mount bookkeeping.
+package udev
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/utils/exec"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/nsenter"
+)
+
+const (
+	udevadmCommand = "udevadm"
+
+	// RulesDir is where this package writes the udev rule files it manages.
+	RulesDir = "/etc/udev/rules.d"
+
+	// SymlinkDir is the directory under /dev in which stable, OCID-keyed
+	// symlinks to attached volumes are created.
+	SymlinkDir = "/dev/oci"
+)
+
+// Interface manages the udev rules backing the /dev/oci/<volume-ocid>
+// symlinks.
+type Interface interface {
+	// EnsureSymlink installs (or replaces) the udev rule that symlinks the
+	// iSCSI-attached disk identified by ipv4, port and iqn to
+	// SymlinkPath(volumeOCID) and reloads udev so the rule takes effect on
+	// the disk's next add event. It returns the symlink path; the disk (and
+	// so the symlink) won't necessarily exist yet - callers that need to
+	// block until it does should wait on the returned path themselves, the
+	// same way they already wait for any other device path to appear.
+	EnsureSymlink(volumeOCID, ipv4 string, port int, iqn string) (string, error)
+
+	// RemoveSymlink removes the udev rule installed by EnsureSymlink for
+	// volumeOCID, if any, and re-triggers udev so the symlink itself is
+	// cleaned up.
+	RemoveSymlink(volumeOCID string) error
+
+	// RemoveSymlinkForDevice finds whichever /dev/oci/<volume-ocid> symlink
+	// (if any) resolves to the same device as devicePath and removes it, for
+	// callers (e.g. UnmountDevice) that know the real device being detached
+	// but not the volume OCID it was keyed on.
+	RemoveSymlinkForDevice(devicePath string) error
+
+	// PruneStaleSymlinks removes every rule installed by EnsureSymlink whose
+	// symlink no longer resolves to a device - by-path scan residue left
+	// behind when a node is rebooted or crashes between Detach() and the
+	// matching UnmountDevice() RemoveSymlinkForDevice call, which otherwise
+	// has no way to run. It returns the number of rules removed.
+	PruneStaleSymlinks() (int, error)
+}
+
+// manager implements Interface.
+type manager struct {
+	runner exec.Interface
+}
+
+// New creates a new udev rule Interface.
+func New() Interface {
+	return &manager{runner: nsenter.MaybeWrap(exec.New())}
+}
+
+// SymlinkPath returns the stable device path for the given volume OCID.
+func SymlinkPath(volumeOCID string) string {
+	return filepath.Join(SymlinkDir, volumeOCID)
+}
+
+// rulePath returns the path of the rule file this package owns for
+// volumeOCID. OCIDs are already filesystem-safe (they only contain
+// alphanumerics, '.', and '-'), so no further sanitisation is needed.
+func rulePath(volumeOCID string) string {
+	return filepath.Join(RulesDir, fmt.Sprintf("99-oci-%s.rules", volumeOCID))
+}
+
+func (m *manager) EnsureSymlink(volumeOCID, ipv4 string, port int, iqn string) (string, error) {
+	idPath := fmt.Sprintf("ip-%s:%d-iscsi-%s-lun-1", ipv4, port, iqn)
+	symlink := strings.TrimPrefix(SymlinkPath(volumeOCID), "/dev/")
+	rule := fmt.Sprintf(
+		`SUBSYSTEM=="block", ENV{ID_PATH}=="%s", SYMLINK+="%s"`+"\n",
+		idPath, symlink,
+	)
+
+	if err := ioutil.WriteFile(rulePath(volumeOCID), []byte(rule), 0644); err != nil {
+		return "", fmt.Errorf("writing udev rule for %q: %v", volumeOCID, err)
+	}
+
+	if err := m.reload(); err != nil {
+		return "", err
+	}
+
+	return SymlinkPath(volumeOCID), nil
+}
+
+func (m *manager) RemoveSymlink(volumeOCID string) error {
+	err := os.Remove(rulePath(volumeOCID))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing udev rule for %q: %v", volumeOCID, err)
+	}
+
+	return m.reload()
+}
+
+func (m *manager) RemoveSymlinkForDevice(devicePath string) error {
+	target, err := filepath.EvalSymlinks(devicePath)
+	if err != nil {
+		return fmt.Errorf("resolving %q: %v", devicePath, err)
+	}
+
+	entries, err := ioutil.ReadDir(SymlinkDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("listing %q: %v", SymlinkDir, err)
+	}
+
+	for _, entry := range entries {
+		symlink := filepath.Join(SymlinkDir, entry.Name())
+		if candidate, err := filepath.EvalSymlinks(symlink); err == nil && candidate == target {
+			return m.RemoveSymlink(entry.Name())
+		}
+	}
+
+	return nil
+}
+
+// rulePrefix/ruleSuffix bound the OCID embedded in a rule's filename by
+// rulePath, so PruneStaleSymlinks can recover it without parsing the rule
+// file's contents.
+const (
+	rulePrefix = "99-oci-"
+	ruleSuffix = ".rules"
+)
+
+func (m *manager) PruneStaleSymlinks() (int, error) {
+	entries, err := ioutil.ReadDir(RulesDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("listing %q: %v", RulesDir, err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, rulePrefix) || !strings.HasSuffix(name, ruleSuffix) {
+			continue
+		}
+		volumeOCID := strings.TrimSuffix(strings.TrimPrefix(name, rulePrefix), ruleSuffix)
+
+		if _, err := filepath.EvalSymlinks(SymlinkPath(volumeOCID)); err == nil {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(RulesDir, name)); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("removing stale udev rule %q: %v", name, err)
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		if err := m.reload(); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+func (m *manager) reload() error {
+	if _, err := m.runner.Command(udevadmCommand, "control", "--reload-rules").CombinedOutput(); err != nil {
+		return fmt.Errorf("reloading udev rules: %v", err)
+	}
+	if out, err := m.runner.Command(udevadmCommand, "trigger").CombinedOutput(); err != nil {
+		loglevel.Warnf("udevadm trigger: %v: %s", err, out)
+		return fmt.Errorf("triggering udev: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package udev
+
+import (
+	"path/filepath"
+	"testing"
+
+	"k8s.io/utils/exec"
+	fakeexec "k8s.io/utils/exec/testing"
+)
+
+func newFakeManager() *manager {
+	noOutput := func(cmd string, args ...string) exec.Cmd {
+		return &fakeexec.FakeCmd{CombinedOutputScript: []fakeexec.FakeCombinedOutputAction{
+			func() ([]byte, error) { return nil, nil },
+		}}
+	}
+	fake := &fakeexec.FakeExec{
+		CommandScript: []fakeexec.FakeCommandAction{noOutput, noOutput},
+	}
+	return &manager{runner: fake}
+}
+
+func TestRulePath(t *testing.T) {
+	got := rulePath("ocid1.volume.oc1.phx.aaaa")
+	want := filepath.Join(RulesDir, "99-oci-ocid1.volume.oc1.phx.aaaa.rules")
+	if got != want {
+		t.Errorf("rulePath() = %q; want %q", got, want)
+	}
+}
+
+func TestRemoveSymlinkMissingRuleIsNotAnError(t *testing.T) {
+	m := newFakeManager()
+	if err := m.RemoveSymlink("ocid1.volume.oc1.phx.does-not-exist"); err != nil {
+		t.Errorf("RemoveSymlink() on a missing rule => %v; want nil", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	m := newFakeManager()
+	if err := m.reload(); err != nil {
+		t.Errorf("reload() => %v; want nil", err)
+	}
+}
+
+func TestPruneStaleSymlinksNoRulesDirIsNotAnError(t *testing.T) {
+	m := newFakeManager()
+	removed, err := m.PruneStaleSymlinks()
+	if err != nil {
+		t.Errorf("PruneStaleSymlinks() => err %v; want nil", err)
+	}
+	if removed != 0 {
+		t.Errorf("PruneStaleSymlinks() => removed %d; want 0", removed)
+	}
+}
+
+func TestSymlinkPath(t *testing.T) {
+	got := SymlinkPath("ocid1.volume.oc1.phx.aaaa")
+	want := "/dev/oci/ocid1.volume.oc1.phx.aaaa"
+	if got != want {
+		t.Errorf("SymlinkPath() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version holds the driver's build identity. Version, Build, and
+// GitCommit are set at build time via the Makefile's -ldflags, the same way
+// regardless of which cmd/ binary or target platform is being built, so
+// that every driver entrypoint reports a consistent version string.
+package version
+
+var (
+	// Version is the driver release version, or the git describe output
+	// when building outside a tagged release.
+	Version string
+	// Build is the git describe output identifying the exact source tree
+	// a binary was built from.
+	Build string
+	// GitCommit is the full git SHA of the source tree a binary was built
+	// from.
+	GitCommit string
+)
@@ -0,0 +1,126 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watch polls one or more node-local state sources (e.g. the
+// driver's on-disk mount tracking or IsAttached cache) and emits their
+// changes as structured, newline-delimited JSON events, so an operator can
+// tail real-time attachment/mount activity for this node instead of
+// repeatedly polling the OCI console during an incident.
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Event is a single change emitted by Run, written as one line of JSON.
+type Event struct {
+	Time   time.Time   `json:"time"`
+	Kind   string      `json:"kind"`
+	Action string      `json:"action"` // "added", "changed" or "removed"
+	Key    string      `json:"key"`
+	Detail interface{} `json:"detail,omitempty"`
+}
+
+// Source is one thing Run polls for state, keyed by an
+// implementation-defined identifier (e.g. a mount directory or volume
+// OCID). Values are compared by their JSON encoding, so anything
+// JSON-marshalable works.
+type Source struct {
+	Kind string
+	Poll func() (map[string]interface{}, error)
+}
+
+// Run polls every source in sources once immediately, then every interval,
+// emitting one Event to w per added, changed, or removed key, until stop is
+// closed. It returns the first polling error encountered.
+func Run(w io.Writer, sources []Source, interval time.Duration, now func() time.Time, stop <-chan struct{}) error {
+	prev := make([]map[string]string, len(sources))
+
+	if err := pollOnce(w, sources, prev, now); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			if err := pollOnce(w, sources, prev, now); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pollOnce polls every source, updates prev in place to the latest
+// encoded snapshot, and emits an Event to w for every difference found:
+// per source, added and changed keys first and then removed ones, each in
+// key order.
+func pollOnce(w io.Writer, sources []Source, prev []map[string]string, now func() time.Time) error {
+	for i, src := range sources {
+		state, err := src.Poll()
+		if err != nil {
+			return fmt.Errorf("polling %s: %v", src.Kind, err)
+		}
+
+		encoded := make(map[string]string, len(state))
+		for key, value := range state {
+			b, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("encoding %s %q: %v", src.Kind, key, err)
+			}
+			encoded[key] = string(b)
+		}
+
+		keys := make([]string, 0, len(encoded))
+		for key := range encoded {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if old, ok := prev[i][key]; !ok {
+				emit(w, now(), src.Kind, "added", key, state[key])
+			} else if old != encoded[key] {
+				emit(w, now(), src.Kind, "changed", key, state[key])
+			}
+		}
+		var removed []string
+		for key := range prev[i] {
+			if _, ok := encoded[key]; !ok {
+				removed = append(removed, key)
+			}
+		}
+		sort.Strings(removed)
+		for _, key := range removed {
+			emit(w, now(), src.Kind, "removed", key, nil)
+		}
+
+		prev[i] = encoded
+	}
+	return nil
+}
+
+func emit(w io.Writer, t time.Time, kind, action, key string, detail interface{}) {
+	b, err := json.Marshal(Event{Time: t, Kind: kind, Action: action, Key: key, Detail: detail})
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(w, string(b))
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunEmitsAddedChangedAndRemoved(t *testing.T) {
+	states := []map[string]interface{}{
+		{"a": "1"},
+		{"a": "2", "b": "1"},
+		{"b": "1"},
+	}
+	poll := 0
+	source := Source{
+		Kind: "test",
+		Poll: func() (map[string]interface{}, error) {
+			state := states[poll]
+			poll++
+			return state, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	stop := make(chan struct{})
+	ticks := make(chan time.Time)
+	now := func() time.Time { return time.Unix(0, 0) }
+
+	go func() {
+		ticks <- time.Unix(0, 0)
+		ticks <- time.Unix(0, 0)
+		close(stop)
+	}()
+
+	if err := runWithTicks(&buf, []Source{source}, now, ticks, stop); err != nil {
+		t.Fatalf("Run() => %v; want nil", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var events []Event
+	for _, line := range lines {
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("could not unmarshal event %q: %v", line, err)
+		}
+		events = append(events, e)
+	}
+
+	want := []struct{ action, key string }{
+		{"added", "a"},
+		{"changed", "a"},
+		{"added", "b"},
+		{"removed", "a"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events; want %d: %v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		if events[i].Action != w.action || events[i].Key != w.key {
+			t.Errorf("event %d = {%s %s}; want {%s %s}", i, events[i].Action, events[i].Key, w.action, w.key)
+		}
+	}
+}
+
+// runWithTicks is Run with an injectable tick channel instead of a real
+// ticker, so the test can drive polls deterministically.
+func runWithTicks(w *bytes.Buffer, sources []Source, now func() time.Time, ticks <-chan time.Time, stop <-chan struct{}) error {
+	prev := make([]map[string]string, len(sources))
+	if err := pollOnce(w, sources, prev, now); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticks:
+			if err := pollOnce(w, sources, prev, now); err != nil {
+				return err
+			}
+		}
+	}
+}
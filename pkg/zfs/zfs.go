@@ -0,0 +1,133 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zfs manages a zpool built on a single attached iSCSI device, for
+// PVs that set kubernetes.io/fsType: zfs to get ZFS's snapshots and
+// compression at the node level instead of a plain ext4/xfs filesystem via
+// pkg/mount. It shells out to zpool the same way pkg/iscsi shells out to
+// iscsiadm: no cgo bindings to libzfs, and no assumption that the node
+// running it has ZFS support built into this binary rather than installed
+// as a host package.
+package zfs
+
+import (
+	"fmt"
+
+	"k8s.io/utils/exec"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/loglevel"
+	"github.com/oracle/oci-flexvolume-driver/pkg/mount"
+	"github.com/oracle/oci-flexvolume-driver/pkg/nsenter"
+)
+
+const (
+	zpoolCommand = "zpool"
+	mountCommand = "/bin/mount"
+
+	// fsType is the kubernetes.io/fsType value that selects this handler
+	// instead of pkg/mount's generic mkfs.<fstype>-based formatAndMount.
+	fsType = "zfs"
+)
+
+// FSType is exported so driver.MountDevice/UnmountDevice can recognise a
+// PV that wants a zpool instead of a kubernetes.io/fsType this driver
+// passes straight through to mkfs.
+const FSType = fsType
+
+// Interface creates, imports, and exports a zpool spanning a single
+// attached block device.
+type Interface interface {
+	// CreateOrImportPool makes device's content available at mountpoint
+	// as the zpool named poolName: importing the pool if device already
+	// holds one (the common case for a kubelet retry, or a remount after a
+	// driver restart), or creating a fresh single-disk pool otherwise.
+	CreateOrImportPool(device, poolName, mountpoint string) error
+
+	// ExportPool exports (and so unmounts) the named pool - the zfs
+	// counterpart to iscsi.Interface's UnmountPath for an ordinary
+	// filesystem.
+	ExportPool(poolName string) error
+
+	// PoolForMountpoint returns the name of the pool mounted at
+	// mountpoint, or an error if no zfs pool is mounted there. UnmountDevice only gets a mount path from the
+	// kubelet, not the pool name CreateOrImportPool chose, so it recovers
+	// the pool name this way instead of persisting its own state.
+	PoolForMountpoint(mountpoint string) (string, error)
+}
+
+type zpoolManager struct {
+	runner  exec.Interface
+	mounter mount.Interface
+}
+
+// New creates a new zpool manager.
+func New() Interface {
+	return &zpoolManager{
+		runner:  nsenter.MaybeWrap(exec.New()),
+		mounter: mount.New(mountCommand),
+	}
+}
+
+func (z *zpoolManager) zpool(parts ...string) (string, error) {
+	zpoolPath, err := z.runner.LookPath(zpoolCommand)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := z.runner.Command(zpoolPath, parts...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("zfs: %s %v: %v: %s", zpoolCommand, parts, err, output)
+	}
+	return string(output), nil
+}
+
+func (z *zpoolManager) CreateOrImportPool(device, poolName, mountpoint string) error {
+	if _, err := z.zpool("list", poolName); err == nil {
+		loglevel.Debugf("zfs: pool %q already imported", poolName)
+		return nil
+	}
+
+	if _, err := z.zpool("import", "-d", device, poolName); err == nil {
+		loglevel.Infof("zfs: imported existing pool %q from %q", poolName, device)
+		return nil
+	}
+
+	loglevel.Infof("zfs: creating pool %q on %q, mounted at %q", poolName, device, mountpoint)
+	if _, err := z.zpool("create", "-m", mountpoint, poolName, device); err != nil {
+		return fmt.Errorf("zfs: error creating pool %q on %q: %v", poolName, device, err)
+	}
+	return nil
+}
+
+func (z *zpoolManager) ExportPool(poolName string) error {
+	if _, err := z.zpool("export", poolName); err != nil {
+		return fmt.Errorf("zfs: error exporting pool %q: %v", poolName, err)
+	}
+	return nil
+}
+
+func (z *zpoolManager) PoolForMountpoint(mountpoint string) (string, error) {
+	mountPoints, err := z.mounter.List()
+	if err != nil {
+		return "", err
+	}
+
+	for _, mp := range mountPoints {
+		if mp.Path == mountpoint && mp.Type == fsType {
+			return mp.Device, nil
+		}
+	}
+	return "", fmt.Errorf("zfs: no %s pool mounted at %q", fsType, mountpoint)
+}
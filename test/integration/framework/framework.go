@@ -16,19 +16,31 @@ package framework
 
 import (
 	"errors"
+	"log"
 	"os"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/client"
 	"github.com/oracle/oci-flexvolume-driver/pkg/oci/driver"
+	"github.com/oracle/oci-flexvolume-driver/pkg/oci/instancemeta"
 )
 
+// provisionedVolumeDisplayName is the display name given to volumes the
+// framework provisions for the duration of a test run.
+const provisionedVolumeDisplayName = "oci-flexvolume-driver-integration-test"
+
 // Framework used to help with integration testing.
 type Framework struct {
 	VolumeName string
 	NodeName   string
 	NodeOCID   string
+
+	// client and provisionedVolumeID are set when the framework provisions
+	// its own test volume, so that Cleanup() can tear it down again.
+	client              client.Interface
+	provisionedVolumeID string
 }
 
 // New testing framework.
@@ -60,14 +72,44 @@ func (f *Framework) NewDriver() *driver.OCIFlexvolumeDriver {
 	return d
 }
 
-// Init the framework.
+// Init the framework. If VOLUME_NAME is unset, a test volume is provisioned
+// via the OCI API in the config's auth compartment and the Availability
+// Domain of the host running the suite, so that it can be run by anyone with credentials
+// without first hand-provisioning a volume out of band.
 func (f *Framework) Init() error {
-	if f.VolumeName == "" {
-		return errors.New("VOLUME_NAME env var unset")
-	}
 	if f.NodeOCID == "" {
 		return errors.New("NODE_OCID env var unset")
 	}
+
+	if f.VolumeName != "" {
+		return nil
+	}
+
+	c, err := client.New(driver.GetConfigPath())
+	if err != nil {
+		return err
+	}
+	f.client = c
+
+	meta, err := instancemeta.New().Get()
+	if err != nil {
+		return err
+	}
+
+	volume, err := c.CreateVolume(meta.AvailabilityDomain, c.GetConfig().Auth.CompartmentOCID, provisionedVolumeDisplayName)
+	if err != nil {
+		return err
+	}
+
+	volume, err = c.WaitForVolumeAvailable(*volume.Id)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("framework: provisioned test volume %s", *volume.Id)
+	f.provisionedVolumeID = *volume.Id
+	f.VolumeName = *volume.Id
+
 	return nil
 }
 
@@ -76,6 +118,12 @@ func (f *Framework) Run(run func() int) {
 	os.Exit(run())
 }
 
-// Cleanup afterwards.
+// Cleanup deletes any test volume provisioned by Init().
 func (f *Framework) Cleanup() {
+	if f.provisionedVolumeID == "" {
+		return
+	}
+	if err := f.client.DeleteVolume(f.provisionedVolumeID); err != nil {
+		log.Printf("framework: failed to delete provisioned test volume %s: %v", f.provisionedVolumeID, err)
+	}
 }
@@ -21,8 +21,8 @@ import (
 	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
 )
 
-// TestIdempotent checks that Attach, MountDevice, and UnmountDevice are
-// idempotent and (currently) that Detach is **not** idempotent.
+// TestIdempotent checks that Attach, MountDevice, UnmountDevice and Detach
+// are all idempotent.
 func TestIdempotent(t *testing.T) {
 	d := fw.NewDriver()
 	opts := flexvolume.Options{
@@ -94,9 +94,9 @@ func TestIdempotent(t *testing.T) {
 	}
 	t.Logf("Detach(): %+v", res)
 
-	// Detaching the volume again is **NOT** idempotent and errors...
+	// Detaching again is no-op and does not error...
 	res = d.Detach(fw.VolumeName, fw.NodeName)
-	if res.Status != flexvolume.StatusFailure {
+	if res.Status != flexvolume.StatusSuccess {
 		t.Fatalf("Failed to Detach(): %+v", res)
 	}
 	t.Logf("Detach(): %+v", res)
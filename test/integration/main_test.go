@@ -30,7 +30,10 @@ func TestMain(m *testing.M) {
 		log.Fatal(err)
 	}
 
-	fw.Run(m.Run)
+	fw.Run(func() int {
+		defer fw.Cleanup()
+		return m.Run()
+	})
 }
 
 func init() {
@@ -0,0 +1,192 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeletcompat
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// fakeOCIServer stands in for the OCI Compute and Block Storage API surface
+// client.AttachVolume, client.FindVolumeAttachment and client.DetachVolume
+// actually call: just enough of GetInstance, AttachVolume,
+// ListVolumeAttachments, GetVolumeAttachment, GetVolume and DetachVolume to
+// take a volume from unattached to ATTACHED and back, entirely in memory.
+// It is not a faithful reimplementation of the OCI API - e.g. it never
+// returns a 409 on a second attach - only enough of it to drive the real
+// client code these call-outs exercise end to end.
+type fakeOCIServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	state string // "", "ATTACHED" or "DETACHED"
+}
+
+const (
+	fakeInstanceID     = "ocid1.instance.oc1.phx.fakeinstance"
+	fakeAttachmentID   = "ocid1.volumeattachment.oc1.phx.fakeattachment"
+	fakeAttachmentIqn  = "iqn.2015-12.com.oracle.fake:fakevolume"
+	fakeAttachmentIP   = "169.254.2.2"
+	fakeAttachmentPort = 3260
+
+	// fakePVShortName is deliberately not an OCID: DeriveVolumeOCID turns
+	// it into fakeAttachmentVolumeOCID below (see pkg/oci/driver's
+	// DeriveVolumeOCID and the config.yaml regionKey this harness writes),
+	// which is also what WaitForAttach uses to decide whether to try the
+	// instance metadata endpoint first - a short name skips that and
+	// always falls through to the Device string Attach() handed off,
+	// which is the path this harness actually wants to exercise rather
+	// than waiting out a call to an unreachable 169.254.169.254.
+	fakePVShortName = "fakevolume"
+
+	// fakeAttachmentVolumeOCID is what DeriveVolumeOCID("phx",
+	// fakePVShortName) produces; it must match the volumeId the fake OCI
+	// server's single tracked attachment carries below.
+	fakeAttachmentVolumeOCID = "ocid1.volume.oc1.phx." + fakePVShortName
+)
+
+func newFakeOCIServer() *fakeOCIServer {
+	s := &fakeOCIServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *fakeOCIServer) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/instances/"):
+		fmt.Fprintf(w, `{
+			"id": %q,
+			"availabilityDomain": "fake-ad-1",
+			"compartmentId": "ocid1.compartment.oc1..fakecompartment",
+			"lifecycleState": "RUNNING",
+			"region": "phx",
+			"shape": "VM.Standard2.1",
+			"timeCreated": "2020-01-01T00:00:00.000Z"
+		}`, fakeInstanceID)
+
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/volumeAttachments"):
+		s.mu.Lock()
+		s.state = "ATTACHED"
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, s.attachmentJSON())
+
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/volumeAttachments"):
+		s.mu.Lock()
+		attached := s.state == "ATTACHED"
+		s.mu.Unlock()
+		if attached {
+			fmt.Fprintf(w, "[%s]", s.attachmentJSON())
+		} else {
+			fmt.Fprint(w, "[]")
+		}
+
+	case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/volumeAttachments/"):
+		fmt.Fprint(w, s.attachmentJSON())
+
+	// volumeCompartment (see pkg/oci/client) calls this to resolve the
+	// volume's own compartment before FindVolumeAttachment lists its
+	// attachments. Only fakeAttachmentVolumeOCID - the one volume this
+	// fake tracks - exists; anything else 404s, same as a real volume
+	// that's since been deleted.
+	case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/volumes/"):
+		volumeID := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if volumeID != fakeAttachmentVolumeOCID {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"code": "NotFound", "message": "kubelet-compat fake OCI server has no volume %s"}`, volumeID)
+			return
+		}
+		fmt.Fprintf(w, `{
+			"id": %q,
+			"availabilityDomain": "fake-ad-1",
+			"compartmentId": "ocid1.compartment.oc1..fakecompartment",
+			"lifecycleState": "AVAILABLE",
+			"sizeInGBs": 50,
+			"timeCreated": "2020-01-01T00:00:00.000Z"
+		}`, volumeID)
+
+	case r.Method == http.MethodDelete && strings.Contains(r.URL.Path, "/volumeAttachments/"):
+		s.mu.Lock()
+		s.state = "DETACHED"
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"code": "NotFound", "message": "kubelet-compat fake OCI server has no handler for %s %s"}`, r.Method, r.URL.Path)
+	}
+}
+
+// attachmentJSON renders the single volume attachment this fake tracks as
+// an IScsiVolumeAttachment (see core.volumeattachment's "iscsi"
+// discriminator), in whatever lifecycle state it's currently in.
+func (s *fakeOCIServer) attachmentJSON() string {
+	s.mu.Lock()
+	state := s.state
+	s.mu.Unlock()
+	if state == "" {
+		state = "ATTACHING"
+	}
+	return fmt.Sprintf(`{
+		"attachmentType": "iscsi",
+		"availabilityDomain": "fake-ad-1",
+		"compartmentId": "ocid1.compartment.oc1..fakecompartment",
+		"id": %q,
+		"instanceId": %q,
+		"volumeId": %q,
+		"timeCreated": "2020-01-01T00:00:00.000Z",
+		"lifecycleState": %q,
+		"ipv4": %q,
+		"iqn": %q,
+		"port": %d
+	}`, fakeAttachmentID, fakeInstanceID, fakeAttachmentVolumeOCID, state, fakeAttachmentIP, fakeAttachmentIqn, fakeAttachmentPort)
+}
+
+// fakeAPIServer stands in for just enough of the kube-apiserver that
+// lookupNodeID and capabilities (see pkg/oci/driver) need: a version
+// endpoint that reports whatever kubelet/apiserver version the test wants
+// to simulate, and a single Node with spec.providerID set so the OCID
+// resolution Attach/Detach depend on has something to resolve.
+type fakeAPIServer struct {
+	*httptest.Server
+	gitVersion string
+}
+
+func newFakeAPIServer(gitVersion, nodeName, providerID string) *fakeAPIServer {
+	s := &fakeAPIServer{gitVersion: gitVersion}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/version":
+			fmt.Fprintf(w, `{"gitVersion": %q}`, s.gitVersion)
+		case r.URL.Path == "/api/v1/nodes/"+nodeName:
+			fmt.Fprintf(w, `{
+				"apiVersion": "v1",
+				"kind": "Node",
+				"metadata": {"name": %q},
+				"spec": {"providerID": %q}
+			}`, nodeName, providerID)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"kind": "Status", "status": "Failure", "code": 404, "message": "kubelet-compat fake apiserver has no handler for %s"}`, r.URL.Path)
+		}
+	}))
+	return s
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeletcompat
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+)
+
+// newFakePrivateKeyPEM generates a throwaway RSA key and PEM-encodes it, the
+// way a real config.yaml's auth.key would be. The OCI Go SDK's signer
+// parses this at call time to sign every request; it never needs to
+// validate against anything the fake OCI server checks, since the fake
+// server accepts every request regardless of its Authorization header.
+func newFakePrivateKeyPEM(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating fake auth key: %v", err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	return string(pem.EncodeToMemory(block))
+}
+
+// writeConfig writes a config.yaml pointing at ociServer for every OCI
+// service endpoint this driver uses, alongside a kubeconfig pointing at
+// apiserver, into dir - together, the pair of files that make
+// NewOCIFlexvolumeDriver treat this as a master node (see GetConfigPath)
+// talking to both fakes instead of the real OCI API and a real cluster.
+func writeConfig(t *testing.T, dir string, ociServer *fakeOCIServer, apiserver *fakeAPIServer) string {
+	t.Helper()
+
+	configPath := filepath.Join(dir, "config.yaml")
+	config := fmt.Sprintf(`
+auth:
+  region: us-phoenix-1
+  regionKey: phx
+  tenancy: ocid1.tenancy.oc1..faketenancy
+  compartment: ocid1.compartment.oc1..fakecompartment
+  user: ocid1.user.oc1..fakeuser
+  fingerprint: "aa:bb:cc:dd:ee:ff:00:11:22:33:44:55:66:77:88:99"
+  vcn: ocid1.vcn.oc1.phx.fakevcn
+  key: |
+%s
+endpoints:
+  compute: %s
+  blockstorage: %s
+`, indent(newFakePrivateKeyPEM(t), "    "), ociServer.URL, ociServer.URL)
+	if err := ioutil.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatalf("writing config.yaml: %v", err)
+	}
+
+	kubeconfigPath := filepath.Join(dir, "kubeconfig")
+	kubeconfig := fmt.Sprintf(`
+apiVersion: v1
+kind: Config
+clusters:
+- name: fake
+  cluster:
+    server: %s
+contexts:
+- name: fake
+  context: {cluster: fake}
+current-context: fake
+`, apiserver.URL)
+	if err := ioutil.WriteFile(kubeconfigPath, []byte(kubeconfig), 0600); err != nil {
+		t.Fatalf("writing kubeconfig: %v", err)
+	}
+
+	return configPath
+}
+
+func indent(s, prefix string) string {
+	out := ""
+	for _, line := range splitLines(s) {
+		out += prefix + line + "\n"
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	start := 0
+	for i, r := range s {
+		if r == '\n' {
+			lines = append(lines, s[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(s) {
+		lines = append(lines, s[start:])
+	}
+	return lines
+}
+
+// runCallout execs the binary built by TestMain exactly as the kubelet
+// would - "oci -config <configPath> <calloutArgs...>" - and parses its
+// stdout as a flexvolume.DriverStatus.
+func runCallout(t *testing.T, configPath string, calloutArgs ...string) flexvolume.DriverStatus {
+	t.Helper()
+
+	args := append([]string{"-config", configPath}, calloutArgs...)
+	cmd := exec.Command(binPath, args...)
+	cmd.Env = append(os.Environ(), "OCI_FLEXD_DRIVER_LOG_DIR="+filepath.Dir(configPath))
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			t.Fatalf("running %s %v: %v", binPath, args, err)
+		}
+	}
+
+	var status flexvolume.DriverStatus
+	if err := json.Unmarshal(out, &status); err != nil {
+		t.Fatalf("parsing %s %v output %q: %v", binPath, args, out, err)
+	}
+	return status
+}
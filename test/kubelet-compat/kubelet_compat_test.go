@@ -0,0 +1,162 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeletcompat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oracle/oci-flexvolume-driver/pkg/flexvolume"
+	"github.com/oracle/oci-flexvolume-driver/pkg/iscsi"
+)
+
+// fakeProviderID is bare, not "oci://"-prefixed: lookupNodeID (see
+// pkg/oci/driver/driver.go) passes spec.providerID straight through to the
+// OCI API as an instance OCID with no scheme-stripping, so that's what this
+// fixture has to provide.
+const (
+	fakeNodeName   = "fake-node"
+	fakeProviderID = fakeInstanceID
+)
+
+// kubeletProfile is a simulated kubelet/apiserver version, named for the
+// behaviour that actually differs at that version rather than the version
+// number itself: whether the apiserver is new enough for getvolumename to
+// be safe (see getVolumeNameMinVersion in pkg/oci/driver/compat.go). The
+// attach/detach call-out sequence below it is unaffected by kubelet
+// version; the only version-sensitive behaviour this driver has is
+// getvolumename, so that's what these profiles exercise.
+type kubeletProfile struct {
+	name              string
+	apiserverVersion  string
+	wantGetVolumeName bool
+}
+
+var kubeletProfiles = []kubeletProfile{
+	{name: "pre-1.6.5", apiserverVersion: "v1.6.4", wantGetVolumeName: false},
+	{name: "1.9.0", apiserverVersion: "v1.9.0", wantGetVolumeName: true},
+}
+
+// TestKubeletCompatibilityAcrossVersions drives the built oci binary
+// through the same call-out sequence a kubelet/KCM issues to attach and
+// then detach a volume - init, attach, waitforattach, detach - once per
+// simulated kubelet/apiserver version in kubeletProfiles, against a fake
+// OCI API server and a fake apiserver. It's a real exec of the compiled
+// binary, not an in-process call: a bug that only shows up once the
+// process is actually started (e.g. a flag default or an env var read at
+// startup) would be invisible to pkg/oci/driver's own unit tests but not
+// to this.
+func TestKubeletCompatibilityAcrossVersions(t *testing.T) {
+	for _, profile := range kubeletProfiles {
+		t.Run(profile.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "kubelet-compat")
+			if err != nil {
+				t.Fatalf("creating temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			ociServer := newFakeOCIServer()
+			defer ociServer.Close()
+			apiserver := newFakeAPIServer(profile.apiserverVersion, fakeNodeName, fakeProviderID)
+			defer apiserver.Close()
+
+			configPath := writeConfig(t, dir, ociServer, apiserver)
+
+			initStatus := runCallout(t, configPath, "init")
+			if initStatus.Status != flexvolume.StatusSuccess {
+				t.Fatalf("init: got status %q, message %q; want %q", initStatus.Status, initStatus.Message, flexvolume.StatusSuccess)
+			}
+			if got := initStatus.Capabilities["getVolumeName"]; got != profile.wantGetVolumeName {
+				t.Errorf("init: capabilities[getVolumeName] = %t; want %t for apiserver %s", got, profile.wantGetVolumeName, profile.apiserverVersion)
+			}
+
+			opts := `{"kubernetes.io/pvOrVolumeName": "` + fakePVShortName + `", "kubernetes.io/fsType": "ext4"}`
+
+			attachStatus := runCallout(t, configPath, "attach", opts, fakeNodeName)
+			if attachStatus.Status != flexvolume.StatusSuccess {
+				t.Fatalf("attach: got status %q, message %q; want %q", attachStatus.Status, attachStatus.Message, flexvolume.StatusSuccess)
+			}
+			if attachStatus.Device == "" {
+				t.Fatal("attach: got empty Device; want an attachment handoff string")
+			}
+
+			waitStatus := runCallout(t, configPath, "waitforattach", attachStatus.Device, opts)
+			if waitStatus.Status != flexvolume.StatusSuccess {
+				t.Fatalf("waitforattach: got status %q, message %q; want %q", waitStatus.Status, waitStatus.Message, flexvolume.StatusSuccess)
+			}
+
+			detachStatus := runCallout(t, configPath, "detach", fakePVShortName, fakeNodeName)
+			if detachStatus.Status != flexvolume.StatusSuccess {
+				t.Fatalf("detach: got status %q, message %q; want %q", detachStatus.Status, detachStatus.Message, flexvolume.StatusSuccess)
+			}
+		})
+	}
+}
+
+// TestMountDeviceLifecycle extends the attach/detach sequence above with
+// mountdevice/unmountdevice, which - unlike every other call-out this
+// package drives - shell out to the real iscsiadm/mount/mkfs on the host to
+// log in to the iSCSI target Attach() handed off and format and mount it.
+// There's no fake for that layer here, so this only runs where iscsid is
+// actually reachable; everywhere else it skips with an explanation rather
+// than failing on an environment this driver was never going to be able to
+// touch.
+func TestMountDeviceLifecycle(t *testing.T) {
+	if err := iscsi.CheckDaemon(); err != nil {
+		t.Skipf("skipping: no reachable iscsid on this host: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "kubelet-compat-mount")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ociServer := newFakeOCIServer()
+	defer ociServer.Close()
+	apiserver := newFakeAPIServer("v1.9.0", fakeNodeName, fakeProviderID)
+	defer apiserver.Close()
+
+	configPath := writeConfig(t, dir, ociServer, apiserver)
+
+	if status := runCallout(t, configPath, "init"); status.Status != flexvolume.StatusSuccess {
+		t.Fatalf("init: got status %q, message %q; want %q", status.Status, status.Message, flexvolume.StatusSuccess)
+	}
+
+	opts := `{"kubernetes.io/pvOrVolumeName": "` + fakePVShortName + `", "kubernetes.io/fsType": "ext4"}`
+
+	attachStatus := runCallout(t, configPath, "attach", opts, fakeNodeName)
+	if attachStatus.Status != flexvolume.StatusSuccess {
+		t.Fatalf("attach: got status %q, message %q; want %q", attachStatus.Status, attachStatus.Message, flexvolume.StatusSuccess)
+	}
+
+	mountDir := filepath.Join(dir, "mount")
+	mountStatus := runCallout(t, configPath, "mountdevice", mountDir, attachStatus.Device, opts)
+	if mountStatus.Status != flexvolume.StatusSuccess {
+		t.Fatalf("mountdevice: got status %q, message %q; want %q", mountStatus.Status, mountStatus.Message, flexvolume.StatusSuccess)
+	}
+
+	unmountStatus := runCallout(t, configPath, "unmountdevice", mountDir)
+	if unmountStatus.Status != flexvolume.StatusSuccess {
+		t.Fatalf("unmountdevice: got status %q, message %q; want %q", unmountStatus.Status, unmountStatus.Message, flexvolume.StatusSuccess)
+	}
+
+	detachStatus := runCallout(t, configPath, "detach", fakePVShortName, fakeNodeName)
+	if detachStatus.Status != flexvolume.StatusSuccess {
+		t.Fatalf("detach: got status %q, message %q; want %q", detachStatus.Status, detachStatus.Message, flexvolume.StatusSuccess)
+	}
+}
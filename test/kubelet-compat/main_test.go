@@ -0,0 +1,59 @@
+// Copyright 2017 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kubeletcompat drives the actual built "oci" binary exactly as the
+// kubelet does - by exec'ing it with the same call-out names and argument
+// shapes flexvolume.ExecDriver switches on - against a fake OCI API server
+// and a fake apiserver, across a handful of simulated kubelet/apiserver
+// versions. Unlike test/integration, which links this repo's driver code
+// directly into the test binary and calls it in-process, every assertion
+// here is made by parsing the DriverStatus a separately exec'd process
+// printed to stdout, so a bug that only shows up in the real binary (a
+// flag default, an env var read at process start, an init-time panic) has
+// somewhere to be caught.
+package kubeletcompat
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// binPath is the "oci" binary built once by TestMain and exec'd by every
+// test in this package.
+var binPath string
+
+func TestMain(m *testing.M) {
+	os.Exit(func() int {
+		dir, err := ioutil.TempDir("", "kubelet-compat-bin")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "kubelet-compat: creating temp dir:", err)
+			return 1
+		}
+		defer os.RemoveAll(dir)
+
+		binPath = filepath.Join(dir, "oci")
+		cmd := exec.Command("go", "build", "-o", binPath, "github.com/oracle/oci-flexvolume-driver/cmd/oci")
+		cmd.Env = append(os.Environ(), "GO111MODULE=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			fmt.Fprintf(os.Stderr, "kubelet-compat: building oci binary: %v\n%s", err, out)
+			return 1
+		}
+
+		return m.Run()
+	}())
+}